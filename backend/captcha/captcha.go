@@ -0,0 +1,111 @@
+// This is a file in the package-"captcha" that contains the Verifier interface.
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"relief_exchange_backend/config"
+	"strings"
+)
+
+// Actions a CAPTCHA can be completed for.
+// reCAPTCHA v3 tokens record the action they were made for, so a token for one can't be used for another.
+const (
+	ActionSignup = "signup"
+	ActionDonate = "donate"
+	ActionReport = "report"
+)
+
+// Errors returned when a CAPTCHA isn't passed. They all wrap ErrFailed.
+var (
+	ErrFailed         = errors.New("captcha failed")
+	ErrMissingToken   = fmt.Errorf("%w: no token provided", ErrFailed)
+	ErrInvalidToken   = fmt.Errorf("%w: invalid or expired token", ErrFailed)
+	ErrLowScore       = fmt.Errorf("%w: score too low", ErrFailed)
+	ErrActionMismatch = fmt.Errorf("%w: token was made for a different action", ErrFailed)
+)
+
+// Result is what a provider said about a CAPTCHA token.
+type Result struct {
+	Score  float64 // Between 0 for a bot and 1 for a human, only given by score based CAPTCHAs
+	Action string  // The action the token was made for, only given by reCAPTCHA v3
+}
+
+// Verifier checks CAPTCHA tokens made by a client.
+type Verifier interface {
+	// Verify checks a token made for an action. It returns an error wrapping ErrFailed if the
+	// CAPTCHA wasn't passed, or another error if the provider couldn't be reached.
+	Verify(ctx context.Context, token string, action string, remoteIP string) (Result, error)
+}
+
+// New creates the verifier for the provider in the config.
+// reCAPTCHA v3 is used instead of v2 when a minimum score is set.
+// Parameters:
+//   - cfg: the CAPTCHA settings.
+//
+// Return values:
+//   - the verifier.
+//   - error, if the provider is unknown.
+func New(cfg config.CAPTCHAConfig) (Verifier, error) {
+	switch cfg.Provider {
+	case "recaptcha":
+		return NewReCAPTCHA(cfg.ReCAPTCHASecret, cfg.ReCAPTCHAMinScore), nil
+	case "hcaptcha":
+		return NewHCaptcha(cfg.HCaptchaSecret, cfg.HCaptchaSiteKey), nil
+	case "stub":
+		return Stub{}, nil
+	default:
+		return nil, fmt.Errorf("unknown captcha provider %q", cfg.Provider)
+	}
+}
+
+// siteVerifyResponse is the response of the siteverify endpoints of reCAPTCHA and hCaptcha, which share a format.
+type siteVerifyResponse struct {
+	Success    bool     `json:"success"`
+	Score      float64  `json:"score"`
+	Action     string   `json:"action"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+// siteVerify sends a token to a provider's siteverify endpoint.
+// The form is sent as the body of a POST request, so the secret never ends up in a URL.
+func siteVerify(ctx context.Context, client *http.Client, endpoint string, form url.Values) (siteVerifyResponse, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return siteVerifyResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return siteVerifyResponse{}, fmt.Errorf("failed sending captcha token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return siteVerifyResponse{}, fmt.Errorf("captcha provider responded with %s", resp.Status)
+	}
+
+	var body siteVerifyResponse
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return siteVerifyResponse{}, fmt.Errorf("failed decoding captcha response: %w", err)
+	}
+	return body, nil
+}
+
+// verificationForm builds the form sent to a siteverify endpoint.
+func verificationForm(secret string, token string, remoteIP string) url.Values {
+	form := url.Values{}
+	form.Set("secret", secret)
+	form.Set("response", token)
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	return form
+}
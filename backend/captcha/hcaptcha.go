@@ -0,0 +1,52 @@
+// @cite "Developer Guide." hCaptcha, 2023. [Online].
+// Available: https://docs.hcaptcha.com/#verify-the-user-response-server-side. [Accessed: 19- October- 2026].
+// This is a file in the package-"captcha" that contains the HCaptcha verifier.
+package captcha
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// hCaptchaVerifyURL is where hCaptcha tokens are checked
+const hCaptchaVerifyURL = "https://api.hcaptcha.com/siteverify"
+
+// HCaptcha verifies hCaptcha tokens.
+type HCaptcha struct {
+	Secret     string
+	SiteKey    string // Optional, checks the token was made for this site
+	VerifyURL  string // Where tokens are checked, hCaptcha's endpoint if empty
+	HTTPClient *http.Client
+}
+
+// NewHCaptcha creates an HCaptcha verifier.
+func NewHCaptcha(secret string, siteKey string) *HCaptcha {
+	return &HCaptcha{Secret: secret, SiteKey: siteKey}
+}
+
+// Verify checks an hCaptcha token, see Verifier.
+// hCaptcha doesn't record actions, so any token made for the site is accepted for every action.
+func (h *HCaptcha) Verify(ctx context.Context, token string, action string, remoteIP string) (Result, error) {
+	if token == "" {
+		return Result{}, ErrMissingToken
+	}
+
+	form := verificationForm(h.Secret, token, remoteIP)
+	if h.SiteKey != "" {
+		form.Set("sitekey", h.SiteKey)
+	}
+	verifyURL := h.VerifyURL
+	if verifyURL == "" {
+		verifyURL = hCaptchaVerifyURL
+	}
+	body, err := siteVerify(ctx, h.HTTPClient, verifyURL, form)
+	if err != nil {
+		return Result{}, err
+	}
+	if !body.Success {
+		return Result{}, fmt.Errorf("%w (%s)", ErrInvalidToken, strings.Join(body.ErrorCodes, ", "))
+	}
+	return Result{Score: body.Score}, nil
+}
@@ -0,0 +1,60 @@
+// @cite "Verifying the user's response." Google for Developers, 2023. [Online].
+// Available: https://developers.google.com/recaptcha/docs/verify. [Accessed: 19- October- 2026].
+// This is a file in the package-"captcha" that contains the ReCAPTCHA verifier.
+package captcha
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// reCAPTCHAVerifyURL is where reCAPTCHA tokens are checked
+const reCAPTCHAVerifyURL = "https://www.google.com/recaptcha/api/siteverify"
+
+// ReCAPTCHA verifies Google reCAPTCHA tokens.
+// With MinScore set, tokens are treated as v3 ones, which need a high enough score and the right action.
+// Without it, they're treated as v2 checkbox ones, which only pass or fail.
+type ReCAPTCHA struct {
+	Secret     string
+	MinScore   float64
+	VerifyURL  string // Where tokens are checked, Google's endpoint if empty
+	HTTPClient *http.Client
+}
+
+// NewReCAPTCHA creates a ReCAPTCHA verifier. Use a minScore of 0 for reCAPTCHA v2.
+func NewReCAPTCHA(secret string, minScore float64) *ReCAPTCHA {
+	return &ReCAPTCHA{Secret: secret, MinScore: minScore}
+}
+
+// Verify checks a reCAPTCHA token, see Verifier.
+func (r *ReCAPTCHA) Verify(ctx context.Context, token string, action string, remoteIP string) (Result, error) {
+	if token == "" {
+		return Result{}, ErrMissingToken
+	}
+
+	verifyURL := r.VerifyURL
+	if verifyURL == "" {
+		verifyURL = reCAPTCHAVerifyURL
+	}
+	body, err := siteVerify(ctx, r.HTTPClient, verifyURL, verificationForm(r.Secret, token, remoteIP))
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{Score: body.Score, Action: body.Action}
+	if !body.Success {
+		return result, fmt.Errorf("%w (%s)", ErrInvalidToken, strings.Join(body.ErrorCodes, ", "))
+	}
+
+	// Scores and actions are only sent for v3 tokens
+	if r.MinScore > 0 {
+		if body.Score < r.MinScore {
+			return result, ErrLowScore
+		}
+		if action != "" && body.Action != action {
+			return result, ErrActionMismatch
+		}
+	}
+	return result, nil
+}
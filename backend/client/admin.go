@@ -0,0 +1,64 @@
+// This is a file in the package-"client" that contains the methods only admins can use, for bans and roles.
+package client
+
+import (
+	"context"
+	"net/http"
+	"relief_exchange_backend/types"
+	"time"
+)
+
+// BanUser bans a user, removing their donations and blocking their images from being posted again.
+// Parameters:
+//   - ctx: the context of the request.
+//   - userUID: the user to ban.
+//   - reason: why they were banned, which is shown to them.
+//   - expiresAt: when the ban ends, nil for a permanent ban.
+func (c *Client) BanUser(ctx context.Context, userUID string, reason string, expiresAt *time.Time) error {
+	body := struct {
+		Reason    string     `json:"reason"`
+		ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	}{reason, expiresAt}
+	req, err := jsonRequest(http.MethodPost, userPath(userUID, "ban"), body)
+	if err != nil {
+		return err
+	}
+	_, err = c.do(ctx, req, nil)
+	return err
+}
+
+// GrantRole gives a user one of the Role constants.
+// Return values:
+//   - the user's roles now.
+//   - error, which matches ErrBadRequest if it isn't a role.
+func (c *Client) GrantRole(ctx context.Context, userUID string, role string) ([]string, error) {
+	req, err := jsonRequest(http.MethodPost, userPath(userUID, "roles"), map[string]string{"role": role})
+	if err != nil {
+		return nil, err
+	}
+	return c.changeRoles(ctx, req)
+}
+
+// RevokeRole takes a role from a user.
+// Return values:
+//   - the user's roles now.
+//   - error, which matches ErrConflict if they're the last admin.
+func (c *Client) RevokeRole(ctx context.Context, userUID string, role string) ([]string, error) {
+	return c.changeRoles(ctx, request{method: http.MethodDelete, path: userPath(userUID, "roles", role)})
+}
+
+// changeRoles sends a change to a user's roles, and gets the roles they have afterwards.
+func (c *Client) changeRoles(ctx context.Context, req request) ([]string, error) {
+	var body struct {
+		Roles []string `json:"roles"`
+	}
+	_, err := c.do(ctx, req, &body)
+	return body.Roles, err
+}
+
+// ListPrivilegedUsers gets every user with a role.
+func (c *Client) ListPrivilegedUsers(ctx context.Context) ([]types.PrivilegedUser, error) {
+	var users []types.PrivilegedUser
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/v1/admin/users"}, &users)
+	return users, err
+}
@@ -0,0 +1,28 @@
+// This is a file in the package-"client" that contains the method for checking CAPTCHAs.
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// CAPTCHAResult is whether a CAPTCHA was passed.
+type CAPTCHAResult struct {
+	Human bool    `json:"human"`
+	Score float64 `json:"score"` // Between 0 for a bot and 1 for a human, only given by score based CAPTCHAs
+}
+
+// VerifyCAPTCHA checks a CAPTCHA token before sending the request it's for. Requests that need one check it again.
+// Parameters:
+//   - ctx: the context of the request.
+//   - token: the CAPTCHA token.
+//   - action: what the CAPTCHA was completed for, e.g. "donate".
+func (c *Client) VerifyCAPTCHA(ctx context.Context, token string, action string) (*CAPTCHAResult, error) {
+	query := url.Values{"token": {token}, "action": {action}}
+	var result CAPTCHAResult
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: "/v1/captcha/verify", query: query}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
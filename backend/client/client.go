@@ -0,0 +1,251 @@
+// Package client is a Go client for the Relief Exchange API, for internal tools and partner scripts.
+// It calls the /v1 routes, signs requests in with a TokenSource, retries requests that were
+// rate limited or failed on the server, and returns errors that can be matched with errors.Is.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// captchaHeader carries the CAPTCHA token of requests that need one
+	captchaHeader = "X-CAPTCHA-Token"
+	// requestIDHeader carries the ID the server gave a request, which it logs errors with
+	requestIDHeader = "X-Request-ID"
+)
+
+// TokenSource gets the Firebase ID token requests are signed in with.
+// ID tokens expire after an hour, so long running tools should refresh it rather than use a StaticToken.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenSource that always signs in with the same ID token.
+type StaticToken string
+
+// Token gets the ID token.
+func (t StaticToken) Token(ctx context.Context) (string, error) {
+	return string(t), nil
+}
+
+// Options are the settings of a Client. Anything left unset gets a default.
+type Options struct {
+	HTTPClient *http.Client  // Defaults to a client with a 1 minute timeout
+	Token      TokenSource   // Requests aren't signed in if it isn't set
+	MaxRetries int           // How many times a request is retried, defaults to 3. Use a negative number to never retry.
+	MinBackoff time.Duration // How long to wait before the first retry, defaults to 500ms, doubling after each one
+	MaxBackoff time.Duration // The longest to wait between retries, defaults to 30s
+	UserAgent  string
+}
+
+// Client calls the Relief Exchange API. It's safe to use from several goroutines at once.
+type Client struct {
+	baseURL *url.URL
+	options Options
+}
+
+// New creates a Client.
+// Parameters:
+//   - baseURL: where the backend is served, e.g. "https://api.example.org".
+//   - options: the settings of the Client.
+//
+// Return values:
+//   - the Client.
+//   - error, if the base URL isn't valid.
+func New(baseURL string, options Options) (*Client, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: it needs a scheme and host", baseURL)
+	}
+
+	if options.HTTPClient == nil {
+		options.HTTPClient = &http.Client{Timeout: time.Minute}
+	}
+	if options.MaxRetries == 0 {
+		options.MaxRetries = 3
+	}
+	if options.MinBackoff == 0 {
+		options.MinBackoff = 500 * time.Millisecond
+	}
+	if options.MaxBackoff == 0 {
+		options.MaxBackoff = 30 * time.Second
+	}
+	if options.UserAgent == "" {
+		options.UserAgent = "relief-exchange-go-client"
+	}
+	return &Client{baseURL: parsed, options: options}, nil
+}
+
+// request is a call to the API, kept so it can be sent again when it's retried.
+type request struct {
+	method      string
+	path        string // Escaped, e.g. "/v1/donations/" + url.PathEscape(id)
+	query       url.Values
+	body        []byte
+	contentType string
+	captcha     string // Sent as the CAPTCHA header if it isn't empty
+}
+
+// jsonRequest creates a request with a JSON body, or no body if it's nil.
+func jsonRequest(method string, path string, body any) (request, error) {
+	req := request{method: method, path: path}
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return request{}, fmt.Errorf("failed encoding request: %w", err)
+		}
+		req.body = data
+		req.contentType = "application/json"
+	}
+	return req, nil
+}
+
+// do sends a request, retrying it if it was rate limited or failed on the server, and decodes the response into out.
+// Parameters:
+//   - ctx: the context of the request, which stops the retries when it's done.
+//   - req: the request.
+//   - out: what the JSON response is decoded into, nil to ignore the response.
+//
+// Return values:
+//   - the status of the response.
+//   - error, an *APIError if the server sent an error, or the reason the request couldn't be made.
+func (c *Client) do(ctx context.Context, req request, out any) (int, error) {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if out != nil {
+		if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed decoding %s %s response: %w", req.method, req.path, err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// send sends a request until it succeeds, fails for good or runs out of retries.
+// The caller has to close the body of the returned response, which always has a successful status.
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.sendOnce(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode < http.StatusBadRequest {
+			return resp, nil
+		}
+
+		apiErr := newAPIError(resp)
+		resp.Body.Close()
+		if attempt >= c.options.MaxRetries || !retryable(req.method, resp.StatusCode) {
+			return nil, apiErr
+		}
+
+		wait := c.backoff(attempt)
+		if apiErr.RetryAfter > wait {
+			wait = apiErr.RetryAfter
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("gave up retrying %s %s: %w", req.method, req.path, apiErr)
+		case <-timer.C:
+		}
+	}
+}
+
+// sendOnce sends a request once.
+func (c *Client) sendOnce(ctx context.Context, req request) (*http.Response, error) {
+	target := c.baseURL.String() + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating %s %s request: %w", req.method, req.path, err)
+	}
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+	if req.captcha != "" {
+		httpReq.Header.Set(captchaHeader, req.captcha)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", c.options.UserAgent)
+	if c.options.Token != nil {
+		token, err := c.options.Token.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed getting ID token: %w", err)
+		}
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.options.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed sending %s %s: %w", req.method, req.path, err)
+	}
+	return resp, nil
+}
+
+// retryable checks if a request that failed with a status can be sent again.
+// Rate limited requests were never handled, so they always can be. Server errors can only be retried
+// for methods that do the same thing when sent twice, since e.g. a donation may have been posted before it failed.
+func retryable(method string, status int) bool {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return true
+	case status >= http.StatusInternalServerError:
+		// Every PATCH of the API sets values rather than changing them, so sending one twice is fine
+		return method == http.MethodGet || method == http.MethodPatch || method == http.MethodDelete
+	default:
+		return false
+	}
+}
+
+// backoff gets how long to wait before a retry, doubling each attempt with some jitter so clients don't retry in step.
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.options.MinBackoff << attempt
+	if wait <= 0 || wait > c.options.MaxBackoff {
+		wait = c.options.MaxBackoff
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// parseRetryAfter reads a Retry-After header, which the server sends as a number of seconds.
+func parseRetryAfter(header string) time.Duration {
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
+
+// Ready checks that the server is ready for traffic.
+// Return values:
+//   - error, which matches ErrServer if it isn't, e.g. while it's shutting down.
+func (c *Client) Ready(ctx context.Context) error {
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/readyz"}, nil)
+	return err
+}
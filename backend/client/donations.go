@@ -0,0 +1,167 @@
+// This is a file in the package-"client" that contains the methods for donations and their images.
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"relief_exchange_backend/types"
+)
+
+// donationPath gets the path of a donation, or of something under it if there's more.
+func donationPath(donationID string, more ...string) string {
+	path := "/v1/donations/" + url.PathEscape(donationID)
+	for _, part := range more {
+		path += "/" + url.PathEscape(part)
+	}
+	return path
+}
+
+// ListDonations gets every donation.
+func (c *Client) ListDonations(ctx context.Context) ([]types.Donation, error) {
+	var donations []types.Donation
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/v1/donations"}, &donations)
+	return donations, err
+}
+
+// GetDonation gets a donation by its ID.
+func (c *Client) GetDonation(ctx context.Context, donationID string) (*types.Donation, error) {
+	var donation types.Donation
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: donationPath(donationID)}, &donation); err != nil {
+		return nil, err
+	}
+	return &donation, nil
+}
+
+// AddDonation posts a donation as the signed in user.
+// Parameters:
+//   - ctx: the context of the request.
+//   - donation: the donation to post, whose images are uploaded with UploadImage first and given by ID.
+//   - captchaToken: the token of a CAPTCHA completed for the donate action, empty if the server doesn't check them.
+//
+// Return values:
+//   - the ID of the donation.
+//   - true if it was merged into one the user already posted, whose ID is returned instead.
+//   - error, which matches ErrConflict if it was rejected as a duplicate.
+func (c *Client) AddDonation(ctx context.Context, donation types.Donation, captchaToken string) (string, bool, error) {
+	req, err := jsonRequest(http.MethodPost, "/v1/donations", donation)
+	if err != nil {
+		return "", false, err
+	}
+	req.captcha = captchaToken
+
+	var donationID string
+	status, err := c.do(ctx, req, &donationID)
+	if err != nil {
+		return "", false, err
+	}
+	return donationID, status == http.StatusOK, nil
+}
+
+// EditDonation replaces a donation. Only its owner or an admin can edit it.
+func (c *Client) EditDonation(ctx context.Context, donationID string, donation types.Donation) error {
+	req, err := jsonRequest(http.MethodPatch, donationPath(donationID), donation)
+	if err != nil {
+		return err
+	}
+	_, err = c.do(ctx, req, nil)
+	return err
+}
+
+// DeleteDonation deletes a donation. Only its owner or an admin can delete it.
+func (c *Client) DeleteDonation(ctx context.Context, donationID string) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: donationPath(donationID)}, nil)
+	return err
+}
+
+// ReportDonation reports a donation as the signed in user.
+// Parameters:
+//   - ctx: the context of the request.
+//   - donationID: the donation to report.
+//   - captchaToken: the token of a CAPTCHA completed for the report action, empty if the server doesn't check them.
+//
+// Return values:
+//   - error, which matches ErrConflict if the user already reported it.
+func (c *Client) ReportDonation(ctx context.Context, donationID string, captchaToken string) error {
+	req := request{method: http.MethodPost, path: donationPath(donationID, "reports"), captcha: captchaToken}
+	_, err := c.do(ctx, req, nil)
+	return err
+}
+
+// UploadImage uploads an image as the signed in user, so it can be added to their donations.
+// Parameters:
+//   - ctx: the context of the request.
+//   - filename: the name of the file, which is only used for its extension.
+//   - image: the contents of a JPEG, PNG or WebP image, which are read into memory so the upload can be retried.
+//
+// Return values:
+//   - the stored image, with the URL of each of its sizes.
+//   - error, which matches ErrTooLarge or ErrUnsupportedImage if the image can't be used.
+func (c *Client) UploadImage(ctx context.Context, filename string, image io.Reader) (*types.Image, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("image", filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating upload: %w", err)
+	}
+	if _, err = io.Copy(part, image); err != nil {
+		return nil, fmt.Errorf("failed reading image: %w", err)
+	}
+	if err = form.Close(); err != nil {
+		return nil, fmt.Errorf("failed creating upload: %w", err)
+	}
+
+	req := request{method: http.MethodPost, path: "/v1/images", body: body.Bytes(), contentType: form.FormDataContentType()}
+	var uploaded types.Image
+	if _, err = c.do(ctx, req, &uploaded); err != nil {
+		return nil, err
+	}
+	return &uploaded, nil
+}
+
+// AddDonationImage adds an image the signed in user uploaded to a donation.
+// Parameters:
+//   - ctx: the context of the request.
+//   - donationID: the donation to add it to.
+//   - imageID: the ID of the uploaded image.
+//   - altText: describes the image for people using screen readers.
+//
+// Return values:
+//   - the donation's images, in the order they're shown.
+//   - error, if the image couldn't be added.
+func (c *Client) AddDonationImage(ctx context.Context, donationID string, imageID string, altText string) ([]types.DonationImage, error) {
+	body := map[string]string{"image_id": imageID, "alt": altText}
+	return c.changeDonationImages(ctx, http.MethodPost, donationPath(donationID, "images"), body)
+}
+
+// ReorderDonationImages changes the order of a donation's images, given the ID of every one of them in their new order.
+func (c *Client) ReorderDonationImages(ctx context.Context, donationID string, imageIDs []string) ([]types.DonationImage, error) {
+	body := map[string][]string{"image_ids": imageIDs}
+	return c.changeDonationImages(ctx, http.MethodPatch, donationPath(donationID, "images"), body)
+}
+
+// SetDonationImageAlt changes the alt text of one of a donation's images.
+func (c *Client) SetDonationImageAlt(ctx context.Context, donationID string, imageID string, altText string) ([]types.DonationImage, error) {
+	body := map[string]string{"alt": altText}
+	return c.changeDonationImages(ctx, http.MethodPatch, donationPath(donationID, "images", imageID), body)
+}
+
+// RemoveDonationImage removes an image from a donation.
+func (c *Client) RemoveDonationImage(ctx context.Context, donationID string, imageID string) ([]types.DonationImage, error) {
+	return c.changeDonationImages(ctx, http.MethodDelete, donationPath(donationID, "images", imageID), nil)
+}
+
+// changeDonationImages sends a change to a donation's images, and gets the images it has afterwards.
+func (c *Client) changeDonationImages(ctx context.Context, method string, path string, body any) ([]types.DonationImage, error) {
+	req, err := jsonRequest(method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	var images []types.DonationImage
+	_, err = c.do(ctx, req, &images)
+	return images, err
+}
@@ -0,0 +1,89 @@
+// This is a file in the package-"client" that contains the errors the API responds with.
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// The errors an *APIError matches with errors.Is, one for each status the server responds with
+var (
+	ErrBadRequest         = errors.New("invalid request")                      // 400
+	ErrUnauthorized       = errors.New("not signed in")                        // 401
+	ErrForbidden          = errors.New("not allowed")                          // 403, including invalid tokens, bans and failed CAPTCHAs
+	ErrNotFound           = errors.New("not found")                            // 404
+	ErrConflict           = errors.New("conflicts with what's already there")  // 409, e.g. duplicate listings or reports
+	ErrGone               = errors.New("no longer available")                  // 410, e.g. expired data exports
+	ErrTooLarge           = errors.New("too large")                            // 413
+	ErrUnsupportedImage   = errors.New("unsupported type of image")            // 415
+	ErrRateLimited        = errors.New("rate limited")                         // 429
+	ErrServer             = errors.New("server error")                         // 500
+	ErrCAPTCHAUnavailable = errors.New("CAPTCHA provider couldn't be reached") // 502
+	ErrTimeout            = errors.New("request took too long")                // 504
+)
+
+// statusErrors maps the statuses the server responds with to the errors they match
+var statusErrors = map[int]error{
+	http.StatusBadRequest:            ErrBadRequest,
+	http.StatusUnauthorized:          ErrUnauthorized,
+	http.StatusForbidden:             ErrForbidden,
+	http.StatusNotFound:              ErrNotFound,
+	http.StatusConflict:              ErrConflict,
+	http.StatusGone:                  ErrGone,
+	http.StatusRequestEntityTooLarge: ErrTooLarge,
+	http.StatusUnsupportedMediaType:  ErrUnsupportedImage,
+	http.StatusTooManyRequests:       ErrRateLimited,
+	http.StatusInternalServerError:   ErrServer,
+	http.StatusBadGateway:            ErrCAPTCHAUnavailable,
+	http.StatusGatewayTimeout:        ErrTimeout,
+}
+
+// APIError is an error response from the server.
+// It matches the Err variable of its status with errors.Is, e.g. errors.Is(err, client.ErrNotFound).
+type APIError struct {
+	StatusCode int
+	Message    string        // The error the server sent
+	RequestID  string        // What the server logged the request as, for finding it in the logs
+	RetryAfter time.Duration // How long to wait before trying again, if the request was rate limited
+}
+
+// newAPIError reads the error a server responded with.
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get(requestIDHeader),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+	var body struct {
+		Error string `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(data, &body) == nil && body.Error != "" {
+		apiErr.Message = body.Error
+	} else {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	return apiErr
+}
+
+// Error describes the error, with the request ID if there is one.
+func (e *APIError) Error() string {
+	message := fmt.Sprintf("%d %s", e.StatusCode, e.Message)
+	if e.RequestID != "" {
+		message += " (request " + e.RequestID + ")"
+	}
+	return message
+}
+
+// Is checks if the error is the one of its status.
+func (e *APIError) Is(target error) bool {
+	if statusErr, ok := statusErrors[e.StatusCode]; ok {
+		return statusErr == target
+	}
+	// Statuses the server doesn't normally send still match the closest error
+	return e.StatusCode >= http.StatusInternalServerError && target == ErrServer
+}
@@ -0,0 +1,57 @@
+// This is a file in the package-"client" that contains the iterator over pages of donations.
+package client
+
+import (
+	"context"
+	"relief_exchange_backend/types"
+)
+
+// DonationIterator goes through donations that are fetched a page at a time. Use it like:
+//
+//	donations := c.UserDonations(uid, 50)
+//	for donations.Next(ctx) {
+//		fmt.Println(donations.Donation().Title)
+//	}
+//	if err := donations.Err(); err != nil {
+//		...
+//	}
+type DonationIterator struct {
+	fetch   func(ctx context.Context, cursor string) (*types.DonationPage, error)
+	page    []types.Donation
+	index   int
+	cursor  string
+	started bool
+	done    bool
+	err     error
+}
+
+// Next moves on to the next donation, fetching the next page when needed.
+// Return values:
+//   - true if there's a donation, false once there are none left or fetching a page failed.
+func (it *DonationIterator) Next(ctx context.Context) bool {
+	it.index++
+	for it.index >= len(it.page) {
+		if it.done || it.err != nil || (it.started && it.cursor == "") {
+			it.done = true
+			return false
+		}
+		page, err := it.fetch(ctx, it.cursor)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.started = true
+		it.page, it.index, it.cursor = page.Donations, 0, page.NextCursor
+	}
+	return true
+}
+
+// Donation gets the current donation, once Next returned true.
+func (it *DonationIterator) Donation() types.Donation {
+	return it.page[it.index]
+}
+
+// Err gets the error that stopped the iteration, or nil if it went through every donation.
+func (it *DonationIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,207 @@
+// This is a file in the package-"client" that contains the methods for users and the signed in user's account.
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"relief_exchange_backend/types"
+)
+
+// userPath gets the path of a user, or of something under it if there's more.
+func userPath(userUID string, more ...string) string {
+	path := "/v1/users/" + url.PathEscape(userUID)
+	for _, part := range more {
+		path += "/" + url.PathEscape(part)
+	}
+	return path
+}
+
+// AddUser adds the signed in user to the database, after they've signed up with Firebase.
+// Parameters:
+//   - ctx: the context of the request.
+//   - captchaToken: the token of a CAPTCHA completed for the signup action, empty if the server doesn't check them.
+func (c *Client) AddUser(ctx context.Context, captchaToken string) error {
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/v1/users", captcha: captchaToken}, nil)
+	return err
+}
+
+// GetUser gets the public profile of a user, with only the optional fields they made public.
+func (c *Client) GetUser(ctx context.Context, userUID string) (*types.PublicProfile, error) {
+	var profile types.PublicProfile
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: userPath(userUID)}, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// GetUserData gets every field of a user's profile, which only the user themselves or an admin can do.
+// Anyone else gets the public profile, so the fields it doesn't have are left empty.
+func (c *Client) GetUserData(ctx context.Context, userUID string) (*types.UserData, error) {
+	var userData types.UserData
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: userPath(userUID)}, &userData); err != nil {
+		return nil, err
+	}
+	return &userData, nil
+}
+
+// GetUserDonationsPage gets one page of a user's donations, newest first.
+// Parameters:
+//   - ctx: the context of the request.
+//   - userUID: the user.
+//   - cursor: the NextCursor of the previous page, empty for the first page.
+//   - limit: how many donations to get, up to 100, or 0 for the server's default.
+//
+// Return values:
+//   - the page, whose NextCursor is empty if it's the last one.
+//   - error, if it couldn't be fetched.
+func (c *Client) GetUserDonationsPage(ctx context.Context, userUID string, cursor string, limit int) (*types.DonationPage, error) {
+	query := url.Values{}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	if limit > 0 {
+		query.Set("limit", fmt.Sprint(limit))
+	}
+	var page types.DonationPage
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: userPath(userUID, "donations"), query: query}, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// UserDonations goes through every donation of a user, newest first, fetching them a page at a time.
+// Parameters:
+//   - userUID: the user.
+//   - pageSize: how many donations to fetch at once, or 0 for the server's default.
+//
+// Return values:
+//   - an iterator over the donations.
+func (c *Client) UserDonations(userUID string, pageSize int) *DonationIterator {
+	return &DonationIterator{
+		fetch: func(ctx context.Context, cursor string) (*types.DonationPage, error) {
+			return c.GetUserDonationsPage(ctx, userUID, cursor, pageSize)
+		},
+	}
+}
+
+// IsBanned checks if a user is banned. Only the user themselves or an admin can check.
+func (c *Client) IsBanned(ctx context.Context, userUID string) (bool, error) {
+	var body struct {
+		Banned bool `json:"banned"`
+	}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: userPath(userUID, "banned")}, &body)
+	return body.Banned, err
+}
+
+// IsAdmin checks if a user is an admin. Only the user themselves or an admin can check.
+func (c *Client) IsAdmin(ctx context.Context, userUID string) (bool, error) {
+	var body struct {
+		Admin bool `json:"admin"`
+	}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: userPath(userUID, "admin")}, &body)
+	return body.Admin, err
+}
+
+// Me gets the signed in user's account, profile, roles and anything they still need to do.
+func (c *Client) Me(ctx context.Context) (*types.Me, error) {
+	var me types.Me
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/v1/me"}, &me); err != nil {
+		return nil, err
+	}
+	return &me, nil
+}
+
+// UpdateProfile changes the fields of the signed in user's profile that are set in the update.
+// Return values:
+//   - the updated profile.
+//   - error, which matches ErrBadRequest if the update isn't valid.
+func (c *Client) UpdateProfile(ctx context.Context, update types.ProfileUpdate) (*types.UserData, error) {
+	req, err := jsonRequest(http.MethodPatch, "/v1/me", update)
+	if err != nil {
+		return nil, err
+	}
+	var userData types.UserData
+	if _, err = c.do(ctx, req, &userData); err != nil {
+		return nil, err
+	}
+	return &userData, nil
+}
+
+// SetPublicFields chooses which of the PublicField constants are shown on the signed in user's public profile.
+// Return values:
+//   - the fields that are now public.
+//   - error, which matches ErrBadRequest if one of them isn't a PublicField.
+func (c *Client) SetPublicFields(ctx context.Context, publicFields []string) ([]string, error) {
+	if publicFields == nil {
+		publicFields = []string{} // The server needs the list even if nothing is public
+	}
+	req, err := jsonRequest(http.MethodPatch, "/v1/me/privacy", map[string][]string{"public_fields": publicFields})
+	if err != nil {
+		return nil, err
+	}
+	var body struct {
+		PublicFields []string `json:"public_fields"`
+	}
+	_, err = c.do(ctx, req, &body)
+	return body.PublicFields, err
+}
+
+// DeleteAccount starts deleting the signed in user's account and all of their data.
+// Return values:
+//   - the deletion, whose progress can be followed with GetAccountDeletion.
+//   - error, if it couldn't be started.
+func (c *Client) DeleteAccount(ctx context.Context) (*types.AccountDeletion, error) {
+	var deletion types.AccountDeletion
+	if _, err := c.do(ctx, request{method: http.MethodDelete, path: "/v1/me"}, &deletion); err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+// GetAccountDeletion gets the progress of an account deletion. It doesn't need to be signed in.
+func (c *Client) GetAccountDeletion(ctx context.Context, deletionID string) (*types.AccountDeletion, error) {
+	var deletion types.AccountDeletion
+	path := "/v1/account-deletions/" + url.PathEscape(deletionID)
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: path}, &deletion); err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+// RequestDataExport starts exporting all of the signed in user's data, or gets the export that's already pending or complete.
+func (c *Client) RequestDataExport(ctx context.Context) (*types.DataExport, error) {
+	var export types.DataExport
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: "/v1/me/exports"}, &export); err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// GetDataExport gets the status of one of the signed in user's data exports.
+func (c *Client) GetDataExport(ctx context.Context, exportID string) (*types.DataExport, error) {
+	var export types.DataExport
+	path := "/v1/me/exports/" + url.PathEscape(exportID)
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: path}, &export); err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// DownloadDataExport writes the ZIP of a complete data export to w.
+// Return values:
+//   - error, which matches ErrConflict if the export isn't complete yet, or ErrGone if it has expired.
+func (c *Client) DownloadDataExport(ctx context.Context, exportID string, w io.Writer) error {
+	path := "/v1/me/exports/" + url.PathEscape(exportID) + "/download"
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: path})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if _, err = io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed downloading export: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,415 @@
+// @file commands.go contains the commands of reliefctl
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/server"
+	"relief_exchange_backend/types"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// commands are every command of reliefctl, in the order they're listed in its usage.
+var commands = []command{
+	{
+		name:  "reports list",
+		usage: "[-min reports]",
+		help:  "list reported donations, most reported first",
+		flags: func(flags *flag.FlagSet) { flags.Int("min", 1, "only list donations with at least this many reports") },
+		run:   listReports,
+	},
+	{
+		name:  "reports resolve",
+		usage: "<donation-id> <dismiss|remove>",
+		help:  "dismiss the reports of a donation, or remove it",
+		run:   resolveReports,
+	},
+	{
+		name:  "users get",
+		usage: "<uid>",
+		help:  "show a user's account, profile, roles and ban",
+		run:   getUser,
+	},
+	{
+		name:  "users ban",
+		usage: "[-reason text] [-expires duration|time] <uid>",
+		help:  "ban a user and remove their donations",
+		flags: func(flags *flag.FlagSet) {
+			flags.String("reason", "", "why they're banned, which is shown to them")
+			flags.String("expires", "", "when the ban ends, as a duration like 720h or an RFC 3339 time, permanent if not set")
+		},
+		run: banUser,
+	},
+	{
+		name:  "users unban",
+		usage: "<uid>",
+		help:  "lift a user's ban",
+		run:   unbanUser,
+	},
+	{
+		name:  "users grant",
+		usage: "<uid> <role>",
+		help:  "give a user a role",
+		run: func(ctx context.Context, app *app, flags *flag.FlagSet, args []string) (result, error) {
+			return changeRole(ctx, app, args, true)
+		},
+	},
+	{
+		name:  "users revoke",
+		usage: "<uid> <role>",
+		help:  "take a role from a user",
+		run: func(ctx context.Context, app *app, flags *flag.FlagSet, args []string) (result, error) {
+			return changeRole(ctx, app, args, false)
+		},
+	},
+	{
+		name: "users privileged",
+		help: "list every user with a role",
+		run:  listPrivilegedUsers,
+	},
+	{
+		name:  "donations get",
+		usage: "<id>",
+		help:  "show a donation",
+		run:   getDonation,
+	},
+	{
+		name: "jobs list",
+		help: "list the background jobs",
+		run:  listJobs,
+	},
+	{
+		name:  "jobs run",
+		usage: "<name>",
+		help:  "run a background job once",
+		run:   runJob,
+	},
+	{
+		name: "migrate status",
+		help: "show how far each schema migration has got",
+		run:  migrationStatus,
+	},
+	{
+		name:  "migrate run",
+		usage: "[-dry-run] [-batch size]",
+		help:  "run the schema migrations that haven't completed, carrying on from where they stopped",
+		flags: func(flags *flag.FlagSet) {
+			flags.Bool("dry-run", false, "count the documents that would change without writing anything")
+			flags.Int("batch", helpers.DefaultMigrationBatchSize, "how many documents to migrate at once, at most the default")
+		},
+		run: runMigrations,
+	},
+}
+
+// listReports lists the donations that have been reported.
+func listReports(ctx context.Context, app *app, flags *flag.FlagSet, args []string) (result, error) {
+	if len(args) != 0 {
+		return result{}, errUsage
+	}
+	minReports := flags.Lookup("min").Value.(flag.Getter).Get().(int)
+	donations, err := app.service.GetReportedDonations(ctx, minReports)
+	if err != nil {
+		return result{}, err
+	}
+
+	res := result{value: donations, columns: []string{"ID", "TITLE", "OWNER", "REPORTS", "FLAGS"}}
+	for _, donation := range donations {
+		res.rows = append(res.rows, []string{
+			donation.ID,
+			truncate(donation.Title, 40),
+			donation.OwnerId,
+			strconv.Itoa(len(donation.Reports)),
+			list(donation.Flags),
+		})
+	}
+	return res, nil
+}
+
+// resolveReports dismisses the reports of a donation or removes it.
+func resolveReports(ctx context.Context, app *app, flags *flag.FlagSet, args []string) (result, error) {
+	if len(args) != 2 {
+		return result{}, errUsage
+	}
+	donationId, resolution := args[0], args[1]
+	if resolution != helpers.ReportResolutionDismiss && resolution != helpers.ReportResolutionRemove {
+		return result{}, fmt.Errorf("%w, got %q", helpers.ErrInvalidResolution, resolution)
+	}
+	if err := app.service.ResolveReports(ctx, donationId, resolution); err != nil {
+		return result{}, err
+	}
+	value := map[string]string{"id": donationId, "resolution": resolution}
+	return fields(value, "id", donationId, "resolution", resolution), nil
+}
+
+// getUser shows everything about a user.
+func getUser(ctx context.Context, app *app, flags *flag.FlagSet, args []string) (result, error) {
+	if len(args) != 1 {
+		return result{}, errUsage
+	}
+	me, err := app.service.GetMe(ctx, args[0])
+	if err != nil {
+		return result{}, err
+	}
+
+	pairs := []string{
+		"uid", me.UID,
+		"email", me.Email,
+		"email verified", strconv.FormatBool(me.EmailVerified),
+		"roles", list(me.Roles),
+		"banned", strconv.FormatBool(me.Banned),
+	}
+	if me.Ban != nil {
+		pairs = append(pairs,
+			"banned by", me.Ban.BannedBy,
+			"ban reason", me.Ban.Reason,
+			"banned at", timestamp(&me.Ban.BannedAt),
+			"ban expires", timestamp(me.Ban.ExpiresAt),
+		)
+	}
+	if me.Profile != nil {
+		pairs = append(pairs,
+			"display name", me.Profile.DisplayName,
+			"registered", timestamp(&me.Profile.RegistrationTimestamp),
+			"area", me.Profile.Area,
+		)
+	}
+	pairs = append(pairs,
+		"donations made", strconv.FormatInt(me.DonationsMade, 10),
+		"active donations", strconv.Itoa(me.ActiveDonations),
+		"reports filed", strconv.Itoa(me.ReportsFiled),
+		"pending actions", list(me.PendingActions),
+	)
+	return fields(me, pairs...), nil
+}
+
+// banUser bans a user, recording whoever ran reliefctl as the admin who banned them.
+func banUser(ctx context.Context, app *app, flags *flag.FlagSet, args []string) (result, error) {
+	if len(args) != 1 {
+		return result{}, errUsage
+	}
+	reason := flags.Lookup("reason").Value.String()
+	expiresAt, err := parseExpiry(flags.Lookup("expires").Value.String(), time.Now())
+	if err != nil {
+		return result{}, err
+	}
+
+	if err = app.service.BanUser(ctx, args[0], app.actor, reason, expiresAt); err != nil {
+		return result{}, err
+	}
+	ban := types.BanRecord{UID: args[0], BannedBy: app.actor, Reason: reason, ExpiresAt: expiresAt}
+	return fields(ban, "uid", ban.UID, "banned by", ban.BannedBy, "reason", ban.Reason, "expires", timestamp(expiresAt)), nil
+}
+
+// parseExpiry reads when a ban ends, either a duration from now or a time.
+// Return values:
+//   - when it ends, nil for a permanent ban if expiry is empty.
+//   - error, if it's neither a positive duration nor an RFC 3339 time in the future.
+func parseExpiry(expiry string, now time.Time) (*time.Time, error) {
+	if expiry == "" {
+		return nil, nil
+	}
+	if duration, err := time.ParseDuration(expiry); err == nil {
+		if duration <= 0 {
+			return nil, fmt.Errorf("-expires must be in the future, got %s", expiry)
+		}
+		expiresAt := now.Add(duration).UTC()
+		return &expiresAt, nil
+	}
+	expiresAt, err := time.Parse(time.RFC3339, expiry)
+	if err != nil {
+		return nil, fmt.Errorf("-expires must be a duration like 720h or an RFC 3339 time, got %q", expiry)
+	}
+	if !expiresAt.After(now) {
+		return nil, fmt.Errorf("-expires must be in the future, got %s", expiry)
+	}
+	expiresAt = expiresAt.UTC()
+	return &expiresAt, nil
+}
+
+// unbanUser lifts a user's ban.
+func unbanUser(ctx context.Context, app *app, flags *flag.FlagSet, args []string) (result, error) {
+	if len(args) != 1 {
+		return result{}, errUsage
+	}
+	if err := app.service.UnbanUser(ctx, args[0]); err != nil {
+		return result{}, err
+	}
+	value := map[string]any{"uid": args[0], "banned": false}
+	return fields(value, "uid", args[0], "banned", "false"), nil
+}
+
+// changeRole grants or revokes a user's role, and shows the roles they have afterwards.
+func changeRole(ctx context.Context, app *app, args []string, grant bool) (result, error) {
+	if len(args) != 2 {
+		return result{}, errUsage
+	}
+	userId, role := args[0], args[1]
+
+	var err error
+	if grant {
+		err = app.service.GrantRole(ctx, userId, role)
+	} else {
+		err = app.service.RevokeRole(ctx, userId, role)
+	}
+	if err != nil {
+		return result{}, err
+	}
+
+	roles, err := app.service.GetUserRoles(ctx, userId)
+	if err != nil {
+		return result{}, err
+	}
+	value := map[string]any{"uid": userId, "roles": roles}
+	return fields(value, "uid", userId, "roles", list(roles)), nil
+}
+
+// listPrivilegedUsers lists every user with a role.
+func listPrivilegedUsers(ctx context.Context, app *app, flags *flag.FlagSet, args []string) (result, error) {
+	if len(args) != 0 {
+		return result{}, errUsage
+	}
+	users, err := app.service.GetPrivilegedUsers(ctx)
+	if err != nil {
+		return result{}, err
+	}
+
+	res := result{value: users, columns: []string{"UID", "NAME", "EMAIL", "ROLES"}}
+	for _, user := range users {
+		res.rows = append(res.rows, []string{user.UID, user.DisplayName, user.Email, list(user.Roles)})
+	}
+	return res, nil
+}
+
+// getDonation shows a donation.
+func getDonation(ctx context.Context, app *app, flags *flag.FlagSet, args []string) (result, error) {
+	if len(args) != 1 {
+		return result{}, errUsage
+	}
+	donation, err := app.service.GetDonationByID(ctx, args[0])
+	if err != nil {
+		return result{}, err
+	}
+
+	images := make([]string, len(donation.Images))
+	for i, image := range donation.Images {
+		images[i] = image.URL
+	}
+	return fields(donation,
+		"id", donation.ID,
+		"title", donation.Title,
+		"description", truncate(donation.Description, 80),
+		"location", donation.Location,
+		"owner", donation.OwnerId,
+		"created", timestamp(&donation.CreationTimestamp),
+		"tags", list(donation.Tags),
+		"images", list(images),
+		"reports", list(donation.Reports),
+		"flags", list(donation.Flags),
+		"duplicate of", donation.DuplicateOf,
+	), nil
+}
+
+// listJobs lists the background jobs and how often the server runs them.
+func listJobs(ctx context.Context, app *app, flags *flag.FlagSet, args []string) (result, error) {
+	if len(args) != 0 {
+		return result{}, errUsage
+	}
+
+	type job struct {
+		Name     string `json:"name"`
+		Interval string `json:"interval"`
+	}
+	jobs := make([]job, len(server.Jobs))
+	res := result{columns: []string{"NAME", "INTERVAL"}}
+	for i, j := range server.Jobs {
+		jobs[i] = job{Name: j.Name, Interval: "once at startup"}
+		if j.Interval > 0 {
+			jobs[i].Interval = j.Interval.String()
+		}
+		res.rows = append(res.rows, []string{jobs[i].Name, jobs[i].Interval})
+	}
+	res.value = jobs
+	return res, nil
+}
+
+// runJob runs a background job once. Its name can be written with dashes instead of spaces.
+func runJob(ctx context.Context, app *app, flags *flag.FlagSet, args []string) (result, error) {
+	if len(args) == 0 {
+		return result{}, errUsage
+	}
+	name := strings.ReplaceAll(strings.Join(args, " "), "-", " ")
+
+	for _, job := range server.Jobs {
+		if job.Name != name {
+			continue
+		}
+		start := time.Now()
+		if err := job.Run(ctx, app.service); err != nil {
+			return result{}, fmt.Errorf("job %q failed: %w", job.Name, err)
+		}
+		took := time.Since(start).Round(time.Millisecond).String()
+		value := map[string]string{"name": job.Name, "took": took}
+		return fields(value, "job", job.Name, "took", took), nil
+	}
+	return result{}, fmt.Errorf("there's no job called %q, see reliefctl jobs list", name)
+}
+
+// migrationStatus shows the progress of every schema migration.
+func migrationStatus(ctx context.Context, app *app, flags *flag.FlagSet, args []string) (result, error) {
+	if len(args) != 0 {
+		return result{}, errUsage
+	}
+	progress, err := app.service.GetMigrationProgress(ctx)
+	if err != nil {
+		return result{}, err
+	}
+	return migrationTable(progress), nil
+}
+
+// runMigrations runs the schema migrations, printing their progress to stderr after every batch.
+func runMigrations(ctx context.Context, app *app, flags *flag.FlagSet, args []string) (result, error) {
+	if len(args) != 0 {
+		return result{}, errUsage
+	}
+	options := helpers.MigrationOptions{
+		DryRun:    flags.Lookup("dry-run").Value.(flag.Getter).Get().(bool),
+		BatchSize: flags.Lookup("batch").Value.(flag.Getter).Get().(int),
+		Progress: func(progress types.MigrationProgress) {
+			fmt.Fprintf(os.Stderr, "migration %d (%s): %d scanned, %d migrated\n",
+				progress.Version, progress.Collection, progress.Scanned, progress.Migrated)
+		},
+	}
+
+	progress, err := app.service.RunMigrations(ctx, options)
+	if err != nil {
+		// The progress so far is still printed, so it's clear which migration stopped and where
+		migrationTable(progress).print(os.Stderr, "table")
+		return result{}, err
+	}
+	return migrationTable(progress), nil
+}
+
+// migrationTable creates the result listing the progress of migrations.
+func migrationTable(progress []types.MigrationProgress) result {
+	res := result{value: progress, columns: []string{"VERSION", "COLLECTION", "STATUS", "SCANNED", "MIGRATED", "DESCRIPTION"}}
+	for _, migration := range progress {
+		status := migration.Status
+		if migration.DryRun {
+			status += " (dry run)"
+		}
+		res.rows = append(res.rows, []string{
+			strconv.Itoa(migration.Version),
+			migration.Collection,
+			status,
+			strconv.Itoa(migration.Scanned),
+			strconv.Itoa(migration.Migrated),
+			migration.Description,
+		})
+	}
+	return res
+}
@@ -0,0 +1,163 @@
+// @file main.go is reliefctl, the admin command line tool for moderating and operating the backend.
+// It connects to Firebase with the backend's config and calls the same helpers as the endpoints, so
+// it can do everything the admin pages can, along with running the background jobs by hand.
+//
+// Usage:
+//
+//	reliefctl [-config config.json] [-o table|json] [-v] <command> [flags] [arguments]
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"relief_exchange_backend/config"
+	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/server"
+	"strings"
+	"text/tabwriter"
+
+	_ "github.com/joho/godotenv/autoload"
+	log "github.com/sirupsen/logrus"
+)
+
+// errUsage is returned by commands that were given the wrong arguments, after printing how to use them
+var errUsage = errors.New("invalid usage")
+
+// command is something reliefctl can do, e.g. "users ban".
+type command struct {
+	name  string
+	usage string // The flags and arguments it takes
+	help  string
+	run   func(ctx context.Context, app *app, flags *flag.FlagSet, args []string) (result, error)
+	flags func(flags *flag.FlagSet) // Adds its flags, if it has any
+}
+
+// app is what commands work with.
+type app struct {
+	service *helpers.Service
+	actor   string // Who's running reliefctl, recorded as the admin who made a ban
+}
+
+// main parses the command line, connects to Firebase and runs the command.
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run runs reliefctl with its arguments and returns the exit code.
+func run(arguments []string) int {
+	global := flag.NewFlagSet("reliefctl", flag.ContinueOnError)
+	configPath := global.String("config", os.Getenv("CONFIG_FILE"), "the backend's config file, defaults to config.json")
+	output := global.String("o", "table", "how to print results, table or json")
+	verbose := global.Bool("v", false, "log what the helpers do")
+	global.Usage = func() { printUsage(global) }
+	if err := global.Parse(arguments); err != nil {
+		return 2
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(os.Stderr, "-o must be table or json, got %q\n", *output)
+		return 2
+	}
+
+	// Commands are one or two words, e.g. "users ban"
+	args := global.Args()
+	cmd, args := findCommand(args)
+	if cmd == nil {
+		printUsage(global)
+		return 2
+	}
+	flags := flag.NewFlagSet("reliefctl "+cmd.name, flag.ContinueOnError)
+	if cmd.flags != nil {
+		cmd.flags(flags)
+	}
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: reliefctl %s %s\n%s\n", cmd.name, cmd.usage, cmd.help)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	// Only warnings are logged by default, so they don't get in the way of the results
+	logger := log.New()
+	logger.SetOutput(os.Stderr)
+	logger.SetLevel(log.WarnLevel)
+	if *verbose {
+		logger.SetLevel(log.InfoLevel)
+	}
+
+	if *configPath == "" {
+		*configPath = "config.json"
+	}
+	cfg, err := config.LoadTool(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx = logging.NewContext(ctx, logger.WithField("command", cmd.name))
+
+	fb, err := server.ConnectFirebase(ctx, cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer fb.Firestore.Close()
+
+	actor := "reliefctl"
+	if user := os.Getenv("USER"); user != "" {
+		actor += ":" + user
+	}
+	app := &app{
+		service: helpers.NewService(fb.Firestore, fb.Auth, fb.Images, fb.Exports, cfg, helpers.SystemClock{}, logger),
+		actor:   actor,
+	}
+
+	res, err := cmd.run(ctx, app, flags, flags.Args())
+	if errors.Is(err, errUsage) {
+		flags.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	if err = res.print(os.Stdout, *output); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	return 0
+}
+
+// findCommand finds the command named by the first words of the arguments.
+// Return values:
+//   - the command, or nil if there isn't one with that name.
+//   - the arguments after its name.
+func findCommand(args []string) (*command, []string) {
+	for i := range commands {
+		words := strings.Fields(commands[i].name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == commands[i].name {
+			return &commands[i], args[len(words):]
+		}
+	}
+	return nil, args
+}
+
+// printUsage lists every command and the global flags.
+func printUsage(global *flag.FlagSet) {
+	fmt.Fprintln(os.Stderr, "usage: reliefctl [-config config.json] [-o table|json] [-v] <command> [flags] [arguments]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	w := tabwriter.NewWriter(os.Stderr, 0, 4, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %s %s\t%s\n", cmd.name, cmd.usage, cmd.help)
+	}
+	w.Flush()
+	fmt.Fprintln(os.Stderr, "\nflags:")
+	global.PrintDefaults()
+}
@@ -0,0 +1,84 @@
+package main
+
+// @file main_test.go tests the parts of reliefctl that don't need Firebase, like reading its arguments.
+import (
+	"bytes"
+	"context"
+	"flag"
+	"relief_exchange_backend/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExpiry(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	expiresAt, err := parseExpiry("", now)
+	assert.NoError(t, err)
+	assert.Nil(t, expiresAt, "No expiry should be a permanent ban")
+
+	expiresAt, err = parseExpiry("720h", now)
+	if assert.NoError(t, err) {
+		assert.Equal(t, now.Add(720*time.Hour), *expiresAt, "Durations should be from now")
+	}
+
+	expiresAt, err = parseExpiry("2024-04-01T09:00:00-05:00", now)
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Date(2024, 4, 1, 14, 0, 0, 0, time.UTC), *expiresAt, "Times should be converted to UTC")
+	}
+
+	for _, expiry := range []string{"0s", "-24h", "2024-03-01T12:00:00Z", "2023-01-01T00:00:00Z"} {
+		_, err = parseExpiry(expiry, now)
+		assert.ErrorContains(t, err, "in the future", "%q isn't in the future", expiry)
+	}
+	for _, expiry := range []string{"a month", "2024-04-01", "30d"} {
+		_, err = parseExpiry(expiry, now)
+		assert.ErrorContains(t, err, "RFC 3339", "%q is neither a duration nor a time", expiry)
+	}
+}
+
+func TestResolveReportsArguments(t *testing.T) {
+	// The arguments are checked before the service is used, so it can be left out
+	ctx := context.Background()
+	flags := flag.NewFlagSet("reliefctl reports resolve", flag.ContinueOnError)
+
+	_, err := resolveReports(ctx, &app{}, flags, []string{"donation"})
+	assert.ErrorIs(t, err, errUsage, "The resolution should be required")
+	_, err = resolveReports(ctx, &app{}, flags, []string{"donation", "remove", "extra"})
+	assert.ErrorIs(t, err, errUsage, "Extra arguments shouldn't be ignored")
+	for _, resolution := range []string{"delete", "Dismiss", ""} {
+		_, err = resolveReports(ctx, &app{}, flags, []string{"donation", resolution})
+		assert.ErrorIs(t, err, helpers.ErrInvalidResolution, "%q isn't a resolution", resolution)
+	}
+}
+
+func TestFindCommand(t *testing.T) {
+	cmd, args := findCommand([]string{"reports", "resolve", "donation", "dismiss"})
+	if assert.NotNil(t, cmd) {
+		assert.Equal(t, "reports resolve", cmd.name)
+		assert.Equal(t, []string{"donation", "dismiss"}, args, "The arguments after the name should be left")
+	}
+
+	cmd, _ = findCommand([]string{"reports"})
+	assert.Nil(t, cmd, "Part of a command's name shouldn't match it")
+	cmd, _ = findCommand([]string{"donations", "remove"})
+	assert.Nil(t, cmd, "Unknown commands shouldn't match anything")
+}
+
+func TestResultPrint(t *testing.T) {
+	res := fields(map[string]string{"uid": "someone"}, "uid", "someone", "reason", "")
+
+	var table bytes.Buffer
+	assert.NoError(t, res.print(&table, "table"))
+	assert.Equal(t, "FIELD   VALUE\nuid     someone\nreason  \n", table.String())
+
+	var json bytes.Buffer
+	assert.NoError(t, res.print(&json, "json"))
+	assert.JSONEq(t, `{"uid": "someone"}`, json.String(), "JSON should be printed from the value, not the table")
+
+	assert.Equal(t, "-", list(nil))
+	assert.Equal(t, "-", timestamp(nil))
+	assert.Equal(t, "Winter co…", truncate("Winter coat, size M", 10))
+}
@@ -0,0 +1,74 @@
+// @file output.go prints the results of reliefctl's commands as a table or JSON
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// result is what a command prints. Tables are written with the columns and rows,
+// and JSON from the value, so scripts get every field rather than what fits in a table.
+type result struct {
+	value   any
+	columns []string
+	rows    [][]string
+}
+
+// fields creates the result of a command about a single thing, shown as a table of its fields.
+// Parameters:
+//   - value: the thing, printed as JSON.
+//   - pairs: the name of each field followed by its value, printed as the table.
+func fields(value any, pairs ...string) result {
+	res := result{value: value, columns: []string{"FIELD", "VALUE"}}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		res.rows = append(res.rows, []string{pairs[i], pairs[i+1]})
+	}
+	return res
+}
+
+// print writes the result as a table or JSON.
+func (r result) print(w io.Writer, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r.value)
+	}
+
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if len(r.columns) > 0 {
+		fmt.Fprintln(table, strings.Join(r.columns, "\t"))
+	}
+	for _, row := range r.rows {
+		fmt.Fprintln(table, strings.Join(row, "\t"))
+	}
+	return table.Flush()
+}
+
+// list joins a list for a table cell, with "-" if it's empty.
+func list(values []string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	return strings.Join(values, ",")
+}
+
+// timestamp formats a time for a table cell, with "-" if it isn't set.
+func timestamp(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// truncate shortens text for a table cell, so long titles don't push the other columns away.
+func truncate(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	return string(runes[:length-1]) + "…"
+}
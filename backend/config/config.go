@@ -0,0 +1,476 @@
+// This is a file in the package-"config" that contains the Config type and its loader.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Config holds every setting of the backend.
+// It's read from a JSON file, then any settings given in environment variables replace the file's.
+type Config struct {
+	Development    bool           `json:"development"` // Allows settings that are only safe locally, like the stub CAPTCHA
+	Port           int            `json:"port"`
+	TrustedProxies []string       `json:"trusted_proxies"`
+	CORS           CORSConfig     `json:"cors"`
+	Log            LogConfig      `json:"log"`
+	Sentry         SentryConfig   `json:"sentry"`
+	Firebase       FirebaseConfig `json:"firebase"`
+	Storage        StorageConfig  `json:"storage"`
+	CAPTCHA        CAPTCHAConfig  `json:"captcha"`
+	Limits         LimitsConfig   `json:"limits"`
+	Metrics        MetricsConfig  `json:"metrics"`
+	Tracing        TracingConfig  `json:"tracing"`
+	Timeouts       TimeoutsConfig `json:"timeouts"`
+}
+
+// Duration is a time.Duration written in config files as a string like "10s".
+type Duration time.Duration
+
+// UnmarshalJSON reads a duration string.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("durations must be strings like \"10s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(raw)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// CORSConfig sets which sites can call the backend from a browser.
+type CORSConfig struct {
+	AllowedOrigins []string `json:"allowed_origins"`
+}
+
+// LogConfig sets what gets logged and how.
+type LogConfig struct {
+	Level  string `json:"level"`  // One of the logrus levels, e.g. "warn"
+	Format string `json:"format"` // "json" or "text"
+}
+
+// SentryConfig sets where errors are reported. Sentry is turned off when DSN is empty.
+type SentryConfig struct {
+	DSN              string  `json:"dsn"`
+	TracesSampleRate float64 `json:"traces_sample_rate"`
+}
+
+// FirebaseConfig sets how the backend connects to Firebase.
+// Credentials are read from CredentialsFile, or CredentialsJSON if there's no file.
+// With neither, Google's application default credentials are used.
+type FirebaseConfig struct {
+	CredentialsFile string `json:"credentials_file"`
+	CredentialsJSON string `json:"-"` // Only from the environment, so secrets stay out of config files
+	StorageBucket   string `json:"storage_bucket"`
+}
+
+// StorageConfig sets where files are kept on disk.
+type StorageConfig struct {
+	LocalDir  string `json:"local_dir"`  // Uploads, when there's no storage bucket
+	ExportDir string `json:"export_dir"` // Personal data exports, when there's no storage bucket
+}
+
+// CAPTCHAConfig sets which CAPTCHA provider checks requests, and its keys.
+type CAPTCHAConfig struct {
+	Provider          string  `json:"provider"` // "recaptcha", "hcaptcha" or "stub"
+	ReCAPTCHASecret   string  `json:"-"`
+	ReCAPTCHAMinScore float64 `json:"recaptcha_min_score"` // 0 for reCAPTCHA v2
+	HCaptchaSecret    string  `json:"-"`
+	HCaptchaSiteKey   string  `json:"hcaptcha_site_key"`
+}
+
+// MetricsConfig sets where the Prometheus metrics are served.
+// With an address they get their own listener, which should only be reachable from inside the deployment.
+// Otherwise they're served at /metrics on the main port to requests with the token.
+// With neither, they aren't served at all.
+type MetricsConfig struct {
+	Address string `json:"address"` // e.g. ":9090"
+	Token   string `json:"-"`
+}
+
+// TracingConfig sets where OpenTelemetry traces are sent.
+type TracingConfig struct {
+	Exporter     string  `json:"exporter"`      // "otlp", "stdout" for local debugging, or "none"
+	OTLPEndpoint string  `json:"otlp_endpoint"` // host:port of the collector, e.g. "localhost:4317", or empty to use the standard OTEL_EXPORTER_OTLP_* variables
+	Insecure     bool    `json:"insecure"`      // Send to the collector without TLS
+	SampleRate   float64 `json:"sample_rate"`   // Share of requests whose traces are kept, between 0 and 1, whatever their traceparent says
+	ServiceName  string  `json:"service_name"`
+}
+
+// TimeoutsConfig sets how long requests can take before they're given up on.
+// Routes are written as the method and path they're registered with, e.g. "POST /v1/images".
+// The original routes share the timeouts of the /v1 routes that replaced them, but can still be given their own.
+type TimeoutsConfig struct {
+	Default Duration            `json:"default"`
+	Routes  map[string]Duration `json:"routes"`
+}
+
+// ForRoute gets the timeout of the first of a route's names that has one, falling back to the default one.
+func (t TimeoutsConfig) ForRoute(routes ...string) time.Duration {
+	for _, route := range routes {
+		if timeout, ok := t.Routes[route]; ok {
+			return time.Duration(timeout)
+		}
+	}
+	return time.Duration(t.Default)
+}
+
+// LimitsConfig holds the limits of features that protect against abuse.
+type LimitsConfig struct {
+	RateLimitsFile string           `json:"rate_limits_file"`
+	ImageMatch     ImageMatchConfig `json:"image_match"`
+	Duplicates     DuplicatesConfig `json:"duplicates"`
+}
+
+// ImageMatchConfig sets how new images are compared against blocked ones.
+type ImageMatchConfig struct {
+	MaxDistance int    `json:"max_distance"` // Largest Hamming distance between perceptual hashes that matches
+	Action      string `json:"action"`       // "reject" or "flag"
+}
+
+// DuplicatesConfig sets how duplicate listings are found and handled.
+type DuplicatesConfig struct {
+	Threshold float64 `json:"threshold"` // Similarity between 0 and 1 at which listings are duplicates
+	Action    string  `json:"action"`    // "reject", "merge" or "flag"
+	Nearby    bool    `json:"nearby"`    // Whether other users' donations in the same location are compared
+}
+
+// Default gets the settings used for anything not set in the file or environment.
+func Default() Config {
+	return Config{
+		Port: 8080,
+		CORS: CORSConfig{AllowedOrigins: []string{"http://localhost:3000"}},
+		Log:  LogConfig{Level: "warn", Format: "json"},
+		Sentry: SentryConfig{
+			TracesSampleRate: 1.0,
+		},
+		Storage: StorageConfig{
+			LocalDir:  "uploads",
+			ExportDir: filepath.Join(os.TempDir(), "relief_exchange_exports"),
+		},
+		CAPTCHA: CAPTCHAConfig{Provider: "recaptcha"},
+		Timeouts: TimeoutsConfig{
+			Default: Duration(10 * time.Second),
+			// Image processing and generating files take longer than anything else
+			Routes: map[string]Duration{
+				"POST /v1/images":                 Duration(30 * time.Second),
+				"GET /v1/me/exports/:id/download": Duration(2 * time.Minute),
+				"POST /v1/donations":              Duration(20 * time.Second),
+				"POST /v1/donations/:id/images":   Duration(20 * time.Second),
+			},
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			SampleRate:  1.0,
+			ServiceName: "relief-exchange-backend",
+		},
+		Limits: LimitsConfig{
+			RateLimitsFile: "rate_limits.json",
+			ImageMatch:     ImageMatchConfig{MaxDistance: 10, Action: "reject"},
+			Duplicates:     DuplicatesConfig{Threshold: 0.8, Action: "reject"},
+		},
+	}
+}
+
+// Load reads the config file at path, applies environment overrides, and validates the result.
+// A missing file isn't an error, since everything can be set through the environment.
+// Parameters:
+//   - path: the path of the JSON config file.
+//
+// Return values:
+//   - the loaded settings.
+//   - error, listing every invalid setting.
+func Load(path string) (Config, error) {
+	cfg, err := read(path)
+	if err != nil {
+		return Config{}, err
+	}
+	if err = cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// LoadTool is Load for command line tools like reliefctl, which don't serve requests,
+// so the settings only the web server uses, like the port, CORS and the CAPTCHA, aren't checked.
+// Parameters:
+//   - path: the path of the JSON config file.
+//
+// Return values:
+//   - the loaded settings.
+//   - error, listing every invalid setting tools use.
+func LoadTool(path string) (Config, error) {
+	cfg, err := read(path)
+	if err != nil {
+		return Config{}, err
+	}
+	if err = cfg.ValidateTool(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// read reads the config file at path and applies environment overrides, without validating the result.
+func read(path string) (Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Config{}, fmt.Errorf("failed reading config file: %w", err)
+	}
+	if err == nil {
+		decoder := json.NewDecoder(strings.NewReader(string(data)))
+		// Catch typos instead of silently ignoring them
+		decoder.DisallowUnknownFields()
+		if err = decoder.Decode(&cfg); err != nil {
+			return Config{}, fmt.Errorf("failed parsing config file %s: %w", path, err)
+		}
+	} else {
+		log.Info("no config file found, using defaults and the environment")
+	}
+
+	if err = cfg.applyEnv(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// applyEnv replaces settings with the ones given in environment variables.
+// The variable names are the ones the backend used before it had a config file.
+func (c *Config) applyEnv() error {
+	var errs []error
+	setString := func(name string, target *string) {
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			*target = value
+		}
+	}
+	setList := func(name string, target *[]string) {
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			*target = nil
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					*target = append(*target, item)
+				}
+			}
+		}
+	}
+	setInt := func(name string, target *int) {
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s must be a whole number, got %q", name, value))
+				return
+			}
+			*target = parsed
+		}
+	}
+	setFloat := func(name string, target *float64) {
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s must be a number, got %q", name, value))
+				return
+			}
+			*target = parsed
+		}
+	}
+	setBool := func(name string, target *bool) {
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s must be true or false, got %q", name, value))
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	setBool("DEVELOPMENT", &c.Development)
+	setInt("PORT", &c.Port)
+	setList("TRUSTED_PROXIES", &c.TrustedProxies)
+	setList("CORS_ALLOWED_ORIGINS", &c.CORS.AllowedOrigins)
+	setString("LOG_LEVEL", &c.Log.Level)
+	setString("LOG_FORMAT", &c.Log.Format)
+	setString("SENTRY_DSN", &c.Sentry.DSN)
+	setFloat("SENTRY_TRACES_SAMPLE_RATE", &c.Sentry.TracesSampleRate)
+	setString("FIREBASE_CREDENTIALS_FILE", &c.Firebase.CredentialsFile)
+	setString("FIREBASE_CREDENTIALS_JSON", &c.Firebase.CredentialsJSON)
+	setString("FIREBASE_STORAGE_BUCKET", &c.Firebase.StorageBucket)
+	setString("LOCAL_STORAGE_DIR", &c.Storage.LocalDir)
+	setString("EXPORT_DIR", &c.Storage.ExportDir)
+	setString("CAPTCHA_PROVIDER", &c.CAPTCHA.Provider)
+	setString("RECAPTCHA_SECRET_KEY", &c.CAPTCHA.ReCAPTCHASecret)
+	setFloat("RECAPTCHA_MIN_SCORE", &c.CAPTCHA.ReCAPTCHAMinScore)
+	setString("HCAPTCHA_SECRET_KEY", &c.CAPTCHA.HCaptchaSecret)
+	setString("HCAPTCHA_SITE_KEY", &c.CAPTCHA.HCaptchaSiteKey)
+	setString("RATE_LIMITS_FILE", &c.Limits.RateLimitsFile)
+	setInt("IMAGE_MATCH_MAX_DISTANCE", &c.Limits.ImageMatch.MaxDistance)
+	setString("IMAGE_MATCH_ACTION", &c.Limits.ImageMatch.Action)
+	setFloat("DUPLICATE_LISTING_THRESHOLD", &c.Limits.Duplicates.Threshold)
+	setString("DUPLICATE_LISTING_ACTION", &c.Limits.Duplicates.Action)
+	setBool("DUPLICATE_LISTING_NEARBY", &c.Limits.Duplicates.Nearby)
+	setString("METRICS_ADDRESS", &c.Metrics.Address)
+	setString("METRICS_TOKEN", &c.Metrics.Token)
+	setString("TRACING_EXPORTER", &c.Tracing.Exporter)
+	setString("TRACING_OTLP_ENDPOINT", &c.Tracing.OTLPEndpoint)
+	setBool("TRACING_OTLP_INSECURE", &c.Tracing.Insecure)
+	setFloat("TRACING_SAMPLE_RATE", &c.Tracing.SampleRate)
+	setString("OTEL_SERVICE_NAME", &c.Tracing.ServiceName)
+	if value, ok := os.LookupEnv("REQUEST_TIMEOUT"); ok && value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("REQUEST_TIMEOUT must be a duration like 10s, got %q", value))
+		} else {
+			c.Timeouts.Default = Duration(timeout)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validate checks every setting, returning an error that lists all the invalid ones.
+func (c Config) Validate() error {
+	return invalidConfig(append(c.serverProblems(), c.sharedProblems()...))
+}
+
+// ValidateTool checks the settings command line tools use, leaving out the ones only the web server uses,
+// returning an error that lists all the invalid ones.
+func (c Config) ValidateTool() error {
+	return invalidConfig(c.sharedProblems())
+}
+
+// invalidConfig joins the problems with a config into one error, nil if there aren't any.
+func invalidConfig(problems []error) error {
+	if len(problems) != 0 {
+		return fmt.Errorf("invalid config:\n%w", errors.Join(problems...))
+	}
+	return nil
+}
+
+// serverProblems checks the settings only the web server uses, which are the ones about serving requests.
+func (c Config) serverProblems() []error {
+	var errs []error
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Port < 1 || c.Port > 65535 {
+		invalid("port must be between 1 and 65535, got %d", c.Port)
+	}
+	if len(c.CORS.AllowedOrigins) == 0 {
+		invalid("cors.allowed_origins must include at least one origin")
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		// Browsers refuse credentialed requests to a wildcard origin, and allowing every site is unsafe anyway
+		if strings.Contains(origin, "*") {
+			invalid("cors.allowed_origins can't contain wildcards since credentials are allowed, got %q", origin)
+		} else if !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			invalid("cors.allowed_origins must start with http:// or https://, got %q", origin)
+		}
+	}
+	// Without a secret every CAPTCHA fails, so nobody could sign up, post or report
+	switch c.CAPTCHA.Provider {
+	case "recaptcha":
+		if c.CAPTCHA.ReCAPTCHASecret == "" {
+			invalid("captcha.provider is recaptcha, so RECAPTCHA_SECRET_KEY must be set")
+		}
+		if c.CAPTCHA.ReCAPTCHAMinScore < 0 || c.CAPTCHA.ReCAPTCHAMinScore > 1 {
+			invalid("captcha.recaptcha_min_score must be between 0 and 1, got %v", c.CAPTCHA.ReCAPTCHAMinScore)
+		}
+	case "hcaptcha":
+		if c.CAPTCHA.HCaptchaSecret == "" {
+			invalid("captcha.provider is hcaptcha, so HCAPTCHA_SECRET_KEY must be set")
+		}
+	case "stub":
+		// The stub lets anything through, so it can't be turned on in production by mistake
+		if !c.Development {
+			invalid("captcha.provider can only be stub when development is true")
+		}
+	default:
+		invalid("captcha.provider must be recaptcha, hcaptcha or stub, got %q", c.CAPTCHA.Provider)
+	}
+	if c.Metrics.Address != "" {
+		if _, _, err := net.SplitHostPort(c.Metrics.Address); err != nil {
+			invalid("metrics.address must be a host and port like \":9090\", got %q", c.Metrics.Address)
+		}
+	}
+
+	if c.Timeouts.Default <= 0 {
+		invalid("timeouts.default must be positive, got %v", time.Duration(c.Timeouts.Default))
+	}
+	for route, timeout := range c.Timeouts.Routes {
+		if timeout <= 0 {
+			invalid("timeouts.routes[%q] must be positive, got %v", route, time.Duration(timeout))
+		}
+	}
+	return errs
+}
+
+// sharedProblems checks the settings both the web server and the command line tools use.
+func (c Config) sharedProblems() []error {
+	var errs []error
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if _, err := log.ParseLevel(c.Log.Level); err != nil {
+		invalid("log.level must be one of panic, fatal, error, warn, info, debug or trace, got %q", c.Log.Level)
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		invalid("log.format must be json or text, got %q", c.Log.Format)
+	}
+	if c.Sentry.TracesSampleRate < 0 || c.Sentry.TracesSampleRate > 1 {
+		invalid("sentry.traces_sample_rate must be between 0 and 1, got %v", c.Sentry.TracesSampleRate)
+	}
+	if c.Firebase.CredentialsFile != "" {
+		if _, err := os.Stat(c.Firebase.CredentialsFile); err != nil {
+			invalid("firebase.credentials_file can't be read: %v", err)
+		}
+	}
+	if c.Storage.LocalDir == "" {
+		invalid("storage.local_dir can't be empty")
+	}
+	if c.Storage.ExportDir == "" {
+		invalid("storage.export_dir can't be empty")
+	}
+	if c.Limits.ImageMatch.MaxDistance < 0 || c.Limits.ImageMatch.MaxDistance > 64 {
+		invalid("limits.image_match.max_distance must be between 0 and 64, got %d", c.Limits.ImageMatch.MaxDistance)
+	}
+	if c.Limits.ImageMatch.Action != "reject" && c.Limits.ImageMatch.Action != "flag" {
+		invalid("limits.image_match.action must be reject or flag, got %q", c.Limits.ImageMatch.Action)
+	}
+	if c.Limits.Duplicates.Threshold <= 0 || c.Limits.Duplicates.Threshold > 1 {
+		invalid("limits.duplicates.threshold must be above 0 and at most 1, got %v", c.Limits.Duplicates.Threshold)
+	}
+	switch c.Limits.Duplicates.Action {
+	case "reject", "merge", "flag":
+	default:
+		invalid("limits.duplicates.action must be reject, merge or flag, got %q", c.Limits.Duplicates.Action)
+	}
+
+	switch c.Tracing.Exporter {
+	case "otlp", "stdout", "none":
+	default:
+		invalid("tracing.exporter must be otlp, stdout or none, got %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRate < 0 || c.Tracing.SampleRate > 1 {
+		invalid("tracing.sample_rate must be between 0 and 1, got %v", c.Tracing.SampleRate)
+	}
+	if c.Tracing.ServiceName == "" {
+		invalid("tracing.service_name can't be empty")
+	}
+	return errs
+}
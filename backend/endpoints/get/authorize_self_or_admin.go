@@ -0,0 +1,43 @@
+package get
+
+// This file is to modulize the code and contains the authorizeSelfOrAdmin function.
+import (
+	"net/http"
+	"relief_exchange_backend/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// authorizeSelfOrAdmin checks that the sender of a request is either the user
+// being looked up or an admin, sending back an error response if they aren't.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//   - userUID: the UID of the user being looked up.
+//
+// Return values:
+//   - whether the request is allowed to continue.
+func (h *Handlers) authorizeSelfOrAdmin(c *gin.Context, userUID string) bool {
+	logger := logging.FromContext(c.Request.Context())
+	token, err := h.service.VerifyAuthHeader(c.Request.Context(), c.GetHeader("Authorization"))
+	if err != nil {
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusUnauthorized, gin.H{"error": "You must be signed in to view this."})
+		return false
+	}
+	if token.UID == userUID {
+		return true
+	}
+
+	isAdmin, err := h.service.CheckIfAdmin(c.Request.Context(), token.UID)
+	if err != nil {
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return false
+	}
+	if !isAdmin {
+		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "You are not authorized to view this user's status."})
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,34 @@
+package get
+
+// This file is to modulize the code and contains the GetAccountDeletion function.
+import (
+	"errors"
+	"net/http"
+	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAccountDeletion handles the endpoint to check on the progress of deleting an account.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It accepts the id of the deletion in the path. No token is needed, since the
+// user's account may already be gone, and the id is only known to whoever requested it.
+func (h *Handlers) GetAccountDeletion(c *gin.Context) {
+	deletion, err := h.service.GetAccountDeletion(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error(err.Error())
+		if errors.Is(err, helpers.ErrDeletionNotFound) {
+			c.IndentedJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	// Only share the progress, not whose account it is
+	deletion.UID = ""
+	c.IndentedJSON(http.StatusOK, deletion)
+}
@@ -0,0 +1,91 @@
+package get
+
+// This file is to modulize the code and contains the GetDataExport and DownloadDataExport functions.
+import (
+	"errors"
+	"net/http"
+	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/storage"
+	"relief_exchange_backend/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDataExport handles the endpoint to check on the status of a data export.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It requires an Authorization header with a bearer token, verifies the token,
+// and sends back the status of the export if it belongs to the sender.
+func (h *Handlers) GetDataExport(c *gin.Context) {
+	export, ok := h.getOwnDataExport(c)
+	if !ok {
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, export)
+}
+
+// DownloadDataExport handles the endpoint to download a finished data export.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It requires an Authorization header with a bearer token, verifies the token,
+// and sends back the ZIP file of the export if it belongs to the sender, is complete and hasn't expired.
+func (h *Handlers) DownloadDataExport(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+	export, ok := h.getOwnDataExport(c)
+	if !ok {
+		return
+	}
+	// Expired exports are deleted by the next background job, so they're refused even if they're still there
+	expired := export.ExpiresAt != nil && !h.service.Clock.Now().Before(*export.ExpiresAt)
+	if export.Status == types.JobStatusExpired || (export.Status == types.JobStatusComplete && expired) {
+		c.IndentedJSON(http.StatusGone, gin.H{"error": "export has expired, please request a new one"})
+		return
+	}
+	if export.Status != types.JobStatusComplete {
+		c.IndentedJSON(http.StatusConflict, gin.H{"error": "export is " + export.Status})
+		return
+	}
+
+	data, err := h.service.ReadDataExport(c.Request.Context(), export.ID)
+	if err != nil {
+		logger.Error(err.Error())
+		if errors.Is(err, storage.ErrNotFound) {
+			c.IndentedJSON(http.StatusGone, gin.H{"error": "export is no longer available, please request a new one"})
+		} else {
+			c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="relief-exchange-data.zip"`)
+	c.Data(http.StatusOK, "application/zip", data)
+}
+
+// getOwnDataExport gets the export in the path of the request, making sure it belongs to the sender.
+// An error response is sent if anything goes wrong.
+func (h *Handlers) getOwnDataExport(c *gin.Context) (types.DataExport, bool) {
+	logger := logging.FromContext(c.Request.Context())
+	token, err := h.service.VerifyAuthHeader(c.Request.Context(), c.GetHeader("Authorization"))
+	if err != nil {
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusUnauthorized, gin.H{"error": "You must be signed in to view this."})
+		return types.DataExport{}, false
+	}
+
+	export, err := h.service.GetDataExport(c.Request.Context(), token.UID, c.Param("id"))
+	if err != nil {
+		logger.Error(err.Error())
+		if errors.Is(err, helpers.ErrExportNotFound) {
+			c.IndentedJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return types.DataExport{}, false
+	}
+
+	return export, true
+}
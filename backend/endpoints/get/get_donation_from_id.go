@@ -5,10 +5,9 @@ package get
 // @author Joshua Chou
 import (
 	"net/http"
-	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
 
 	"github.com/gin-gonic/gin"
-	log "github.com/sirupsen/logrus"
 )
 
 // GetDonationByID handles the endpoint to fetch a donation by id using the getDonationById function
@@ -16,15 +15,16 @@ import (
 //   - c: the gin context, the request and response http.
 //
 // It sends the requested donation to the client.
-func GetDonationByID(c *gin.Context) {
+func (h *Handlers) GetDonationByID(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
 	id := c.Param("id")
-	donation, err := helpers.GetDonationByID(id)
+	donation, err := h.service.GetDonationByID(c.Request.Context(), id)
 	if err != nil {
-		log.Warn("Donation not found, ID:", id)
-		log.Error(err.Error())
+		logger.Warn("Donation not found, ID:", id)
+		logger.Error(err.Error())
 		c.IndentedJSON(http.StatusNotFound, gin.H{"error": err.Error()})
 	} else {
-		log.Info("Get donation by ID successful.")
+		logger.Info("Get donation by ID successful.")
 		c.IndentedJSON(http.StatusOK, donation)
 	}
 }
@@ -4,11 +4,9 @@ package get
 // @author Joshua Chou
 import (
 	"net/http"
-
-	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
 
 	"github.com/gin-gonic/gin"
-	log "github.com/sirupsen/logrus"
 )
 
 // getDonationsListEndpoint handles the endpoint to fetch all donations.
@@ -16,13 +14,14 @@ import (
 //   - c: the gin context, the request and response http.
 //
 // It sends a list of all donations in the database to the client.
-func GetDonationsList(c *gin.Context) {
-	donations, err := helpers.GetAllDonations()
+func (h *Handlers) GetDonationsList(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+	donations, err := h.service.GetAllDonations(c.Request.Context())
 	if err != nil {
-		log.Error(err)
+		logger.Error(err)
 		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	} else {
-		log.Info("Get donations successful.")
+		logger.Info("Get donations successful.")
 		c.IndentedJSON(http.StatusOK, donations)
 	}
 }
@@ -4,10 +4,9 @@ package get
 // @author Aritro Saha
 import (
 	"net/http"
-	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
 
 	"github.com/gin-gonic/gin"
-	log "github.com/sirupsen/logrus"
 )
 
 // GetIfAdmin handles the endpoint to check if a user is an admin.
@@ -15,14 +14,28 @@ import (
 //   - c: the gin context, the request and response http.
 //
 // It accepts a user's UID, and checks
-// if they are an admin or not.
-func GetIfAdmin(c *gin.Context) {
-	userUID := c.Query("uid")
+// if they are an admin or not. Only the user themselves or an admin can check.
+func (h *Handlers) GetIfAdmin(c *gin.Context) {
+	h.getIfAdmin(c, c.Query("uid"))
+}
+
+// GetIfAdminByID handles the /v1 endpoint to check if the user in the path is an admin.
+// Parameters:
+//   - c: the gin context, the request and response http.
+func (h *Handlers) GetIfAdminByID(c *gin.Context) {
+	h.getIfAdmin(c, c.Param("id"))
+}
+
+// getIfAdmin checks the user for both versions of the endpoint.
+func (h *Handlers) getIfAdmin(c *gin.Context, userUID string) {
+	if !h.authorizeSelfOrAdmin(c, userUID) {
+		return
+	}
 
 	// Get the result from the helper function
-	isAdmin, err := helpers.CheckIfAdmin(userUID)
+	isAdmin, err := h.service.CheckIfAdmin(c.Request.Context(), userUID)
 	if err != nil {
-		log.Error(err.Error())
+		logging.FromContext(c.Request.Context()).Error(err.Error())
 		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
 		return
 	}
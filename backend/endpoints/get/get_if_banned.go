@@ -4,10 +4,9 @@ package get
 // @author Aritro Saha
 import (
 	"net/http"
-	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
 
 	"github.com/gin-gonic/gin"
-	log "github.com/sirupsen/logrus"
 )
 
 // banUserEndpoint handles the endpoint to check if a user is banned.
@@ -15,14 +14,28 @@ import (
 //   - c: the gin context, the request and response http.
 //
 // It accepts a user's id token and the id of the user to be checked, and checks
-// if they have been banned on the platform.
-func GetIfBanned(c *gin.Context) {
-	userUID := c.Query("uid")
+// if they have been banned on the platform. Only the user themselves or an admin can check.
+func (h *Handlers) GetIfBanned(c *gin.Context) {
+	h.getIfBanned(c, c.Query("uid"))
+}
+
+// GetIfBannedByID handles the /v1 endpoint to check if the user in the path is banned.
+// Parameters:
+//   - c: the gin context, the request and response http.
+func (h *Handlers) GetIfBannedByID(c *gin.Context) {
+	h.getIfBanned(c, c.Param("id"))
+}
+
+// getIfBanned checks the user for both versions of the endpoint.
+func (h *Handlers) getIfBanned(c *gin.Context, userUID string) {
+	if !h.authorizeSelfOrAdmin(c, userUID) {
+		return
+	}
 
 	// Get the result from the helper function
-	isBanned, err := helpers.CheckIfBanned(userUID)
+	isBanned, err := h.service.CheckIfBanned(c.Request.Context(), userUID)
 	if err != nil {
-		log.Error(err.Error())
+		logging.FromContext(c.Request.Context()).Error(err.Error())
 		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
 		return
 	}
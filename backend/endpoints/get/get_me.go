@@ -0,0 +1,34 @@
+package get
+
+// This file is to modulize the code and contains the GetMe function.
+import (
+	"net/http"
+	"relief_exchange_backend/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMe handles the endpoint to fetch everything about the signed-in user.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It requires an Authorization header with a bearer token, verifies the token,
+// and sends back the user's profile, roles, ban status, counts and pending actions.
+func (h *Handlers) GetMe(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+	token, err := h.service.VerifyAuthHeader(c.Request.Context(), c.GetHeader("Authorization"))
+	if err != nil {
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusUnauthorized, gin.H{"error": "You must be signed in to view this."})
+		return
+	}
+
+	me, err := h.service.GetMe(c.Request.Context(), token.UID)
+	if err != nil {
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, me)
+}
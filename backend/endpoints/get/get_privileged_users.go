@@ -0,0 +1,47 @@
+package get
+
+// This file is to modulize the code and contains the GetPrivilegedUsers function.
+import (
+	"net/http"
+	"relief_exchange_backend/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPrivilegedUsers handles the endpoint to list every user holding a role.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It requires an Authorization header with a bearer token, verifies that
+// the sender is an admin, and sends back every privileged user.
+func (h *Handlers) GetPrivilegedUsers(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+	// Verify the token of the sender
+	token, err := h.service.VerifyAuthHeader(c.Request.Context(), c.GetHeader("Authorization"))
+	if err != nil {
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "You are not authorized to view privileged users."})
+		return
+	}
+
+	// Only admins can see who holds which roles
+	isAdmin, err := h.service.CheckIfAdmin(c.Request.Context(), token.UID)
+	if err != nil {
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	if !isAdmin {
+		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "You are not authorized to view privileged users."})
+		return
+	}
+
+	users, err := h.service.GetPrivilegedUsers(c.Request.Context())
+	if err != nil {
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, users)
+}
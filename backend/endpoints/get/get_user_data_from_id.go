@@ -3,27 +3,69 @@ package get
 // This file is to modulize the code and contains the GetUserDataByID function.
 // @author Aritro Saha
 import (
+	"errors"
 	"net/http"
 	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
 
 	"github.com/gin-gonic/gin"
-	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-// GetUserDataByID handles the endpoint to fetch a user's data by id using the helpers.GetUserDataByID Function
+// GetUserDataByID handles the endpoint to fetch a user's data by id using the helpers.GetUserProfile Function
 // Parameters:
 //   - c: the gin context, the request and response http.
 //
-// It sends the requested user's data to the client.
-func GetUserDataByID(c *gin.Context) {
+// It sends the requested user's data to the client. The user themselves and admins
+// get all of it, while everyone else only gets their public profile.
+func (h *Handlers) GetUserDataByID(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
 	id := c.Param("id")
-	userData, err := helpers.GetUserDataByID(id)
+	userData, err := h.service.GetUserProfile(c.Request.Context(), id)
 	if err != nil {
-		log.Warn("User data not found, ID:", id)
-		log.Error(err.Error())
-		c.IndentedJSON(http.StatusNotFound, gin.H{"error": err.Error()})
-	} else {
-		log.Info("Get user data by ID successful.")
+		logger.Error(err.Error())
+		switch {
+		// Banned users are hidden, as if they didn't exist
+		case status.Code(err) == codes.NotFound, errors.Is(err, helpers.ErrUserBanned):
+			c.IndentedJSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		default:
+			c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	if h.canViewPrivateProfile(c, id) {
+		logger.Info("Get user data by ID successful.")
 		c.IndentedJSON(http.StatusOK, userData)
+	} else {
+		logger.Info("Get public profile by ID successful.")
+		c.IndentedJSON(http.StatusOK, userData.ToPublicProfile())
+	}
+}
+
+// canViewPrivateProfile checks whether the sender of a request is signed in as
+// the user being looked up or as an admin. Signing in is optional, so anything
+// going wrong just means they get the public profile.
+func (h *Handlers) canViewPrivateProfile(c *gin.Context, userUID string) bool {
+	logger := logging.FromContext(c.Request.Context())
+	if c.GetHeader("Authorization") == "" {
+		return false
+	}
+
+	token, err := h.service.VerifyAuthHeader(c.Request.Context(), c.GetHeader("Authorization"))
+	if err != nil {
+		logger.Warn(err.Error())
+		return false
+	}
+	if token.UID == userUID {
+		return true
+	}
+
+	isAdmin, err := h.service.CheckIfAdmin(c.Request.Context(), token.UID)
+	if err != nil {
+		logger.Warn(err.Error())
+		return false
 	}
+	return isAdmin
 }
@@ -0,0 +1,51 @@
+package get
+
+// This file is to modulize the code and contains the GetUserDonations function.
+import (
+	"errors"
+	"net/http"
+	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetUserDonations handles the endpoint to fetch a page of a user's donations.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It accepts the user's id in the path, and optionally a cursor and limit in the query,
+// and sends back a page of their donations along with the cursor for the next one.
+// Limits above helpers.MaxDonationPageSize get a page of that size.
+func (h *Handlers) GetUserDonations(c *gin.Context) {
+	id := c.Param("id")
+	limit := helpers.DefaultDonationPageSize
+	if rawLimit := c.Query("limit"); rawLimit != "" {
+		var err error
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil {
+			c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "limit must be a number"})
+			return
+		}
+	}
+
+	page, err := h.service.GetUserDonations(c.Request.Context(), id, c.Query("cursor"), limit)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error(err.Error())
+		switch {
+		case errors.Is(err, helpers.ErrInvalidCursor):
+			c.IndentedJSON(http.StatusBadRequest, gin.H{"error": helpers.ErrInvalidCursor.Error()})
+		// Banned users' donations are hidden, as if they didn't exist
+		case status.Code(err) == codes.NotFound, errors.Is(err, helpers.ErrUserBanned):
+			c.IndentedJSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		default:
+			c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, page)
+}
@@ -0,0 +1,31 @@
+/*
+ * File: handlers.go
+ * -------------
+ * This module contains the Handlers the GET endpoints are methods of, which hold
+ * the helpers they call instead of reaching for package-level clients.
+ */
+
+package get
+
+import (
+	"relief_exchange_backend/helpers"
+	"sync/atomic"
+)
+
+// Handlers handles the GET endpoints.
+type Handlers struct {
+	service *helpers.Service
+
+	// shuttingDown is set once the server starts shutting down, so no new traffic is sent to it
+	shuttingDown atomic.Bool
+}
+
+// NewHandlers creates the GET endpoint handlers.
+// Parameters:
+//   - service: the helpers the endpoints call.
+//
+// Return values:
+//   - the handlers.
+func NewHandlers(service *helpers.Service) *Handlers {
+	return &Handlers{service: service}
+}
@@ -0,0 +1,53 @@
+/*
+ * File: health.go
+ * -------------
+ * This module handles the health and readiness endpoints used by the deployment.
+ * The health endpoint only shows that the process is serving requests, while the readiness
+ * endpoint also checks that Firestore and Firebase Auth can be reached.
+ */
+
+package get
+
+import (
+	"net/http"
+	"relief_exchange_backend/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MarkShuttingDown makes the readiness endpoint fail from now on.
+func (h *Handlers) MarkShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// GetHealth handles the endpoint to check that the server is alive.
+// Parameters:
+//   - c: the gin context, the request and response http.
+func (h *Handlers) GetHealth(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GetReadiness handles the endpoint to check that the server can handle requests.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It responds with 503 and the dependencies that failed if the server isn't ready.
+func (h *Handlers) GetReadiness(c *gin.Context) {
+	if h.shuttingDown.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting down"})
+		return
+	}
+
+	failures := h.service.CheckReadiness(c.Request.Context())
+	if len(failures) != 0 {
+		checks := gin.H{}
+		for name, err := range failures {
+			logging.FromContext(c.Request.Context()).Warn(err.Error())
+			checks[name] = "unreachable"
+		}
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "checks": checks})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
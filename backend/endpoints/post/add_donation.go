@@ -6,7 +6,6 @@ of the donation user through token verification.
 This package includes the following import dependencies:
 
     "net/http" : Provides HTTP client and server implementations
-    "relief_exchange_backend/globals" : Contains global variables or objects
     "relief_exchange_backend/helpers" : Contains helper functions
     "relief_exchange_backend/types" : Contains types that are used in the backend
     "github.com/gin-gonic/gin" : Gin is a HTTP web framework written in Go
@@ -25,37 +24,70 @@ Available: https://pkg.go.dev/github.com/reiver/go-iter. [Accessed: 30- May- 202
 package post
 
 import (
+	"errors"
 	"net/http"
-	"relief_exchange_backend/globals"
+	"relief_exchange_backend/captcha"
 	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
 	"relief_exchange_backend/types"
 
 	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
-	log "github.com/sirupsen/logrus"
 )
 
-// postDonationEndpoint handles the endpoint to post a new donation.
+// AddDonation handles the endpoint to post a new donation.
 // Parameters:
 //   - c: the gin context, the request and response http.
 //
 // It accepts a donation and a user's id token, verifies the token,
 // and then uses the addDonation function to add the donation to the database.
-func AddDonation(c *gin.Context) {
+func (h *Handlers) AddDonation(c *gin.Context) {
 	var body struct {
 		DonationData types.Donation `json:"data"`
 		IDToken      string         `json:"token"`
+		CAPTCHAToken string         `json:"captcha"`
 	}
 	// Bind the request body to the body struct, this stores the donation data and id token of the user to allow go to use.
 	if err := c.ShouldBindJSON(&body); err != nil {
-		log.Error(err.Error())
+		logging.FromContext(c.Request.Context()).Error(err.Error())
 		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	h.addDonation(c, body.IDToken, body.CAPTCHAToken, body.DonationData)
+}
+
+// AddDonationV1 handles the /v1 endpoint to post a new donation.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It accepts the donation as the body, with the user's id token in the Authorization header
+// and the CAPTCHA token in the X-CAPTCHA-Token header.
+func (h *Handlers) AddDonationV1(c *gin.Context) {
+	idToken, ok := bearerToken(c)
+	if !ok {
+		return
+	}
+	var donation types.Donation
+	if err := c.ShouldBindJSON(&donation); err != nil {
+		logging.FromContext(c.Request.Context()).Error(err.Error())
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.addDonation(c, idToken, c.GetHeader(CAPTCHAHeader), donation)
+}
+
+// addDonation posts a donation for both versions of the endpoint, once they've read the request.
+func (h *Handlers) addDonation(c *gin.Context, idToken string, captchaToken string, donation types.Donation) {
+	logger := logging.FromContext(c.Request.Context())
+	// Make sure a person is posting, not a script
+	if !h.verifyCAPTCHA(c, captchaToken, captcha.ActionDonate) {
+		return
+	}
+
 	// Verify the IdToken of the sender (user) with the server
-	token, err := globals.AuthClient.VerifyIDToken(globals.FirebaseContext, body.IDToken)
+	token, err := h.service.VerifyIDToken(c.Request.Context(), idToken)
 	if err != nil {
-		log.Warn("Failed to verify ID token")
+		logger.Warn("Failed to verify ID token")
 		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "You are not authorized to make this donation."})
 		return
 	}
@@ -63,29 +95,42 @@ func AddDonation(c *gin.Context) {
 	userUID := token.UID
 
 	// Use addDonation function to add the donation, passing in the donationData and the uid
-	docID, err := helpers.AddDonation(body.DonationData, userUID)
+	docID, merged, err := h.service.AddDonation(c.Request.Context(), donation, userUID)
 
 	// If there's an error adding the donation, send back err msg to frontend,
 	// otherwise send back docId for the frontend to use
 	if err != nil {
-		log.Error(err.Error())
+		logger.Error(err.Error())
+		if errors.Is(err, helpers.ErrDuplicateListing) {
+			c.IndentedJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if status := donationImageErrorStatus(err); status != http.StatusInternalServerError {
+			c.IndentedJSON(status, gin.H{"error": donationImageErrorMessage(err)})
+			return
+		}
 		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
+	} else if merged {
+		// Nothing new was posted, so the donation counter stays the same
+		logger.Info("Donation merged into an existing one.")
+		c.IndentedJSON(http.StatusOK, docID)
+		return
 	} else {
-		log.Info("Post donation successful.")
+		logger.Info("Post donation successful.")
 		c.IndentedJSON(http.StatusCreated, docID)
 	}
 
 	// Increment the user's donation counter.
 	// Run this after setting up the response since this isn't a priority
 	// and won't affect it.
-	userData, err := helpers.GetUserDataByID(token.UID)
+	userData, err := h.service.GetUserDataByID(c.Request.Context(), token.UID)
 	// Only do it if there was no error
 	// Don't bother returning an error to endpoint since updating the counter
 	// isn't a proper failure
 	if err == nil {
 		// Update user data with new donations count
-		_, err = globals.FirestoreClient.Collection("users").Doc(token.UID).Update(globals.FirebaseContext, []firestore.Update{
+		_, err = h.service.Firestore.Collection("users").Doc(token.UID).Update(c.Request.Context(), []firestore.Update{
 			{
 				Path:  "donations_made",
 				Value: userData.DonationsMade + 1,
@@ -95,6 +140,6 @@ func AddDonation(c *gin.Context) {
 
 	// Log any errors that occured
 	if err != nil {
-		log.Error(err.Error())
+		logger.Error(err.Error())
 	}
 }
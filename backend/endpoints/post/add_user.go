@@ -6,7 +6,6 @@ of the user through token verification.
 This package includes the following import dependencies:
 
 	"net/http" : Provides HTTP client and server implementations
-	"relief_exchange_backend/globals" : Contains global variables or objects
 	"relief_exchange_backend/helpers" : Contains helper functions
 	"github.com/gin-gonic/gin" : Gin is a HTTP web framework written in Go
 	"github.com/sirupsen/logrus" : Logrus is a structured logger for Go
@@ -20,11 +19,10 @@ package post
 
 import (
 	"net/http"
-	"relief_exchange_backend/globals"
-	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/captcha"
+	"relief_exchange_backend/logging"
 
 	"github.com/gin-gonic/gin"
-	log "github.com/sirupsen/logrus"
 )
 
 // AddUser handles the endpoint to add a new user using the addUser function.
@@ -32,24 +30,49 @@ import (
 //   - c: the gin context, the request and response http.
 //
 // It accepts a user's id token, verifies the token, and then adds the user to the database.
-func AddUser(c *gin.Context) {
+func (h *Handlers) AddUser(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
 	var body struct {
-		IDToken string `json:"token"`
+		IDToken      string `json:"token"`
+		CAPTCHAToken string `json:"captcha"`
 	}
 	// Attempt to bind the JSON body of the request to the struct
 	if err := c.ShouldBindJSON(&body); err != nil {
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		// stores request body info into the body varible, so that it matches feild in struct in json format
 		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()}) // if user not signed in, then will send error
 		return
 	}
+	h.addUser(c, body.IDToken, body.CAPTCHAToken)
+}
 
+// AddUserV1 handles the /v1 endpoint to add the signed in user.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It takes the user's id token from the Authorization header and the CAPTCHA token from the X-CAPTCHA-Token header.
+func (h *Handlers) AddUserV1(c *gin.Context) {
+	idToken, ok := bearerToken(c)
+	if !ok {
+		return
+	}
+	h.addUser(c, idToken, c.GetHeader(CAPTCHAHeader))
+}
+
+// addUser adds the user for both versions of the endpoint, once they've read the request.
+func (h *Handlers) addUser(c *gin.Context, idToken string, captchaToken string) {
+	logger := logging.FromContext(c.Request.Context())
 	// Attempt to verify the ID token
 	// Token is provided for user to verify themselves with the server
 	// After it is decoded, we have access to all fields
-	token, err := globals.AuthClient.VerifyIDToken(globals.FirebaseContext, body.IDToken)
+	// Stop scripts from mass creating accounts
+	if !h.verifyCAPTCHA(c, captchaToken, captcha.ActionSignup) {
+		return
+	}
+
+	token, err := h.service.VerifyIDToken(c.Request.Context(), idToken)
 	if err != nil {
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "You are not authorized to create this user"})
 		return
 	}
@@ -57,13 +80,13 @@ func AddUser(c *gin.Context) {
 	userUID := token.UID
 
 	// Attempt to add the user to the database using the AddUser functions
-	err = helpers.AddUser(userUID)
+	err = h.service.AddUser(c.Request.Context(), userUID)
 	if err != nil {
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	} else {
-		log.Info("user added successfully")
+		logger.Info("user added successfully")
 		c.IndentedJSON(http.StatusCreated, gin.H{"message": "User added successfully"})
 	}
 }
@@ -2,9 +2,9 @@
  * File: ban_user.go
  * -------------
  * This module handles the ban user endpoint in the server. It bans a user
- * from the platform if the request maker has admin privileges.
+ * from the platform if the request maker is a moderator or admin.
  * It takes a gin context as a parameter, extracts the token and userToBan from the request,
- * checks if the token is valid and if the user who made the request is an admin.
+ * checks if the token is valid and if the user who made the request is a moderator.
  * If everything checks out, it calls the BanUser helper function to ban the user with the given userToBan id.
  */
 // @authors Joshua Chou,Aritro Saha
@@ -12,11 +12,11 @@ package post
 
 import (
 	"net/http"
-	"relief_exchange_backend/globals"
-	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/types"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	log "github.com/sirupsen/logrus"
 )
 
 // BanUser handles the endpoint to ban a user.
@@ -24,49 +24,76 @@ import (
 //   - c: the gin context, the request and response http.
 //
 // It accepts a user's id token and the id of the user to be banned, verifies the token,
-// checks if the user performing the ban is an admin, then bans the user if authorized using the banUser function and the checkIfAdmin function
-func BanUser(c *gin.Context) {
+// checks if the user performing the ban is a moderator, then bans the user if authorized using the banUser function and the HasRole function
+func (h *Handlers) BanUser(c *gin.Context) {
 	var body struct {
-		UserToBan string `json:"userToBan"`
-		Token     string `json:"token"`
+		UserToBan string     `json:"userToBan"`
+		Reason    string     `json:"reason"`
+		ExpiresAt *time.Time `json:"expires_at"` // Optional, the ban is permanent if not given
+		Token     string     `json:"token"`
 	}
 
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	h.banUser(c, body.Token, body.UserToBan, body.Reason, body.ExpiresAt)
+}
+
+// BanUserV1 handles the /v1 endpoint to ban the user in the path.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It takes the moderator's id token from the Authorization header, and the reason and optional expiry from the body.
+func (h *Handlers) BanUserV1(c *gin.Context) {
+	idToken, ok := bearerToken(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		Reason    string     `json:"reason"`
+		ExpiresAt *time.Time `json:"expires_at"` // Optional, the ban is permanent if not given
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.banUser(c, idToken, c.Param("id"), body.Reason, body.ExpiresAt)
+}
+
+// banUser bans a user for both versions of the endpoint, once they've read the request.
+func (h *Handlers) banUser(c *gin.Context, idToken string, uuidToBan string, reason string, expiresAt *time.Time) {
+	logger := logging.FromContext(c.Request.Context())
 
 	// get sending user token
-	token, err := globals.AuthClient.VerifyIDToken(globals.FirebaseContext, body.Token)
+	token, err := h.service.VerifyIDToken(c.Request.Context(), idToken)
 	if err != nil {
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "You are not authorized to create this user"})
 		return
 	}
 
-	// get uuid of user to ban
-	uuidToBan := body.UserToBan
-	log.Info(uuidToBan)
-	// Check if the user trying to perform the ban is an admin
-	isAdmin, err := helpers.CheckIfAdmin(token.UID)
+	logger.Info(uuidToBan)
+	// Check if the user trying to perform the ban is a moderator, which includes admins
+	isModerator, err := h.service.HasRole(c.Request.Context(), token.UID, types.RoleModerator)
 	if err != nil {
-		log.Error(err.Error())
-		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "internal server error"})
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
 		return
 	}
 
-	if isAdmin {
-		// if sending user is an admin delete all the donations of the user to ban including their data and account
-		err = helpers.BanUser(uuidToBan)
+	if isModerator {
+		// if sending user is a moderator delete all the donations of the user to ban including their data and account
+		err = h.service.BanUser(c.Request.Context(), uuidToBan, token.UID, reason, expiresAt)
 		if err != nil {
-			log.Error(err.Error())
+			logger.Error(err.Error())
 			c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "There was an error processing the ban"})
 			return
 		}
 
 		c.IndentedJSON(http.StatusOK, gin.H{"status": "User banned successfully"})
 	} else {
-		log.Error(err.Error())
+		logger.Warn("only moderators can ban users")
 		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "You are not authorized to ban this user"})
 	}
 }
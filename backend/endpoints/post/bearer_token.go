@@ -0,0 +1,32 @@
+/*
+ * File: bearer_token.go
+ * -------------
+ * This module contains how the /v1 endpoints get the tokens of a request. Unlike the
+ * original endpoints, which take them in the body, /v1 takes the ID token as a bearer
+ * token in the Authorization header and the CAPTCHA token in its own header.
+ */
+
+package post
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CAPTCHAHeader carries the CAPTCHA token of /v1 requests that need one
+const CAPTCHAHeader = "X-CAPTCHA-Token"
+
+// bearerToken gets the ID token from the Authorization header of a /v1 request.
+// If there isn't one, a 401 is sent and false is returned.
+// Parameters:
+//   - c: the gin context, the request and response http.
+func bearerToken(c *gin.Context) (string, bool) {
+	idToken, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !found || idToken == "" {
+		c.IndentedJSON(http.StatusUnauthorized, gin.H{"error": "You must be signed in to do this."})
+		return "", false
+	}
+	return idToken, true
+}
@@ -4,7 +4,7 @@
  * This module handles the delete donation endpoint in the server.
  * It takes a gin context as a parameter, extracts the id from the url parameter,
  * extracts the authorization token from the headers, and verifies it.
- * If the token is valid and the owner id from the token matches the owner id in the donation data, or the user is an admin,
+ * If the token is valid and the owner id from the token matches the owner id in the donation data, or the user is a moderator,
  * it calls the DeleteDonation helper function to delete the donation with the given id.
  */
 // @author Joshua Chou
@@ -15,12 +15,11 @@ package post
 
 import (
 	"net/http"
-	"relief_exchange_backend/globals"
-	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/types"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	log "github.com/sirupsen/logrus"
 )
 
 // DeleteDonation handles the endpoint to delete a donation by id.
@@ -29,15 +28,16 @@ import (
 //
 // It requires an Authorization header with a bearer token, verifies the token,
 // checks if the user is authorized to delete the donation, then deletes it.
-func DeleteDonation(c *gin.Context) {
+func (h *Handlers) DeleteDonation(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
 	// Extract id of donation from request
 	id := c.Param("id")
 	// Get the donation firestore document reference
-	donationRef := globals.FirestoreClient.Collection("donations").Doc(id)
+	donationRef := h.service.Firestore.Collection("donations").Doc(id)
 	// Get the data of the document reference
-	donationData, err := donationRef.Get(globals.FirebaseContext)
+	donationData, err := donationRef.Get(c.Request.Context())
 	if err != nil {
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
@@ -64,27 +64,37 @@ func DeleteDonation(c *gin.Context) {
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 	// Verify the token using the VerifyIDToken function from the AuthClient.
 	// This function checks if the token is valid and returns the decoded token.
-	token, err := globals.AuthClient.VerifyIDToken(globals.FirebaseContext, tokenString)
+	token, err := h.service.VerifyIDToken(c.Request.Context(), tokenString)
 	if err != nil {
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "You are not authorized to delete this donation."})
 		return
 	}
 
 	// Extract the user id from the token
 	userUID := token.UID
-	// Only allow donation owner, or admins to delete this donation
-	// If sender id (userUID) does not match the id of the donation owner, or the sender id, is not an admin, then they are not authorized to delete the donation
-	isAdmin, _ := helpers.CheckIfAdmin(userUID)
-	if donationData.Data()["owner_id"].(string) != userUID && (!isAdmin) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You are not authorized to delete this donation."})
-		return
+	// Only allow donation owner, or moderators (which includes admins) to delete this donation
+	// If sender id (userUID) does not match the id of the donation owner, or the sender id, is not a moderator, then they are not authorized to delete the donation
+	// Donations from before the migrations can be missing owner_id, so only moderators can delete those
+	ownerId, _ := donationData.Data()["owner_id"].(string)
+	isOwner := ownerId != "" && ownerId == userUID
+	if !isOwner {
+		isModerator, err := h.service.HasRole(c.Request.Context(), userUID, types.RoleModerator)
+		if err != nil {
+			logger.Error(err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			return
+		}
+		if !isModerator {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not authorized to delete this donation."})
+			return
+		}
 	}
 
-	// Delete the donation using the firestore reference
-	_, err = donationRef.Delete(globals.FirebaseContext) // only need the err return value
+	// Delete the donation, blocking its photos if a moderator took it down
+	err = h.service.DeleteDonation(c.Request.Context(), id, !isOwner)
 	if err != nil {
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
@@ -4,7 +4,8 @@
  * This module handles the delete user endpoint in the server.
  * It takes a gin context as a parameter, binds the request body to a struct,
  * extracts the token from it, and verifies the token.
- * If the token is valid, it calls the DeleteUser helper function to delete the user with the uid extracted from the token.
+ * If the token is valid, it queues up the deletion of the user with the uid extracted from the token,
+ * which runs in the background and can be checked on with the deletion status endpoint.
  */
 // @author Joshua Chou
 // @cite "Validating Google Sign In ID Token in Go." Stack Overflow, 2016. [Online].
@@ -12,52 +13,78 @@
 package post
 
 import (
+	"context"
 	"net/http"
-	"relief_exchange_backend/globals"
-	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/types"
 
 	"github.com/gin-gonic/gin"
-	log "github.com/sirupsen/logrus"
 )
 
 // DeleteUser handles the endpoint to delete all of a user's data
-// using the deleteUser function.
+// using the RequestAccountDeletion function.
 // Parameters:
 //   - c: the gin context, the request and response http.
 //
-// It accepts a user's id token, verifies the token, and then deletes all of their data.
-func DeleteUser(c *gin.Context) {
+// It accepts a user's id token, verifies the token, and then starts deleting all of their data.
+func (h *Handlers) DeleteUser(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
 	var body struct {
 		IDToken string `json:"token"`
 	}
 	// Attempt to bind the JSON body of the request to the struct
 	if err := c.ShouldBindJSON(&body); err != nil {
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		// Stores request body info into the body varible, so that it matches field in struct in json format
 		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()}) // if user not signed in, then will send error
 		return
 	}
+	h.deleteUser(c, body.IDToken)
+}
 
+// DeleteUserV1 handles the /v1 endpoint to delete the signed in user's account.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It takes the user's id token from the Authorization header.
+func (h *Handlers) DeleteUserV1(c *gin.Context) {
+	idToken, ok := bearerToken(c)
+	if !ok {
+		return
+	}
+	h.deleteUser(c, idToken)
+}
+
+// deleteUser queues up deleting an account for both versions of the endpoint, once they've read the request.
+func (h *Handlers) deleteUser(c *gin.Context, idToken string) {
+	logger := logging.FromContext(c.Request.Context())
 	// Attempt to verify the ID token
 	// Token is provided for user to verify themselves with the server
 	// After it is decoded, we have access to all fields
-	token, err := globals.AuthClient.VerifyIDToken(globals.FirebaseContext, body.IDToken)
+	token, err := h.service.VerifyIDToken(c.Request.Context(), idToken)
 	if err != nil {
-		log.Error(err.Error())
-		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "You are not authorized to create this user"})
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "You are not authorized to delete this user"})
 		return
 	}
 	// Extract the user's UID from the token
 	userUID := token.UID
 
-	// Attempt to delete the user using the DeleteUser function
-	err = helpers.DeleteUser(userUID)
+	// Queue up the deletion of the user
+	deletion, err := h.service.RequestAccountDeletion(c.Request.Context(), userUID)
 	if err != nil {
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
-	} else {
-		log.Info("user added successfully")
-		c.IndentedJSON(http.StatusCreated, gin.H{"message": "User deleted successfully"})
 	}
+
+	// Start deleting right away, the background job retries it if anything fails
+	if deletion.Status == types.JobStatusPending {
+		h.runTask(c.Request.Context(), "account deletion", func(ctx context.Context) error {
+			return h.service.ProcessAccountDeletion(ctx, deletion.ID)
+		})
+	}
+
+	logger.Info("user deletion requested successfully")
+	c.IndentedJSON(http.StatusAccepted, deletion)
 }
@@ -0,0 +1,191 @@
+/*
+ * File: donation_images.go
+ * -------------
+ * This module handles the endpoints that change a donation's images.
+ * Each takes a gin context as a parameter, extracts the donation id from the url parameter,
+ * extracts the authorization token from the headers, and verifies it.
+ * If the token is valid and the sender can edit the donation, it calls the matching helper
+ * function to add, remove, reorder or describe an image, and sends back the donation's images.
+ */
+
+package post
+
+import (
+	"errors"
+	"net/http"
+	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/types"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AddDonationImage handles the endpoint to attach an uploaded image to a donation.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It accepts the id of an image uploaded by the sender and its alt text.
+func (h *Handlers) AddDonationImage(c *gin.Context) {
+	var body struct {
+		ImageID string `json:"image_id"`
+		AltText string `json:"alt"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logging.FromContext(c.Request.Context()).Error(err.Error())
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, ok := h.authorizeDonationEdit(c)
+	if !ok {
+		return
+	}
+
+	images, err := h.service.AddDonationImage(c.Request.Context(), c.Param("id"), userId, body.ImageID, body.AltText)
+	respondWithDonationImages(c, images, err)
+}
+
+// RemoveDonationImage handles the endpoint to remove an image from a donation.
+// Parameters:
+//   - c: the gin context, the request and response http.
+func (h *Handlers) RemoveDonationImage(c *gin.Context) {
+	if _, ok := h.authorizeDonationEdit(c); !ok {
+		return
+	}
+
+	images, err := h.service.RemoveDonationImage(c.Request.Context(), c.Param("id"), c.Param("imageId"))
+	respondWithDonationImages(c, images, err)
+}
+
+// ReorderDonationImages handles the endpoint to change the order of a donation's images.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It accepts the ids of every image of the donation in their new order.
+func (h *Handlers) ReorderDonationImages(c *gin.Context) {
+	var body struct {
+		ImageIDs []string `json:"image_ids"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logging.FromContext(c.Request.Context()).Error(err.Error())
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, ok := h.authorizeDonationEdit(c); !ok {
+		return
+	}
+
+	images, err := h.service.ReorderDonationImages(c.Request.Context(), c.Param("id"), body.ImageIDs)
+	respondWithDonationImages(c, images, err)
+}
+
+// SetDonationImageAlt handles the endpoint to change the alt text of a donation's image.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It accepts the new alt text.
+func (h *Handlers) SetDonationImageAlt(c *gin.Context) {
+	var body struct {
+		AltText string `json:"alt"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logging.FromContext(c.Request.Context()).Error(err.Error())
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, ok := h.authorizeDonationEdit(c); !ok {
+		return
+	}
+
+	images, err := h.service.SetDonationImageAlt(c.Request.Context(), c.Param("id"), c.Param("imageId"), body.AltText)
+	respondWithDonationImages(c, images, err)
+}
+
+// authorizeDonationEdit verifies the Authorization header and checks that the sender
+// can edit the donation in the url. If they can't, a response is sent and ok is false.
+func (h *Handlers) authorizeDonationEdit(c *gin.Context) (userId string, ok bool) {
+	logger := logging.FromContext(c.Request.Context())
+	token, err := h.service.VerifyAuthHeader(c.Request.Context(), c.GetHeader("Authorization"))
+	if err != nil {
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "You are not authorized to edit this donation."})
+		return "", false
+	}
+
+	donation, err := h.service.GetDonationByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			c.IndentedJSON(http.StatusNotFound, gin.H{"error": "donation not found"})
+		} else {
+			c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return "", false
+	}
+
+	canEdit, err := h.service.CanEditDonation(c.Request.Context(), token.UID, donation)
+	if err != nil {
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return "", false
+	}
+	if !canEdit {
+		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "You are not authorized to edit this donation."})
+		return "", false
+	}
+
+	return token.UID, true
+}
+
+// respondWithDonationImages sends back a donation's images after a change, or the reason it failed.
+func respondWithDonationImages(c *gin.Context, images []types.DonationImage, err error) {
+	if err != nil {
+		c.IndentedJSON(donationImageErrorStatus(err), gin.H{"error": donationImageErrorMessage(err)})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, images)
+}
+
+// donationImageErrorStatus gets the status code to send back for an error changing a donation's images.
+func donationImageErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, helpers.ErrImageNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, helpers.ErrImageUnavailable):
+		return http.StatusForbidden
+	case errors.Is(err, helpers.ErrTooManyImages),
+		errors.Is(err, helpers.ErrInvalidAltText),
+		errors.Is(err, helpers.ErrBannedImage),
+		errors.Is(err, helpers.ErrInvalidImageOrder),
+		errors.Is(err, helpers.ErrImageURL):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// donationImageErrorMessage gets the message to send back for an error changing a donation's images.
+// Unexpected errors aren't passed on, since they can include internal details.
+func donationImageErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, helpers.ErrImageNotFound):
+		return helpers.ErrImageNotFound.Error()
+	case errors.Is(err, helpers.ErrImageUnavailable):
+		return helpers.ErrImageUnavailable.Error()
+	case errors.Is(err, helpers.ErrTooManyImages):
+		return helpers.ErrTooManyImages.Error()
+	case errors.Is(err, helpers.ErrInvalidAltText):
+		return helpers.ErrInvalidAltText.Error()
+	case errors.Is(err, helpers.ErrInvalidImageOrder):
+		return helpers.ErrInvalidImageOrder.Error()
+	case errors.Is(err, helpers.ErrBannedImage):
+		return helpers.ErrBannedImage.Error()
+	case errors.Is(err, helpers.ErrImageURL):
+		return helpers.ErrImageURL.Error()
+	default:
+		return "There was an error updating the donation's images"
+	}
+}
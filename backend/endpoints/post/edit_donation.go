@@ -14,12 +14,10 @@ package post
 import (
 	"fmt"
 	"net/http"
-	"relief_exchange_backend/globals"
-	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
 	"relief_exchange_backend/types"
 
 	"github.com/gin-gonic/gin"
-	log "github.com/sirupsen/logrus"
 )
 
 // EditDonation handles the endpoint to edit an existing donation.
@@ -29,7 +27,7 @@ import (
 // It accepts an existing donation UID, new donation data, and a user's id token,
 // verifies the token, and then uses the EditDonation helper to edit the donation
 // in the database.
-func EditDonation(c *gin.Context) {
+func (h *Handlers) EditDonation(c *gin.Context) {
 	var body struct {
 		ExistingDonationID string         `json:"id"`
 		NewDonationData    types.Donation `json:"data"`
@@ -37,14 +35,39 @@ func EditDonation(c *gin.Context) {
 	}
 	// Bind the request body to the body struct, this stores the donation data and id token of the user to allow go to use.
 	if err := c.ShouldBindJSON(&body); err != nil {
-		log.Error(err.Error())
+		logging.FromContext(c.Request.Context()).Error(err.Error())
 		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	h.editDonation(c, body.IDToken, body.ExistingDonationID, body.NewDonationData)
+}
+
+// EditDonationV1 handles the /v1 endpoint to edit the donation in the path.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It accepts the new donation data as the body, with the user's id token in the Authorization header.
+func (h *Handlers) EditDonationV1(c *gin.Context) {
+	idToken, ok := bearerToken(c)
+	if !ok {
+		return
+	}
+	var donation types.Donation
+	if err := c.ShouldBindJSON(&donation); err != nil {
+		logging.FromContext(c.Request.Context()).Error(err.Error())
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.editDonation(c, idToken, c.Param("id"), donation)
+}
+
+// editDonation edits a donation for both versions of the endpoint, once they've read the request.
+func (h *Handlers) editDonation(c *gin.Context, idToken string, donationID string, donation types.Donation) {
+	logger := logging.FromContext(c.Request.Context())
 	// Verify the IdToken of the sender (user) with the server
-	token, err := globals.AuthClient.VerifyIDToken(globals.FirebaseContext, body.IDToken)
+	token, err := h.service.VerifyIDToken(c.Request.Context(), idToken)
 	if err != nil {
-		log.Warn("Failed to verify ID token")
+		logger.Warn("Failed to verify ID token")
 		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "You are not authorized to make this donation."})
 		return
 	}
@@ -53,19 +76,19 @@ func EditDonation(c *gin.Context) {
 	userUID := token.UID
 
 	// Get donation data to extract creator's UID
-	existingDonation, err := helpers.GetDonationByID(body.ExistingDonationID)
+	existingDonation, err := h.service.GetDonationByID(c.Request.Context(), donationID)
 	if err != nil {
 		err = fmt.Errorf("err while getting existing donation: %w", err)
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		c.Status(http.StatusInternalServerError)
 		return
 	}
 
 	// Check whether user is an admin
-	isAdmin, err := helpers.CheckIfAdmin(userUID)
+	isAdmin, err := h.service.CheckIfAdmin(c.Request.Context(), userUID)
 	if err != nil {
 		err = fmt.Errorf("err while checking if admin: %w", err)
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		c.Status(http.StatusInternalServerError)
 		return
 	}
@@ -73,36 +96,37 @@ func EditDonation(c *gin.Context) {
 	// Only allow the original creator or an admin to edit posts
 	if !(isAdmin || existingDonation.OwnerId == userUID) {
 		err = fmt.Errorf("user cannot edit donation, is not the original author or an admin")
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "request user is not author or admin"})
+		return
 	}
 
 	// Check if they're already banned
-	banned, err := helpers.CheckIfBanned(userUID)
+	banned, err := h.service.CheckIfBanned(c.Request.Context(), userUID)
 	if err != nil {
 		err = fmt.Errorf("err while checking if banned: %w", err)
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	if banned {
 		err := fmt.Errorf("user is banned")
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "you were banned from the platform"})
 		return
 	}
 
 	// Use EditDonation function to edit the donation, passing in the donationData and the existing UID
-	err = helpers.EditDonation(body.NewDonationData, body.ExistingDonationID)
+	err = h.service.EditDonation(c.Request.Context(), donation, donationID)
 
 	// If there's an error adding the donation, send back err msg to frontend,
 	// otherwise send back docId for the frontend to use
 	if err != nil {
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	} else {
-		log.Info("Editing donation successful.")
+		logger.Info("Editing donation successful.")
 		c.Status(http.StatusOK)
 	}
 }
@@ -0,0 +1,38 @@
+/*
+ * File: handlers.go
+ * -------------
+ * This module contains the Handlers the POST endpoints are methods of, which hold
+ * the helpers they call, the CAPTCHA verifier and how to run work after responding,
+ * instead of reaching for package-level clients.
+ */
+
+package post
+
+import (
+	"context"
+	"relief_exchange_backend/captcha"
+	"relief_exchange_backend/helpers"
+)
+
+// RunTask runs a task after the request that started it has been responded to, in a way the server
+// waits for when it shuts down. The task's context isn't cancelled along with the request's.
+type RunTask func(ctx context.Context, name string, task func(ctx context.Context) error)
+
+// Handlers handles the POST endpoints.
+type Handlers struct {
+	service *helpers.Service
+	captcha captcha.Verifier
+	runTask RunTask
+}
+
+// NewHandlers creates the POST endpoint handlers.
+// Parameters:
+//   - service: the helpers the endpoints call.
+//   - captchaVerifier: checks the CAPTCHA tokens sent with sensitive requests.
+//   - runTask: runs the work the endpoints start after responding, like deleting an account.
+//
+// Return values:
+//   - the handlers.
+func NewHandlers(service *helpers.Service, captchaVerifier captcha.Verifier, runTask RunTask) *Handlers {
+	return &Handlers{service: service, captcha: captchaVerifier, runTask: runTask}
+}
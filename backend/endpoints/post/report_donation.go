@@ -11,12 +11,13 @@
 package post
 
 import (
+	"errors"
 	"net/http"
-	"relief_exchange_backend/globals"
+	"relief_exchange_backend/captcha"
 	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
 
 	"github.com/gin-gonic/gin"
-	log "github.com/sirupsen/logrus"
 )
 
 // ReportDonation handles the endpoint to report a donation.
@@ -25,24 +26,48 @@ import (
 //
 // It accepts a user's id token and a donation id, verifies the token,
 // then adds the user's report to the donation.
-func ReportDonation(c *gin.Context) {
+func (h *Handlers) ReportDonation(c *gin.Context) {
 	// Define body to store request information
 	var body struct {
-		DonationID string `json:"donation_id"`
-		IDToken    string `json:"token"`
+		DonationID   string `json:"donation_id"`
+		IDToken      string `json:"token"`
+		CAPTCHAToken string `json:"captcha"`
 	}
 	// Attempt to bind the request to the body, so golang can use the donation_id and sender token
 	if err := c.ShouldBindJSON(&body); err != nil { // Transfers request body so that fields match the struct
-		log.Println(err.Error())
+		logging.FromContext(c.Request.Context()).Error(err.Error())
 		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	h.reportDonation(c, body.IDToken, body.CAPTCHAToken, body.DonationID)
+}
+
+// ReportDonationV1 handles the /v1 endpoint to report the donation in the path.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It takes the user's id token from the Authorization header and the CAPTCHA token from the X-CAPTCHA-Token header.
+func (h *Handlers) ReportDonationV1(c *gin.Context) {
+	idToken, ok := bearerToken(c)
+	if !ok {
+		return
+	}
+	h.reportDonation(c, idToken, c.GetHeader(CAPTCHAHeader), c.Param("id"))
+}
+
+// reportDonation reports a donation for both versions of the endpoint, once they've read the request.
+func (h *Handlers) reportDonation(c *gin.Context, idToken string, captchaToken string, donationID string) {
+	logger := logging.FromContext(c.Request.Context())
+	// Stop scripts from mass reporting donations
+	if !h.verifyCAPTCHA(c, captchaToken, captcha.ActionReport) {
+		return
+	}
 
 	// Verify the token with the server
 	// Function checks if the token is valid and returns the decoded token
-	token, err := globals.AuthClient.VerifyIDToken(globals.FirebaseContext, body.IDToken)
+	token, err := h.service.VerifyIDToken(c.Request.Context(), idToken)
 	if err != nil {
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "You are not authorized to report this donation."})
 		return
 	}
@@ -50,20 +75,19 @@ func ReportDonation(c *gin.Context) {
 	userUID := token.UID
 
 	// Report the donation using the donationid and the senderid
-	err = helpers.ReportDonation(body.DonationID, userUID)
+	err = h.service.ReportDonation(c.Request.Context(), donationID, userUID)
 	// If user has already sent a report to this donation, do not continue and send an error to the frontend
 	if err != nil {
-		log.Error(err.Error())
-		if err.Error() == "User has already sent a report" {
-			c.IndentedJSON(http.StatusConflict, gin.H{"error": err.Error()})
+		logger.Error(err.Error())
+		if errors.Is(err, helpers.ErrAlreadyReported) {
+			c.IndentedJSON(http.StatusConflict, gin.H{"error": helpers.ErrAlreadyReported.Error()})
 		} else {
 			//if there was some other error, send back a internal server error to the frontend
-			log.Println(err.Error())
-			c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
 		}
 		return
 	}
 
 	c.Status(http.StatusAccepted)
-	log.Info("report successful")
+	logger.Info("report successful")
 }
@@ -0,0 +1,77 @@
+/*
+ * File: request_data_export.go
+ * -------------
+ * This module handles the request data export endpoint in the server.
+ * It takes a gin context as a parameter, binds the request body to a struct,
+ * extracts the token from it, and verifies the token.
+ * If the token is valid, it queues up an export of all the sender's data, which
+ * is generated in the background and can be checked on with the export status endpoint.
+ */
+
+package post
+
+import (
+	"context"
+	"net/http"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestDataExport handles the endpoint to request a copy of all of a user's data.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It accepts a user's id token, verifies the token, and then starts generating their export.
+func (h *Handlers) RequestDataExport(c *gin.Context) {
+	var body struct {
+		IDToken string `json:"token"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logging.FromContext(c.Request.Context()).Error(err.Error())
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.requestDataExport(c, body.IDToken)
+}
+
+// RequestDataExportV1 handles the /v1 endpoint to request a copy of all of the signed in user's data.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It takes the user's id token from the Authorization header.
+func (h *Handlers) RequestDataExportV1(c *gin.Context) {
+	idToken, ok := bearerToken(c)
+	if !ok {
+		return
+	}
+	h.requestDataExport(c, idToken)
+}
+
+// requestDataExport queues up an export for both versions of the endpoint, once they've read the request.
+func (h *Handlers) requestDataExport(c *gin.Context, idToken string) {
+	logger := logging.FromContext(c.Request.Context())
+	token, err := h.service.VerifyIDToken(c.Request.Context(), idToken)
+	if err != nil {
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "You are not authorized to export this data."})
+		return
+	}
+
+	export, err := h.service.RequestDataExport(c.Request.Context(), token.UID)
+	if err != nil {
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	// Start generating it right away, the background job picks it up if this server stops first
+	if export.Status == types.JobStatusPending {
+		h.runTask(c.Request.Context(), "data export", func(ctx context.Context) error {
+			return h.service.ProcessDataExport(ctx, export.ID)
+		})
+	}
+
+	c.IndentedJSON(http.StatusAccepted, export)
+}
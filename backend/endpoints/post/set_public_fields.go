@@ -0,0 +1,84 @@
+/*
+ * File: set_public_fields.go
+ * -------------
+ * This module handles the profile privacy endpoint in the server.
+ * It takes a gin context as a parameter, binds the request body to a struct,
+ * extracts the token and the fields to make public from it, and verifies the token.
+ * If the token is valid, it calls the SetPublicFields helper function to update the
+ * sender's privacy settings.
+ */
+
+package post
+
+import (
+	"errors"
+	"net/http"
+	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetPublicFields handles the endpoint to choose which optional fields are on a user's public profile.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It accepts a user's id token and the list of fields to make public, verifies the token,
+// and then updates the user's privacy settings.
+func (h *Handlers) SetPublicFields(c *gin.Context) {
+	var body struct {
+		PublicFields []string `json:"public_fields" binding:"required"`
+		IDToken      string   `json:"token"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logging.FromContext(c.Request.Context()).Error(err.Error())
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.setPublicFields(c, body.IDToken, body.PublicFields)
+}
+
+// SetPublicFieldsV1 handles the /v1 endpoint to choose which optional fields are on the signed in user's public profile.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It accepts the list of fields to make public, with the user's id token in the Authorization header.
+func (h *Handlers) SetPublicFieldsV1(c *gin.Context) {
+	idToken, ok := bearerToken(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		PublicFields []string `json:"public_fields" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logging.FromContext(c.Request.Context()).Error(err.Error())
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.setPublicFields(c, idToken, body.PublicFields)
+}
+
+// setPublicFields updates the privacy settings for both versions of the endpoint, once they've read the request.
+func (h *Handlers) setPublicFields(c *gin.Context, idToken string, publicFields []string) {
+	logger := logging.FromContext(c.Request.Context())
+	token, err := h.service.VerifyIDToken(c.Request.Context(), idToken)
+	if err != nil {
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "You are not authorized to change these settings."})
+		return
+	}
+
+	err = h.service.SetPublicFields(c.Request.Context(), token.UID, publicFields)
+	if err != nil {
+		logger.Error(err.Error())
+		if errors.Is(err, helpers.ErrInvalidPublicField) {
+			c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"public_fields": publicFields})
+}
@@ -0,0 +1,137 @@
+/*
+ * File: set_user_role.go
+ * -------------
+ * This module handles the grant and revoke role endpoints in the server.
+ * It takes a gin context as a parameter, binds the request body to a struct,
+ * extracts the token, target user and role from it, and verifies the token.
+ * If the token is valid and the sender is an admin, it calls the GrantRole or
+ * RevokeRole helper function to change the roles of the target user.
+ */
+
+package post
+
+import (
+	"errors"
+	"net/http"
+	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GrantRole handles the endpoint to grant a role to a user.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It accepts an admin's id token, the uid of the target user and the role to grant.
+func (h *Handlers) GrantRole(c *gin.Context) {
+	h.setUserRole(c, true)
+}
+
+// RevokeRole handles the endpoint to revoke a role from a user.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It accepts an admin's id token, the uid of the target user and the role to revoke.
+func (h *Handlers) RevokeRole(c *gin.Context) {
+	h.setUserRole(c, false)
+}
+
+// GrantRoleV1 handles the /v1 endpoint to grant a role to the user in the path.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It accepts the role to grant, with the admin's id token in the Authorization header.
+func (h *Handlers) GrantRoleV1(c *gin.Context) {
+	idToken, ok := bearerToken(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		Role string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logging.FromContext(c.Request.Context()).Error(err.Error())
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.changeUserRole(c, idToken, c.Param("id"), body.Role, true)
+}
+
+// RevokeRoleV1 handles the /v1 endpoint to revoke the role in the path from the user in the path.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It takes the admin's id token from the Authorization header.
+func (h *Handlers) RevokeRoleV1(c *gin.Context) {
+	idToken, ok := bearerToken(c)
+	if !ok {
+		return
+	}
+	h.changeUserRole(c, idToken, c.Param("id"), c.Param("role"), false)
+}
+
+// setUserRole reads the request of the original grant and revoke endpoints, then changes the requested role.
+func (h *Handlers) setUserRole(c *gin.Context, grant bool) {
+	var body struct {
+		UserUID string `json:"uid"`
+		Role    string `json:"role"`
+		Token   string `json:"token"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logging.FromContext(c.Request.Context()).Error(err.Error())
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.changeUserRole(c, body.Token, body.UserUID, body.Role, grant)
+}
+
+// changeUserRole verifies that the sender is an admin, then grants or revokes the role of the user.
+func (h *Handlers) changeUserRole(c *gin.Context, idToken string, userUID string, role string, grant bool) {
+	logger := logging.FromContext(c.Request.Context())
+	// Verify the token of the sender
+	token, err := h.service.VerifyIDToken(c.Request.Context(), idToken)
+	if err != nil {
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "You are not authorized to change roles."})
+		return
+	}
+
+	// Only admins can change roles
+	isAdmin, err := h.service.CheckIfAdmin(c.Request.Context(), token.UID)
+	if err != nil {
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	if !isAdmin {
+		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "You are not authorized to change roles."})
+		return
+	}
+
+	if grant {
+		err = h.service.GrantRole(c.Request.Context(), userUID, role)
+	} else {
+		err = h.service.RevokeRole(c.Request.Context(), userUID, role)
+	}
+	if err != nil {
+		logger.Error(err.Error())
+		switch {
+		case errors.Is(err, helpers.ErrInvalidRole):
+			c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, helpers.ErrLastAdmin):
+			c.IndentedJSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "There was an error changing the user's roles"})
+		}
+		return
+	}
+
+	roles, err := h.service.GetUserRoles(c.Request.Context(), userUID)
+	if err != nil {
+		logger.Error(err.Error())
+		c.Status(http.StatusOK)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"uid": userUID, "roles": roles})
+}
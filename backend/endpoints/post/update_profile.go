@@ -0,0 +1,101 @@
+/*
+ * File: update_profile.go
+ * -------------
+ * This module handles the update profile endpoint in the server.
+ * It takes a gin context as a parameter, binds the request body to a struct,
+ * extracts the token and the profile changes from it, and verifies the token.
+ * If the token is valid, it calls the UpdateProfile helper function to update the
+ * sender's profile.
+ */
+
+package post
+
+import (
+	"errors"
+	"net/http"
+	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateProfile handles the endpoint to edit a user's profile.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It accepts a user's id token and the fields of their profile to change,
+// verifies the token, and then updates their profile.
+func (h *Handlers) UpdateProfile(c *gin.Context) {
+	var body struct {
+		Profile types.ProfileUpdate `json:"data"`
+		IDToken string              `json:"token"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logging.FromContext(c.Request.Context()).Error(err.Error())
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.updateProfile(c, body.IDToken, body.Profile)
+}
+
+// UpdateProfileV1 handles the /v1 endpoint to edit the signed in user's profile.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It accepts the fields of the profile to change as the body, with the user's id token in the Authorization header.
+func (h *Handlers) UpdateProfileV1(c *gin.Context) {
+	idToken, ok := bearerToken(c)
+	if !ok {
+		return
+	}
+	var profile types.ProfileUpdate
+	if err := c.ShouldBindJSON(&profile); err != nil {
+		logging.FromContext(c.Request.Context()).Error(err.Error())
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.updateProfile(c, idToken, profile)
+}
+
+// updateProfile edits a profile for both versions of the endpoint, once they've read the request.
+func (h *Handlers) updateProfile(c *gin.Context, idToken string, profile types.ProfileUpdate) {
+	logger := logging.FromContext(c.Request.Context())
+	token, err := h.service.VerifyIDToken(c.Request.Context(), idToken)
+	if err != nil {
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "You are not authorized to edit this profile."})
+		return
+	}
+
+	// Banned users can't change anything on the platform
+	banned, err := h.service.CheckIfBanned(c.Request.Context(), token.UID)
+	if err != nil {
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	if banned {
+		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "you were banned from the platform"})
+		return
+	}
+
+	err = h.service.UpdateProfile(c.Request.Context(), token.UID, profile)
+	if err != nil {
+		logger.Error(err.Error())
+		if errors.Is(err, helpers.ErrInvalidProfile) {
+			c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	userData, err := h.service.GetUserDataByID(c.Request.Context(), token.UID)
+	if err != nil {
+		logger.Error(err.Error())
+		c.Status(http.StatusOK)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, userData)
+}
@@ -0,0 +1,101 @@
+/*
+ * File: upload_image.go
+ * -------------
+ * This module handles the upload image endpoint in the server.
+ * It takes a gin context as a parameter, extracts the authorization token from the headers,
+ * and verifies it. If the token is valid and the user isn't banned, it reads the image from
+ * the multipart form and calls the UploadImage helper function to process and store it.
+ */
+// @cite "Upload file | Gin Web Framework." Gin, 2023. [Online].
+// Available: https://gin-gonic.com/docs/examples/upload-file/single-file/. [Accessed: 19- October- 2026].
+
+package post
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead is extra room given to the request body for the multipart headers around the file
+const multipartOverhead = 1 << 20
+
+// UploadImage handles the endpoint to upload an image for a donation.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//
+// It requires an Authorization header with a bearer token and the image in the "image"
+// field of a multipart form. It sends back the URLs of the stored image's renditions.
+func (h *Handlers) UploadImage(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+	// Stop reading huge uploads early instead of buffering all of them
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, helpers.MaxImageUploadBytes+multipartOverhead)
+
+	token, err := h.service.VerifyAuthHeader(c.Request.Context(), c.GetHeader("Authorization"))
+	if err != nil {
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "You are not authorized to upload images."})
+		return
+	}
+
+	// Banned users can't post anything
+	banned, err := h.service.CheckIfBanned(c.Request.Context(), token.UID)
+	if err != nil {
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	if banned {
+		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "you were banned from the platform"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		logger.Error(err.Error())
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.IndentedJSON(http.StatusRequestEntityTooLarge, gin.H{"error": helpers.ErrImageTooLarge.Error()})
+		} else {
+			c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "an image must be uploaded in the image field"})
+		}
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "could not read the uploaded image"})
+		return
+	}
+	defer file.Close()
+
+	// Read one byte more than allowed, so files that are too large can be told apart
+	data, err := io.ReadAll(io.LimitReader(file, helpers.MaxImageUploadBytes+1))
+	if err != nil {
+		logger.Error(err.Error())
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "could not read the uploaded image"})
+		return
+	}
+
+	image, err := h.service.UploadImage(c.Request.Context(), token.UID, data)
+	if err != nil {
+		logger.Error(err.Error())
+		switch {
+		case errors.Is(err, helpers.ErrImageTooLarge):
+			c.IndentedJSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		case errors.Is(err, helpers.ErrUnsupportedImageType):
+			c.IndentedJSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		case errors.Is(err, helpers.ErrInvalidImage):
+			c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "There was an error storing the image"})
+		}
+		return
+	}
+
+	c.IndentedJSON(http.StatusCreated, image)
+}
@@ -2,8 +2,8 @@
  * File: validate_captcha_token.go
  * -------------
  * This module handles the validate captcha token endpoint in the server.
- * It validates the Google's reCAPTCHA token provided by the user in order to prevent bot interaction.
- * It is still incomplete and more logic is required to fully handle CAPTCHA validation.
+ * It checks a CAPTCHA token with the configured provider, so the frontend can tell a user
+ * early that their CAPTCHA didn't pass. The endpoints that need a CAPTCHA check it again themselves.
  // @author Aritro Saha
 */
 
@@ -12,9 +12,10 @@
 package post
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
-	"os"
+	"relief_exchange_backend/captcha"
+	"relief_exchange_backend/logging"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,36 +24,16 @@ import (
 // Parameters:
 //   - c: the gin context, the request and response http.
 //
-// It sends a request to Google's reCAPTCHA API and returns whether the token is valid.
-func ValidateCAPTCHAToken(c *gin.Context) {
-	// Define a struct to hold the response body from the reCAPTCHA API.
-	var captchaResponseBody struct {
-		Success bool `json:"success"`
-	}
-	// Extract the CAPTCHA token from the request query parameters.
-	token := c.Query("token")
-	// Send a GET request to the reCAPTCHA API with the secret key and the token.
-	// [1]
-	resp, err := http.Get("https://www.google.com/recaptcha/api/siteverify?secret=" + os.Getenv("RECAPTCHA_SECRET_KEY") + "&response=" + token)
-	// If an error occurred while sending the request, return a 500 Internal Server Error status.
-	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	// Ensure the response body is closed after the function returns
-	defer resp.Body.Close()
-	// If the reCAPTCHA API returned a status code other than 200 OK, return a 500 Internal Server Error status.
-	if resp.StatusCode != http.StatusOK {
-		c.AbortWithStatus(http.StatusInternalServerError)
+// It takes the token and optionally the action it was made for from the query parameters,
+// and returns whether it passed along with its score, if the provider gives one.
+func (h *Handlers) ValidateCAPTCHAToken(c *gin.Context) {
+	result, err := h.captcha.Verify(c.Request.Context(), c.Query("token"), c.Query("action"), c.ClientIP())
+	if err != nil && !errors.Is(err, captcha.ErrFailed) {
+		// The provider couldn't be reached, which says nothing about the user
+		logging.FromContext(c.Request.Context()).Error(err.Error())
+		c.IndentedJSON(http.StatusBadGateway, gin.H{"error": "Could not verify the CAPTCHA, please try again later."})
 		return
 	}
-	// Decode the response body into the captchaResponseBody struct (defined at start of function)
-	err = json.NewDecoder(resp.Body).Decode(&captchaResponseBody)
-	// If an error occurred while decoding the response body, return a 500 Internal Server Error status.
-	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	// Return a 200 OK status and whether the CAPTCHA token was valid.
-	c.IndentedJSON(http.StatusOK, gin.H{"human": captchaResponseBody.Success})
+
+	c.IndentedJSON(http.StatusOK, gin.H{"human": err == nil, "score": result.Score})
 }
@@ -0,0 +1,38 @@
+/*
+ * File: verify_captcha.go
+ * -------------
+ * This module contains the CAPTCHA check shared by the endpoints that bots are most likely to abuse.
+ * The check happens on the server when the request is made, so a client can't skip it.
+ */
+
+package post
+
+import (
+	"errors"
+	"net/http"
+	"relief_exchange_backend/captcha"
+	"relief_exchange_backend/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// verifyCAPTCHA checks the CAPTCHA token sent with a request.
+// If it doesn't pass, a response is sent and false is returned.
+// Parameters:
+//   - c: the gin context, the request and response http.
+//   - token: the CAPTCHA token sent by the client.
+//   - action: what the CAPTCHA was completed for, one of the captcha.Action constants.
+func (h *Handlers) verifyCAPTCHA(c *gin.Context, token string, action string) bool {
+	_, err := h.captcha.Verify(c.Request.Context(), token, action, c.ClientIP())
+	if err == nil {
+		return true
+	}
+
+	logging.FromContext(c.Request.Context()).WithField("action", action).Warn(err.Error())
+	if errors.Is(err, captcha.ErrFailed) {
+		c.IndentedJSON(http.StatusForbidden, gin.H{"error": "CAPTCHA verification failed, please try again."})
+	} else {
+		c.IndentedJSON(http.StatusBadGateway, gin.H{"error": "Could not verify the CAPTCHA, please try again later."})
+	}
+	return false
+}
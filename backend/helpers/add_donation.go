@@ -5,12 +5,14 @@
 package helpers
 
 import (
+	"context"
 	"fmt"
-	"relief_exchange_backend/globals"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/metrics"
+	"relief_exchange_backend/tracing"
 	"relief_exchange_backend/types"
 
 	"cloud.google.com/go/firestore"
-	log "github.com/sirupsen/logrus"
 )
 
 // AddDonation adds a new donation record to Firestore.
@@ -20,46 +22,117 @@ import (
 //   - donation: the Donation object to add.
 //   - userId: the ID of the user making the donation.
 //
+// If it's nearly the same as one of the user's donations, it's rejected, merged into that donation
+// or flagged, depending on the duplicates limits in the config. If it's nearly the same as
+// another user's donation it's only flagged, since they may be giving away the same thing.
+//
 // Return values:
-//   - ID of the new donation record.
+//   - ID of the new donation record, or of the donation it was merged into.
+//   - true if it was merged into an existing donation instead of being added.
 //   - error, if any occurred during the operation.
-func AddDonation(donation types.Donation, userId string) (string, error) {
+func (s *Service) AddDonation(ctx context.Context, donation types.Donation, userId string) (string, bool, error) {
+	ctx, span := tracing.Start(ctx, "helpers.AddDonation", tracing.UID(userId))
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
 	// Check if they're already banned
-	banned, err := CheckIfBanned(userId)
+	banned, err := s.CheckIfBanned(ctx, userId)
 	if err != nil {
 		err = fmt.Errorf("err while checking if banned: %w", err)
-		log.Error(err.Error())
-		return "", err
+		logger.Error(err.Error())
+		return "", false, err
 	}
 	if banned {
 		err := fmt.Errorf("user is already banned")
-		log.Error(err.Error())
-		return "", err
+		logger.Error(err.Error())
+		return "", false, err
+	}
+
+	// Check the photos against ones from removed donations and banned users
+	flags := make([]string, 0)
+	flagged, err := s.screenDonationImages(ctx, imageIds(donation.Images))
+	if err != nil {
+		return "", false, err
+	}
+	if flagged {
+		flags = append(flags, types.DonationFlagBannedImage)
 	}
 
-	docRef, _, err := globals.FirestoreClient.Collection("donations").Add(globals.FirebaseContext, map[string]interface{}{
-		"title":              donation.Title,
-		"description":        donation.Description,
-		"location":           donation.Location,
-		"img":                donation.Image,
-		"owner_id":           userId,
-		"creation_timestamp": donation.CreationTimestamp,
-		"tags":               donation.Tags,
-		"reports":            make([]string, 0),
+	// Stop the same item from being posted over and over to stay at the top of the list
+	policy := s.Config.Limits.Duplicates
+	duplicate, err := s.FindDuplicateListing(ctx, donation, userId, policy.Threshold, policy.Nearby)
+	if err != nil {
+		logger.Error(err.Error())
+		return "", false, err
+	}
+	duplicateOf := ""
+	if duplicate != nil {
+		// Only the user's own donations can stop a new one from being posted
+		ownDuplicate := duplicate.OwnerId == userId
+		switch {
+		case policy.Action == DuplicateActionReject && ownDuplicate:
+			metrics.Donations.WithLabelValues(metrics.DonationRejected).Inc()
+			return "", false, ErrDuplicateListing
+		case policy.Action == DuplicateActionMerge && ownDuplicate:
+			if err = s.mergeDuplicateListing(ctx, duplicate.ID, donation, userId); err != nil {
+				return "", false, err
+			}
+			span.SetAttributes(tracing.DonationID(duplicate.ID))
+			metrics.Donations.WithLabelValues(metrics.DonationMerged).Inc()
+			return duplicate.ID, true, nil
+		default:
+			flags = append(flags, types.DonationFlagDuplicateListing)
+			duplicateOf = duplicate.ID
+		}
+	}
+
+	// Create the donation and claim its images together, so an image can't end up on two donations
+	docRef := s.Firestore.Collection("donations").NewDoc()
+	span.SetAttributes(tracing.DonationID(docRef.ID))
+	err = s.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		images, imageRefs, err := s.resolveDonationImages(tx, donation, userId)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Create(docRef, map[string]interface{}{
+			"title":              donation.Title,
+			"description":        donation.Description,
+			"location":           donation.Location,
+			"img":                coverImageURL(images),
+			"images":             donationImagesToData(images),
+			"owner_id":           userId,
+			"creation_timestamp": donation.CreationTimestamp,
+			"tags":               donation.Tags,
+			"reports":            make([]string, 0),
+			"report_count":       0,
+			"flags":              flags,
+			"duplicate_of":       duplicateOf,
+			schemaVersionField:   SchemaVersion("donations"),
+		})
+		if err != nil {
+			return err
+		}
+		for _, imageRef := range imageRefs {
+			if err = tx.Update(imageRef, []firestore.Update{{Path: "donation_id", Value: docRef.ID}}); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 	if err != nil {
 		err = fmt.Errorf("error while adding donation: %w", err)
-		log.Error(err.Error())
-		return "", err
+		logger.Error(err.Error())
+		return "", false, err
 	}
 
 	// Get current posts and append new post
 	// Get the user's document
-	userDoc, err := globals.FirestoreClient.Doc("users/" + userId).Get(globals.FirebaseContext)
+	userDoc, err := s.Firestore.Doc("users/" + userId).Get(ctx)
 	if err != nil {
 		err = fmt.Errorf("error while getting user document (addDonation): %w", err)
-		log.Error(err.Error())
-		return "", err
+		logger.Error(err.Error())
+		return "", false, err
 	}
 
 	// Extract posts array
@@ -75,16 +148,21 @@ func AddDonation(donation types.Donation, userId string) (string, error) {
 	posts = append(posts, docRef)
 
 	// Update the user document
-	_, err = globals.FirestoreClient.Doc("users/"+userId).Set(globals.FirebaseContext, map[string]interface{}{
+	_, err = s.Firestore.Doc("users/"+userId).Set(ctx, map[string]interface{}{
 		"posts":          posts,
 		"donations_made": userDoc.Data()["donations_made"].(int64),
 	}, firestore.MergeAll) // mergeall ensures that only the posts feild is changed
 	if err != nil {
 		err = fmt.Errorf("error while updating user document (addDonation): %w", err)
-		log.Error(err.Error())
-		return "", err
+		logger.Error(err.Error())
+		return "", false, err
 	}
 
-	log.Info("ID of new donation: %v", docRef.ID)
-	return docRef.ID, nil
+	if len(flags) != 0 {
+		metrics.Donations.WithLabelValues(metrics.DonationFlagged).Inc()
+	} else {
+		metrics.Donations.WithLabelValues(metrics.DonationCreated).Inc()
+	}
+	logger.WithField("donation_id", docRef.ID).Info("donation added")
+	return docRef.ID, false, nil
 }
@@ -5,12 +5,14 @@
 package helpers
 
 import (
+	"context"
 	"fmt"
-	"relief_exchange_backend/globals"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+	"relief_exchange_backend/types"
 	"time"
 
 	"cloud.google.com/go/firestore"
-	log "github.com/sirupsen/logrus"
 )
 
 // addUser adds a new user to Firestore.
@@ -21,41 +23,50 @@ import (
 //
 // Return values:
 //   - error, if any occurred during the operation.
-func AddUser(userId string) error {
+func (s *Service) AddUser(ctx context.Context, userId string) error {
+	ctx, span := tracing.Start(ctx, "helpers.AddUser", tracing.UID(userId))
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
 	// Check if they're already banned
-	banned, err := CheckIfBanned(userId)
+	banned, err := s.CheckIfBanned(ctx, userId)
 	if err != nil {
 		err = fmt.Errorf("err while checking if banned: %w", err)
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return err
 	}
 	if banned {
 		// If the user is already banned, log and return an error
 		err := fmt.Errorf("user is already banned")
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return err
 	}
 	//Get user data from auth server
-	userData, err := globals.AuthClient.GetUser(globals.FirebaseContext, userId)
+	userData, err := s.getAuthUser(ctx, userId)
 	if err != nil {
 		err = fmt.Errorf("failed getting user data from auth server: %w", err)
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return err
 	}
 	// Create a new document in Firestore for the user with the provided data
-	_, err = globals.FirestoreClient.Doc("users/"+userId).Create(globals.FirebaseContext, map[string]interface{}{
-		"display_name":    userData.DisplayName,
-		"email":           userData.Email,
-		"admin":           false,
-		"posts":           []firestore.DocumentRef{}, //the posts made by the user
-		"uid":             userId,
-		"donations_made":  0,
-		"registered_date": time.Unix(userData.UserMetadata.CreationTimestamp/1000, 0),
+	_, err = s.Firestore.Doc("users/"+userId).Create(ctx, map[string]interface{}{
+		"display_name":        userData.DisplayName,
+		"email":               userData.Email,
+		"admin":               false,
+		"roles":               []string{},
+		"posts":               []firestore.DocumentRef{}, //the posts made by the user
+		"uid":                 userId,
+		"donations_made":      0,
+		"registered_date":     time.Unix(userData.UserMetadata.CreationTimestamp/1000, 0),
+		"avatar":              userData.PhotoURL,
+		"public_fields":       types.DefaultPublicFields,
+		"contact_preferences": map[string]interface{}{"email": false, "note": ""},
+		schemaVersionField:    SchemaVersion("users"),
 	})
 	if err != nil {
 		// Log and return the error if there was a problem creating the user's document
 		err = fmt.Errorf("failed creating user data doc: %w", err)
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return err
 	}
 	// If everything went well, return nil indicating no errors
@@ -0,0 +1,50 @@
+// This is a file in the package-"helpers" that contains the traced calls made to Firebase Auth.
+// Firebase Auth is called over HTTP with its own client, so its calls are wrapped here to give each one a span.
+package helpers
+
+import (
+	"context"
+	"relief_exchange_backend/tracing"
+
+	"firebase.google.com/go/auth"
+)
+
+// getAuthUser gets a user's account from Firebase Auth.
+func (s *Service) getAuthUser(ctx context.Context, userId string) (*auth.UserRecord, error) {
+	ctx, span := tracing.StartClient(ctx, "firebase.auth/GetUser", tracing.UID(userId))
+	defer span.End()
+
+	user, err := s.Auth.GetUser(ctx, userId)
+	tracing.RecordError(span, err)
+	return user, err
+}
+
+// getAuthUsers gets the accounts of several users from Firebase Auth at once.
+func (s *Service) getAuthUsers(ctx context.Context, identifiers []auth.UserIdentifier) (*auth.GetUsersResult, error) {
+	ctx, span := tracing.StartClient(ctx, "firebase.auth/GetUsers")
+	defer span.End()
+
+	result, err := s.Auth.GetUsers(ctx, identifiers)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+// deleteAuthUser deletes a user's account from Firebase Auth.
+func (s *Service) deleteAuthUser(ctx context.Context, userId string) error {
+	ctx, span := tracing.StartClient(ctx, "firebase.auth/DeleteUser", tracing.UID(userId))
+	defer span.End()
+
+	err := s.Auth.DeleteUser(ctx, userId)
+	tracing.RecordError(span, err)
+	return err
+}
+
+// setAuthClaims replaces the custom claims of a user's account in Firebase Auth.
+func (s *Service) setAuthClaims(ctx context.Context, userId string, claims map[string]interface{}) error {
+	ctx, span := tracing.StartClient(ctx, "firebase.auth/SetCustomUserClaims", tracing.UID(userId))
+	defer span.End()
+
+	err := s.Auth.SetCustomUserClaims(ctx, userId, claims)
+	tracing.RecordError(span, err)
+	return err
+}
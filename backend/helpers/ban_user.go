@@ -7,54 +7,69 @@
 package helpers
 
 import (
+	"context"
 	"fmt"
-	"relief_exchange_backend/globals"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/metrics"
+	"relief_exchange_backend/tracing"
+	"relief_exchange_backend/types"
+	"time"
 
 	"cloud.google.com/go/firestore"
-	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
 )
 
 // BanUser bans a user by removing their records from Firestore and flagging their UID.
+// The reason and expiry of the ban are recorded in the user's doc in the bans collection,
+// and every image they uploaded is added to the image blocklist.
 // Parameters:
+//   - ctx: the context in which the function is invoked.
 //   - userId: the ID of the user to ban.
+//   - bannedBy: the ID of the moderator or admin performing the ban.
+//   - reason: why the user was banned, shown to them when they sign in.
+//   - expiresAt: when the ban stops applying, or nil if it is permanent.
 //
 // Return values:
 //   - error, if any occurred during the operation.
-func BanUser(userId string) error {
+func (s *Service) BanUser(ctx context.Context, userId string, bannedBy string, reason string, expiresAt *time.Time) error {
+	ctx, span := tracing.Start(ctx, "helpers.BanUser", tracing.UID(userId))
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
 	// Check if they're already banned
-	banned, err := CheckIfBanned(userId)
+	banned, err := s.CheckIfBanned(ctx, userId)
 	if err != nil {
 		err = fmt.Errorf("err while checking if banned: %w", err)
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return err
 	}
 	if banned {
 		err := fmt.Errorf("user is already banned")
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return err
 	}
 
-	// Check if they're an admin
-	isAdmin, err := CheckIfAdmin(userId)
+	// Check if they're a moderator or admin, who have to lose their role first
+	isModerator, err := s.HasRole(ctx, userId, types.RoleModerator)
 	if err != nil {
-		err = fmt.Errorf("err while checking if admin: %w", err)
-		log.Error(err.Error())
+		err = fmt.Errorf("err while checking if moderator: %w", err)
+		logger.Error(err.Error())
 		return err
 	}
-	if isAdmin {
-		err := fmt.Errorf("cannot ban an admin")
-		log.Error(err.Error())
+	if isModerator {
+		err := fmt.Errorf("cannot ban a moderator or admin")
+		logger.Error(err.Error())
 		return err
 	}
 
 	// Get user data document reference
-	userDataRef := globals.FirestoreClient.Doc("users/" + userId)
+	userDataRef := s.Firestore.Doc("users/" + userId)
 
 	// Get user data
-	userDataDoc, err := userDataRef.Get(globals.FirebaseContext)
+	userDataDoc, err := userDataRef.Get(ctx)
 	if err != nil {
 		err = fmt.Errorf("failed getting user data: %w", err)
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return err
 	}
 
@@ -68,29 +83,41 @@ func BanUser(userId string) error {
 	rawPosts, ok := userDataDoc.Data()["posts"].([]interface{})
 	if !ok {
 		err = fmt.Errorf("failed extracting posts field from user data")
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return err
 	}
 
+	// Stop their photos from being posted again from another account
+	if err = s.BlockUserImages(ctx, userId, BlockReasonUserBanned); err != nil {
+		logger.Warn("failed blocking banned user's images: ", err)
+	}
+
 	// Convert each raw post to a *firestore.DocumentRef
+	keptPosts := make([]*firestore.DocumentRef, 0)
 	for _, rawPost := range rawPosts {
 		postRef, ok := rawPost.(*firestore.DocumentRef)
 		if !ok {
-			log.Warn("failed converting raw post to *firestore.DocumentRef")
+			logger.Warn("failed converting raw post to *firestore.DocumentRef")
 			continue
 		}
-		if _, err := postRef.Delete(globals.FirebaseContext); err != nil {
-			log.Warn("failed deleting post: %w", err)
+		if _, err := postRef.Delete(ctx); err != nil {
+			logger.WithError(err).Warn("failed deleting post")
+			keptPosts = append(keptPosts, postRef)
 			continue
 		}
 	}
 
+	// Only keep the posts that couldn't be deleted, so deleted donations aren't counted as theirs
+	if _, err = userDataRef.Update(ctx, []firestore.Update{{Path: "posts", Value: keptPosts}}); err != nil {
+		logger.WithError(err).Warn("failed removing deleted posts")
+	}
+
 	// Add them to the banned list
-	banDocRef := globals.FirestoreClient.Doc("config/bans")
+	banDocRef := s.Firestore.Doc("config/bans")
 	var banDocSnapshot *firestore.DocumentSnapshot
-	if banDocSnapshot, err = banDocRef.Get(globals.FirebaseContext); err != nil {
+	if banDocSnapshot, err = banDocRef.Get(ctx); err != nil {
 		err = fmt.Errorf("failed getting ban list: %w", err)
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return err
 	}
 
@@ -98,7 +125,7 @@ func BanUser(userId string) error {
 	var banList []string
 	if rawBanList, ok := banDocSnapshot.Data()["users"].([]interface{}); !ok {
 		err = fmt.Errorf("could not convert banned users list to []interface{}")
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return err
 	} else {
 		for _, uid := range rawBanList {
@@ -106,16 +133,34 @@ func BanUser(userId string) error {
 		}
 	}
 
-	// Add user to ban list
-	banList = append(banList, userId)
+	// Add user to ban list, unless they're still in it from an expired ban
+	if !slices.Contains(banList, userId) {
+		banList = append(banList, userId)
+	}
 
 	// Update the document with new banned user
-	banDocRef.Update(globals.FirebaseContext, []firestore.Update{
+	banDocRef.Update(ctx, []firestore.Update{
 		{
 			Path:  "users",
 			Value: banList,
 		},
 	})
 
+	// Record the details of the ban
+	banRecord := map[string]interface{}{
+		"banned_by": bannedBy,
+		"reason":    reason,
+		"banned_at": s.Clock.Now().UTC(),
+	}
+	if expiresAt != nil {
+		banRecord["expires_at"] = expiresAt.UTC()
+	}
+	if _, err = s.Firestore.Collection("bans").Doc(userId).Set(ctx, banRecord); err != nil {
+		err = fmt.Errorf("failed recording ban details: %w", err)
+		logger.Error(err.Error())
+		return err
+	}
+
+	metrics.Bans.Inc()
 	return nil
 }
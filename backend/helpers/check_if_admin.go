@@ -5,25 +5,30 @@
 package helpers
 
 import (
+	"context"
 	"fmt"
-	"relief_exchange_backend/globals"
-
-	log "github.com/sirupsen/logrus"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
 )
 
 // CheckIfAdmin checks if a user has admin privileges.
 // Parameters:
+//   - ctx: the context in which the function is invoked.
 //   - senderId: the ID of the user to check.
 //
 // Return values:
 //   - true if the user has admin privileges, false otherwise.
 //   - error, if any occurred during the check.
-func CheckIfAdmin(senderId string) (bool, error) {
+func (s *Service) CheckIfAdmin(ctx context.Context, senderId string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "helpers.CheckIfAdmin", tracing.UID(senderId))
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
 	// Get the user document
-	doc, err := globals.FirestoreClient.Doc("users/" + senderId).Get(globals.FirebaseContext)
+	doc, err := s.Firestore.Doc("users/" + senderId).Get(ctx)
 
 	if err != nil {
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return false, err
 	}
 
@@ -33,10 +38,10 @@ func CheckIfAdmin(senderId string) (bool, error) {
 	isAdmin, ok := data["admin"].(bool)
 	if !ok {
 		err = fmt.Errorf("failed getting admin field from user doc: %w", err)
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return false, err
 	}
 
-	log.Info("isAdmin: %v", isAdmin)
+	logger.WithField("is_admin", isAdmin).Debug("checked admin status")
 	return isAdmin, nil
 }
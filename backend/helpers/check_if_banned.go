@@ -5,29 +5,35 @@
 package helpers
 
 import (
+	"context"
 	"fmt"
-	"relief_exchange_backend/globals"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
 
 	"cloud.google.com/go/firestore"
-	log "github.com/sirupsen/logrus"
 	"golang.org/x/exp/slices"
 )
 
 // checkIfBanned checks whether a user is banned by looking at the config docs in Firestore.
 // Parameters:
+//   - ctx: the context in which the function is invoked.
 //   - client: the Firestore client.
 //   - userId: the ID of the user to check.
 //
 // Return values:
 //   - bool, if they are banned or not
 //   - error, if any occurred during the operation.
-func CheckIfBanned(userId string) (bool, error) {
+func (s *Service) CheckIfBanned(ctx context.Context, userId string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "helpers.CheckIfBanned", tracing.UID(userId))
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
 	// Add them to the banned list
-	banDocRef := globals.FirestoreClient.Doc("config/bans")
+	banDocRef := s.Firestore.Doc("config/bans")
 	var banDocSnapshot *firestore.DocumentSnapshot
 	var err error
-	if banDocSnapshot, err = banDocRef.Get(globals.FirebaseContext); err != nil {
-		log.Error(err.Error())
+	if banDocSnapshot, err = banDocRef.Get(ctx); err != nil {
+		logger.Error(err.Error())
 		return false, fmt.Errorf("failed getting ban list: %w", err)
 	}
 
@@ -38,7 +44,7 @@ func CheckIfBanned(userId string) (bool, error) {
 	var ok bool
 	if banListRaw, ok = banDocSnapshot.Data()["users"].([]interface{}); !ok {
 		err = fmt.Errorf("ban users list is not of type []interface{}")
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return false, err
 	}
 
@@ -46,7 +52,7 @@ func CheckIfBanned(userId string) (bool, error) {
 	var banList []string
 	for _, rawBannedUID := range banListRaw {
 		if bannedUID, ok := rawBannedUID.(string); !ok {
-			log.Warn("UID in banned list is not a string")
+			logger.Warn("UID in banned list is not a string")
 			continue
 		} else {
 			banList = append(banList, bannedUID)
@@ -54,5 +60,18 @@ func CheckIfBanned(userId string) (bool, error) {
 	}
 
 	// Return whether uid in list
-	return slices.Contains(banList, userId), nil
+	if !slices.Contains(banList, userId) {
+		return false, nil
+	}
+
+	// Temporary bans stop applying once they expire
+	record, err := s.GetBanRecord(ctx, userId)
+	if err != nil {
+		return false, err
+	}
+	if record != nil && record.ExpiresAt != nil && s.Clock.Now().After(*record.ExpiresAt) {
+		return false, nil
+	}
+
+	return true, nil
 }
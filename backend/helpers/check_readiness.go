@@ -0,0 +1,65 @@
+// This is a file in the package-"helpers" that contains the CheckReadiness function.
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"firebase.google.com/go/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ReadinessTimeout is how long each dependency gets to respond to a readiness check
+const ReadinessTimeout = 3 * time.Second
+
+// readinessProbeID is looked up to check connectivity. It doesn't exist, so finding nothing is a success.
+const readinessProbeID = "readiness-probe"
+
+// CheckReadiness checks that the backend can reach Firestore and Firebase Auth.
+// The checks run at the same time, each limited to ReadinessTimeout.
+// Parameters:
+//   - ctx: the context of the request, which cancels the checks if it ends first.
+//
+// Return values:
+//   - the error of each dependency that couldn't be reached, keyed by its name. Empty if they're all ready.
+func (s *Service) CheckReadiness(ctx context.Context) map[string]error {
+	checks := map[string]func(context.Context) error{
+		"firestore": func(ctx context.Context) error {
+			_, err := s.Firestore.Collection("config").Doc(readinessProbeID).Get(ctx)
+			if status.Code(err) == codes.NotFound {
+				return nil
+			}
+			return err
+		},
+		"auth": func(ctx context.Context) error {
+			_, err := s.Auth.GetUser(ctx, readinessProbeID)
+			if auth.IsUserNotFound(err) {
+				return nil
+			}
+			return err
+		},
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	failures := map[string]error{}
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) error) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, ReadinessTimeout)
+			defer cancel()
+			if err := check(checkCtx); err != nil {
+				mu.Lock()
+				failures[name] = fmt.Errorf("%s unreachable: %w", name, err)
+				mu.Unlock()
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	return failures
+}
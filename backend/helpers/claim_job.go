@@ -0,0 +1,56 @@
+// This is a file in the package-"helpers" that contains the claimJob function.
+package helpers
+
+import (
+	"context"
+	"errors"
+	"relief_exchange_backend/types"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+// errJobClaimed is returned when a job is already being worked on, or isn't due yet.
+var errJobClaimed = errors.New("job is already being processed")
+
+// claimJob marks a background job as running inside a transaction, so that only one
+// worker (possibly on another server) works on it at a time.
+// A job can be claimed if it's pending and due, or if it has been running for longer
+// than staleAfter, which means the worker on it died.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - jobRef: the reference to the job's document.
+//   - staleAfter: how long a job can be running before it's assumed its worker died.
+//
+// Return values:
+//   - the data of the job's document.
+//   - error, errJobClaimed if the job can't be claimed right now.
+func (s *Service) claimJob(ctx context.Context, jobRef *firestore.DocumentRef, staleAfter time.Duration) (map[string]interface{}, error) {
+	var data map[string]interface{}
+	err := s.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(jobRef)
+		if err != nil {
+			return err
+		}
+		data = doc.Data()
+
+		status, _ := data["status"].(string)
+		claimedAt, _ := data["claimed_at"].(time.Time)
+		nextAttemptAt, hasNextAttempt := data["next_attempt_at"].(time.Time)
+		due := status == types.JobStatusPending && (!hasNextAttempt || s.Clock.Now().After(nextAttemptAt))
+		stale := status == types.JobStatusRunning && s.Clock.Now().Sub(claimedAt) > staleAfter
+		if !due && !stale {
+			return errJobClaimed
+		}
+
+		return tx.Update(jobRef, []firestore.Update{
+			{Path: "status", Value: types.JobStatusRunning},
+			{Path: "claimed_at", Value: s.Clock.Now().UTC()},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
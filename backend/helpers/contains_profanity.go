@@ -0,0 +1,57 @@
+// This is a file in the package-"helpers" that contains the ContainsProfanity function.
+package helpers
+
+import (
+	"strings"
+	"unicode"
+)
+
+// profaneWords includes the words that aren't allowed in user-written profile text.
+// Only whole words are matched, so words that happen to contain one (e.g. "class") are fine.
+var profaneWords = map[string]bool{
+	"arse": true, "arsehole": true, "ass": true, "asshole": true, "bastard": true,
+	"bitch": true, "bollocks": true, "bullshit": true, "crap": true, "cunt": true,
+	"damn": true, "dick": true, "dickhead": true, "fag": true, "faggot": true,
+	"fuck": true, "fucker": true, "fucking": true, "motherfucker": true, "nigga": true,
+	"nigger": true, "piss": true, "prick": true, "pussy": true, "retard": true,
+	"shit": true, "shitty": true, "slut": true, "twat": true, "wanker": true,
+	"whore": true,
+}
+
+// leetReplacer undoes common character substitutions used to get around filters.
+var leetReplacer = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s", "!", "i",
+)
+
+// ContainsProfanity checks whether some text contains a profane word.
+// Parameters:
+//   - text: the text to check.
+//
+// Return values:
+//   - true if the text contains a profane word, false otherwise.
+func ContainsProfanity(text string) bool {
+	normalized := leetReplacer.Replace(strings.ToLower(text))
+	words := strings.FieldsFunc(normalized, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	for _, word := range words {
+		if profaneWords[word] || profaneWords[collapseRepeats(word)] {
+			return true
+		}
+	}
+	return false
+}
+
+// collapseRepeats removes letters repeated in a row, e.g. "shiiit" becomes "shit".
+func collapseRepeats(word string) string {
+	var builder strings.Builder
+	var last rune
+	for _, r := range word {
+		if r != last {
+			builder.WriteRune(r)
+		}
+		last = r
+	}
+	return builder.String()
+}
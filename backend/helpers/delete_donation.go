@@ -0,0 +1,59 @@
+// This is a file in the package-"helpers" that contains the DeleteDonation function.
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+
+	"cloud.google.com/go/firestore"
+)
+
+// DeleteDonation deletes a donation along with its images.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - donationId: the ID of the donation to delete.
+//   - removedByModerator: whether it was taken down rather than deleted by its owner,
+//     in which case its photos are added to the image blocklist so they can't be posted again.
+//
+// Return values:
+//   - error, if the donation couldn't be deleted.
+func (s *Service) DeleteDonation(ctx context.Context, donationId string, removedByModerator bool) error {
+	ctx, span := tracing.Start(ctx, "helpers.DeleteDonation", tracing.DonationID(donationId))
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
+	// Photos from donations removed by moderators can't be posted again
+	if removedByModerator {
+		if err := s.BlockDonationImages(ctx, donationId, BlockReasonDonationRemoved); err != nil {
+			logger.Error(err.Error())
+		}
+	}
+
+	// Take it out of its owner's posts along with deleting it, so it isn't counted as one of their donations.
+	// They're found by their posts rather than owner_id, which donations from before the migrations can be missing.
+	donationRef := s.Firestore.Collection("donations").Doc(donationId)
+	owners, err := s.Firestore.Collection("users").Where("posts", "array-contains", donationRef).Documents(ctx).GetAll()
+	if err != nil {
+		err = fmt.Errorf("failed finding donation's owner: %w", err)
+		logger.Error(err.Error())
+		return err
+	}
+	batch := s.Firestore.Batch()
+	batch.Delete(donationRef)
+	for _, owner := range owners {
+		batch.Update(owner.Ref, []firestore.Update{{Path: "posts", Value: firestore.ArrayRemove(donationRef)}})
+	}
+	if _, err = batch.Commit(ctx); err != nil {
+		err = fmt.Errorf("failed deleting donation: %w", err)
+		logger.Error(err.Error())
+		return err
+	}
+
+	// Clean up the donation's images, which nothing else can use now
+	if err := s.DeleteDonationImages(ctx, donationId); err != nil {
+		logger.Error(err.Error())
+	}
+	return nil
+}
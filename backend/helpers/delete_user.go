@@ -1,76 +1,409 @@
 // @author Joshua Chou
 // @cite "How to insert a reference type field on Firestore with Golang." Stack Overflow, 2021. [Online].
 // Available: https://stackoverflow.com/questions/69797221/how-to-insert-a-reference-type-field-on-firestore-with-golang. [Accessed: 26- April- 2023].
+// @cite "Delete data from Cloud Firestore | Firebase." Google, 2023. [Online].
+// Available: https://firebase.google.com/docs/firestore/manage-data/delete-data#collections. [Accessed: 19- October- 2026].
 package helpers
 
-// This is a file in the package-"helpers" that contains the DeleteUser function.
+// This is a file in the package-"helpers" that contains the functions used to delete a user's account.
 import (
+	"context"
+	"errors"
 	"fmt"
-	"relief_exchange_backend/globals"
+	"math"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+	"relief_exchange_backend/types"
+	"time"
 
 	"cloud.google.com/go/firestore"
-	log "github.com/sirupsen/logrus"
+	"firebase.google.com/go/auth"
+	"golang.org/x/exp/slices"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-// DeleteUser removes a user by removing their records from Firestore and
-// their auth data in Firebase Authentication.
+// ErrDeletionNotFound is returned when an account deletion doesn't exist.
+var ErrDeletionNotFound = errors.New("account deletion not found")
+
+// Settings for retrying account deletions
+const (
+	MaxDeletionAttempts     = 5
+	accountDeletionStale    = 15 * time.Minute
+	accountDeletionBatch    = 100
+	accountDeletionBaseWait = time.Minute
+)
+
+// RequestAccountDeletion queues up the deletion of a user's account.
+// If their account is already being deleted, that deletion is returned instead,
+// and a deletion that failed for good is restarted.
 // Parameters:
-//   - userId: the ID of the user to delete
+//   - ctx: the context in which the function is invoked.
+//   - userId: the ID of the user to delete.
 //
 // Return values:
+//   - the deletion that will remove their account.
 //   - error, if any occurred during the operation.
-func DeleteUser(userId string) error {
-	// Get user data document reference
-	userDataRef := globals.FirestoreClient.Doc("users/" + userId)
+func (s *Service) RequestAccountDeletion(ctx context.Context, userId string) (types.AccountDeletion, error) {
+	ctx, span := tracing.Start(ctx, "helpers.RequestAccountDeletion", tracing.UID(userId))
+	defer span.End()
+	logger := logging.FromContext(ctx)
 
-	// Get user data
-	userDataDoc, err := userDataRef.Get(globals.FirebaseContext)
+	// Reuse the user's existing deletion, so requesting one twice doesn't start two
+	deletions := s.Firestore.Collection("deletions")
+	existing, err := deletions.Where("uid", "==", userId).Limit(1).Documents(ctx).GetAll()
 	if err != nil {
-		err = fmt.Errorf("failed getting user data: %w", err)
-		log.Error(err.Error())
-		return err
+		err = fmt.Errorf("failed checking for existing deletion: %w", err)
+		logger.Error(err.Error())
+		return types.AccountDeletion{}, err
+	}
+	var deletionRef *firestore.DocumentRef
+	if len(existing) != 0 {
+		deletion := accountDeletionFromDoc(existing[0])
+		if deletion.Status != types.JobStatusFailed {
+			return deletion, nil
+		}
+		deletionRef = existing[0].Ref
+	} else {
+		deletionRef = deletions.NewDoc()
 	}
 
-	// Extract posts field from user data
-	// Note: .([]interface{}) is a type assertion that checks if the value returned is a slice of interfaces,
-	// The attributes in doc.Data() are assumed to be either of an unknown type or a slice of unknown type.
-	// If it were a single unknown type, we could directly convert it to the desired type.
-	// However, we can't directly convert a slice of unknown type to a slice of a specific type.
-	// Therefore, we have convert every element in the slice to the desired type,
-	// and then add it to a new slice of the desired type.
-	rawPosts, ok := userDataDoc.Data()["posts"].([]interface{})
-	if !ok {
-		err = fmt.Errorf("failed extracting posts field from user data")
-		log.Error(err.Error())
+	// Completed steps are kept when restarting, since they don't have to be run again
+	_, err = deletionRef.Set(ctx, map[string]interface{}{
+		"uid":          userId,
+		"status":       types.JobStatusPending,
+		"attempts":     0,
+		"requested_at": s.Clock.Now().UTC(),
+		"error":        firestore.Delete,
+	}, firestore.MergeAll)
+	if err != nil {
+		err = fmt.Errorf("failed creating deletion: %w", err)
+		logger.Error(err.Error())
+		return types.AccountDeletion{}, err
+	}
+
+	return s.GetAccountDeletion(ctx, deletionRef.ID)
+}
+
+// GetAccountDeletion retrieves the progress of deleting a user's account.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - deletionId: the ID of the deletion.
+//
+// Return values:
+//   - the deletion.
+//   - error, ErrDeletionNotFound if it doesn't exist.
+func (s *Service) GetAccountDeletion(ctx context.Context, deletionId string) (types.AccountDeletion, error) {
+	ctx, span := tracing.Start(ctx, "helpers.GetAccountDeletion")
+	defer span.End()
+
+	doc, err := s.Firestore.Collection("deletions").Doc(deletionId).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return types.AccountDeletion{}, ErrDeletionNotFound
+	}
+	if err != nil {
+		err = fmt.Errorf("failed getting deletion: %w", err)
+		logging.FromContext(ctx).Error(err.Error())
+		return types.AccountDeletion{}, err
+	}
+
+	return accountDeletionFromDoc(doc), nil
+}
+
+// ProcessAccountDeletion runs the remaining steps of an account deletion.
+// Nothing happens if another worker is already running it or it isn't due to be retried yet.
+// If a step fails, the deletion is retried later with an increasing wait, up to MaxDeletionAttempts times.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - deletionId: the ID of the deletion.
+//
+// Return values:
+//   - error, if any occurred during the operation.
+func (s *Service) ProcessAccountDeletion(ctx context.Context, deletionId string) error {
+	ctx, span := tracing.Start(ctx, "helpers.ProcessAccountDeletion")
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
+	deletionRef := s.Firestore.Collection("deletions").Doc(deletionId)
+	data, err := s.claimJob(ctx, deletionRef, accountDeletionStale)
+	if errors.Is(err, errJobClaimed) {
+		return nil
+	}
+	if err != nil {
+		err = fmt.Errorf("failed claiming deletion: %w", err)
+		logger.Error(err.Error())
 		return err
 	}
 
-	// Convert each raw post to a *firestore.DocumentRef and delete them
-	for _, rawPost := range rawPosts {
-		postRef, ok := rawPost.(*firestore.DocumentRef)
-		if !ok {
-			log.Warn("failed converting raw post to *firestore.DocumentRef")
+	deletion := accountDeletionFromData(deletionId, data)
+
+	for _, step := range types.DeletionSteps {
+		if slices.Contains(deletion.CompletedSteps, step) {
 			continue
 		}
-		if _, err := postRef.Delete(globals.FirebaseContext); err != nil {
-			log.Warn("failed deleting post: %w", err)
-			continue
+
+		count, err := s.runDeletionStep(ctx, deletion.UID, step)
+		if err != nil {
+			err = fmt.Errorf("failed deleting %s: %w", step, err)
+			logger.Error(err.Error())
+			s.failDeletionAttempt(ctx, deletionRef, deletion.Attempts+1, err)
+			return err
+		}
+
+		// Save progress after each step so a retry skips it
+		_, err = deletionRef.Update(ctx, []firestore.Update{
+			{Path: "completed_steps", Value: firestore.ArrayUnion(step)},
+			{Path: "progress." + step, Value: firestore.Increment(count)},
+		})
+		if err != nil {
+			err = fmt.Errorf("failed saving deletion progress: %w", err)
+			logger.Error(err.Error())
+			s.failDeletionAttempt(ctx, deletionRef, deletion.Attempts+1, err)
+			return err
 		}
 	}
 
-	// Delete user's data from Firestore
-	_, err = userDataRef.Delete(globals.FirebaseContext)
+	_, err = deletionRef.Update(ctx, []firestore.Update{
+		{Path: "status", Value: types.JobStatusComplete},
+		{Path: "completed_at", Value: s.Clock.Now().UTC()},
+		{Path: "error", Value: firestore.Delete},
+	})
 	if err != nil {
-		log.Warn("failed deleting user: %w", err)
+		err = fmt.Errorf("failed marking deletion as complete: %w", err)
+		logger.Error(err.Error())
 		return err
 	}
 
-	// Delete user from Firebase Auth
-	err = globals.AuthClient.DeleteUser(globals.FirebaseContext, userId)
-	if err != nil {
-		log.Errorf("error deleting user: %v\n", err)
-		return err
+	return nil
+}
+
+// ProcessPendingAccountDeletions runs every account deletion that is due to be retried or whose worker died.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//
+// Return values:
+//   - error, if any occurred during the operation.
+func (s *Service) ProcessPendingAccountDeletions(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "helpers.ProcessPendingAccountDeletions")
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
+	for _, jobStatus := range []string{types.JobStatusPending, types.JobStatusRunning} {
+		iter := s.Firestore.Collection("deletions").Where("status", "==", jobStatus).Documents(ctx)
+		for {
+			doc, err := iter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				logger.Error(err.Error())
+				return err
+			}
+			if err = s.ProcessAccountDeletion(ctx, doc.Ref.ID); err != nil {
+				logger.Warn("failed processing account deletion: ", err)
+			}
+		}
 	}
 
 	return nil
 }
+
+// failDeletionAttempt records a failed attempt at a deletion, and either schedules
+// a retry or marks it as failed for good.
+func (s *Service) failDeletionAttempt(ctx context.Context, deletionRef *firestore.DocumentRef, attempts int64, cause error) {
+	updates := []firestore.Update{
+		{Path: "attempts", Value: attempts},
+		{Path: "error", Value: cause.Error()},
+	}
+	if attempts >= MaxDeletionAttempts {
+		updates = append(updates, firestore.Update{Path: "status", Value: types.JobStatusFailed})
+	} else {
+		// Wait longer after each failure: 1, 2, 4, 8... minutes
+		wait := accountDeletionBaseWait * time.Duration(math.Pow(2, float64(attempts-1)))
+		updates = append(updates,
+			firestore.Update{Path: "status", Value: types.JobStatusPending},
+			firestore.Update{Path: "next_attempt_at", Value: s.Clock.Now().UTC().Add(wait)},
+		)
+	}
+
+	if _, err := deletionRef.Update(ctx, updates); err != nil {
+		logging.FromContext(ctx).Error(err.Error())
+	}
+}
+
+// runDeletionStep runs one step of deleting a user's account.
+// Every step can be run again safely if it was interrupted.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//
+// Return values:
+//   - the number of items that were deleted or changed.
+//   - error, if any occurred during the step.
+func (s *Service) runDeletionStep(ctx context.Context, userId string, step string) (int, error) {
+	switch step {
+	case types.DeletionStepDonations:
+		// Find their donations by owner instead of their posts field, so none are missed
+		return s.updateInBatches(ctx,
+			s.Firestore.Collection("donations").Where("owner_id", "==", userId),
+			func(batch *firestore.WriteBatch, doc *firestore.DocumentSnapshot) {
+				batch.Delete(doc.Ref)
+			},
+		)
+	case types.DeletionStepReports:
+		// Remove them from the reports of other people's donations
+		return s.removeUserReports(ctx, userId)
+	case types.DeletionStepImages:
+		return s.updateInBatches(ctx,
+			s.Firestore.Collection("images").Where("owner_id", "==", userId),
+			func(batch *firestore.WriteBatch, doc *firestore.DocumentSnapshot) {
+				s.deleteImageFiles(ctx, doc)
+				batch.Delete(doc.Ref)
+			},
+		)
+	case types.DeletionStepExports:
+		return s.updateInBatches(ctx,
+			s.Firestore.Collection("exports").Where("uid", "==", userId),
+			func(batch *firestore.WriteBatch, doc *firestore.DocumentSnapshot) {
+				if err := s.Exports.Delete(ctx, dataExportKey(doc.Ref.ID)); err != nil {
+					logging.FromContext(ctx).Warn("failed deleting export file: ", err)
+				}
+				batch.Delete(doc.Ref)
+			},
+		)
+	case types.DeletionStepProfile:
+		// Deleting a document that doesn't exist isn't an error
+		if _, err := s.Firestore.Collection("users").Doc(userId).Delete(ctx); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	case types.DeletionStepAuth:
+		err := s.deleteAuthUser(ctx, userId)
+		if err != nil && !auth.IsUserNotFound(err) {
+			return 0, err
+		}
+		return 1, nil
+	default:
+		return 0, fmt.Errorf("unknown deletion step: %s", step)
+	}
+}
+
+// updateInBatches applies a write to every document matched by a query, a batch at a time.
+// The query is run again after each batch, so the write must stop the document from matching.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//
+// Return values:
+//   - the number of documents written to.
+//   - error, if any occurred during the operation.
+func (s *Service) updateInBatches(ctx context.Context, query firestore.Query, write func(batch *firestore.WriteBatch, doc *firestore.DocumentSnapshot)) (int, error) {
+	total := 0
+	for {
+		docs, err := query.Limit(accountDeletionBatch).Documents(ctx).GetAll()
+		if err != nil {
+			return total, err
+		}
+		if len(docs) == 0 {
+			return total, nil
+		}
+
+		batch := s.Firestore.Batch()
+		for _, doc := range docs {
+			write(batch, doc)
+		}
+		if _, err = batch.Commit(ctx); err != nil {
+			return total, err
+		}
+		total += len(docs)
+	}
+}
+
+// removeUserReports removes a user from the reports of every donation they reported.
+// Each donation's report_count is counted again from its reports in a transaction, rather than decremented,
+// since donations that haven't been migrated don't have one yet.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - userId: the ID of the user whose reports to remove.
+//
+// Return values:
+//   - the number of donations changed.
+//   - error, if any occurred during the operation.
+func (s *Service) removeUserReports(ctx context.Context, userId string) (int, error) {
+	// Only the refs are needed, since each donation is read again in its transaction
+	query := s.Firestore.Collection("donations").Where("reports", "array-contains", userId).Select().Limit(accountDeletionBatch)
+	total := 0
+	for {
+		docs, err := query.Documents(ctx).GetAll()
+		if err != nil {
+			return total, err
+		}
+		if len(docs) == 0 {
+			return total, nil
+		}
+
+		for _, doc := range docs {
+			err = s.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+				donation, err := tx.Get(doc.Ref)
+				if status.Code(err) == codes.NotFound {
+					return nil // Deleted since it was found
+				}
+				if err != nil {
+					return err
+				}
+
+				rawReports, _ := stringList(donation.Data()["reports"])
+				reports := make([]string, 0, len(rawReports))
+				for _, report := range rawReports {
+					if report != userId {
+						reports = append(reports, report)
+					}
+				}
+				return tx.Update(doc.Ref, []firestore.Update{
+					{Path: "reports", Value: reports},
+					{Path: "report_count", Value: len(reports)},
+				})
+			})
+			if err != nil {
+				return total, fmt.Errorf("failed removing reports from %s: %w", doc.Ref.ID, err)
+			}
+			total++
+		}
+	}
+}
+
+// accountDeletionFromDoc converts a deletion's Firestore document to an AccountDeletion object.
+func accountDeletionFromDoc(doc *firestore.DocumentSnapshot) types.AccountDeletion {
+	return accountDeletionFromData(doc.Ref.ID, doc.Data())
+}
+
+// accountDeletionFromData converts the data of a deletion's Firestore document to an AccountDeletion object.
+func accountDeletionFromData(id string, data map[string]interface{}) types.AccountDeletion {
+	deletion := types.AccountDeletion{
+		ID:             id,
+		CompletedSteps: make([]string, 0),
+		Progress:       map[string]int{},
+	}
+	deletion.UID, _ = data["uid"].(string)
+	deletion.Status, _ = data["status"].(string)
+	deletion.Attempts, _ = data["attempts"].(int64)
+	deletion.RequestedAt, _ = data["requested_at"].(time.Time)
+	deletion.Error, _ = data["error"].(string)
+	if completedAt, ok := data["completed_at"].(time.Time); ok {
+		deletion.CompletedAt = &completedAt
+	}
+	if rawSteps, ok := data["completed_steps"].([]interface{}); ok {
+		for _, rawStep := range rawSteps {
+			if step, ok := rawStep.(string); ok {
+				deletion.CompletedSteps = append(deletion.CompletedSteps, step)
+			}
+		}
+	}
+	if rawProgress, ok := data["progress"].(map[string]interface{}); ok {
+		for step, rawCount := range rawProgress {
+			if count, ok := rawCount.(int64); ok {
+				deletion.Progress[step] = int(count)
+			}
+		}
+	}
+	return deletion
+}
@@ -0,0 +1,102 @@
+// This is a file in the package-"helpers" that contains the donationFromDoc function.
+package helpers
+
+import (
+	"context"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/types"
+
+	"cloud.google.com/go/firestore"
+)
+
+// donationFromDoc converts a donation's Firestore document to a Donation object.
+// Every endpoint returning donations goes through this, so they all look the same to clients.
+// Parameters:
+//   - doc: the snapshot of the donation's document.
+//
+// Return values:
+//   - Donation object stored in the document.
+//   - error, if any occurred during the conversion.
+func donationFromDoc(ctx context.Context, doc *firestore.DocumentSnapshot) (types.Donation, error) {
+	logger := logging.FromContext(ctx)
+	var donation types.Donation
+	err := doc.DataTo(&donation)
+	if err != nil {
+		logger.Error(err.Error())
+		return types.Donation{}, err
+	}
+
+	// Documents older than the migrations may be missing fields, or have them with the wrong types
+	data := doc.Data()
+	if schemaVersionOf(data) < SchemaVersion("donations") {
+		logger.WithField("donation_id", doc.Ref.ID).Warn("donation hasn't been migrated, run the schema migrations")
+	}
+
+	// Clients expect lists rather than null
+	if donation.Reports == nil {
+		donation.Reports = make([]string, 0)
+	}
+	if donation.Flags == nil {
+		donation.Flags = make([]string, 0)
+	}
+	donation.Images = donationImagesFromData(ctx, data)
+	if len(donation.Images) != 0 {
+		donation.Image = donation.Images[0].URL
+	}
+
+	donation.ID = doc.Ref.ID // ID is stored in the Ref feild, so DataTo, does not store id in the donations object
+	return donation, nil
+}
+
+// donationImagesFromData converts the "images" field of a donation document to a slice of images.
+// Donations made before multiple images were supported only have the "img" field, so it is used instead.
+func donationImagesFromData(ctx context.Context, data map[string]interface{}) []types.DonationImage {
+	images := make([]types.DonationImage, 0)
+	rawImages, ok := data["images"].([]interface{})
+	if !ok {
+		if legacyImage, _ := data["img"].(string); legacyImage != "" {
+			title, _ := data["title"].(string)
+			images = append(images, types.DonationImage{URL: legacyImage, AltText: title})
+		}
+		return images
+	}
+
+	for _, rawImage := range rawImages {
+		imageData, ok := rawImage.(map[string]interface{})
+		if !ok {
+			logging.FromContext(ctx).Warn("donation image is not a map")
+			continue
+		}
+		var image types.DonationImage
+		image.ImageID, _ = imageData["image_id"].(string)
+		image.URL, _ = imageData["url"].(string)
+		image.MediumURL, _ = imageData["medium_url"].(string)
+		image.ThumbnailURL, _ = imageData["thumbnail_url"].(string)
+		image.AltText, _ = imageData["alt"].(string)
+		images = append(images, image)
+	}
+	return images
+}
+
+// donationImagesToData converts a donation's images to the value stored in its "images" field.
+func donationImagesToData(images []types.DonationImage) []map[string]interface{} {
+	data := make([]map[string]interface{}, 0, len(images))
+	for _, image := range images {
+		data = append(data, map[string]interface{}{
+			"image_id":      image.ImageID,
+			"url":           image.URL,
+			"medium_url":    image.MediumURL,
+			"thumbnail_url": image.ThumbnailURL,
+			"alt":           image.AltText,
+		})
+	}
+	return data
+}
+
+// coverImageURL gets the URL stored in a donation's legacy "img" field, which is its first image.
+func coverImageURL(images []types.DonationImage) string {
+	if len(images) == 0 {
+		return ""
+	}
+	return images[0].URL
+}
@@ -0,0 +1,403 @@
+// This is a file in the package-"helpers" that contains the functions for managing a donation's images.
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+	"relief_exchange_backend/types"
+	"strings"
+	"unicode/utf8"
+
+	"cloud.google.com/go/firestore"
+	"golang.org/x/exp/slices"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Limits on a donation's images
+const (
+	MaxDonationImages     = 10
+	MaxImageAltTextLength = 250
+)
+
+var (
+	ErrTooManyImages     = fmt.Errorf("a donation can have at most %d images", MaxDonationImages)
+	ErrInvalidAltText    = fmt.Errorf("alt text must be at most %d characters and can't contain profanity", MaxImageAltTextLength)
+	ErrImageNotFound     = errors.New("image not found")
+	ErrImageUnavailable  = errors.New("image was uploaded by someone else or is already used by a donation")
+	ErrInvalidImageOrder = errors.New("new order must include every image of the donation exactly once")
+	ErrImageURL          = errors.New("images must be uploaded and given by image_id, img can't be set")
+)
+
+// CanEditDonation checks whether a user may change a donation.
+// Only the donation's owner or an admin can, and neither can while banned.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - userId: the ID of the user making the change.
+//   - donation: the donation being changed.
+//
+// Return values:
+//   - true if the user can edit the donation.
+//   - error, if any occurred during the checks.
+func (s *Service) CanEditDonation(ctx context.Context, userId string, donation types.Donation) (bool, error) {
+	ctx, span := tracing.Start(ctx, "helpers.CanEditDonation", tracing.UID(userId))
+	defer span.End()
+
+	banned, err := s.CheckIfBanned(ctx, userId)
+	if err != nil {
+		return false, fmt.Errorf("err while checking if banned: %w", err)
+	}
+	if banned {
+		return false, nil
+	}
+	if donation.OwnerId == userId {
+		return true, nil
+	}
+	return s.CheckIfAdmin(ctx, userId)
+}
+
+// AddDonationImage attaches an uploaded image to the end of a donation's images.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - donationId: the ID of the donation.
+//   - userId: the ID of the user adding the image, who must have uploaded it.
+//   - imageId: the ID returned when the image was uploaded.
+//   - altText: a description of the image for screen readers.
+//
+// Return values:
+//   - the donation's images after the change.
+//   - error, if any occurred during the operation.
+func (s *Service) AddDonationImage(ctx context.Context, donationId string, userId string, imageId string, altText string) ([]types.DonationImage, error) {
+	ctx, span := tracing.Start(ctx, "helpers.AddDonationImage", tracing.DonationID(donationId), tracing.UID(userId))
+	defer span.End()
+
+	altText, err := validateAltText(altText)
+	if err != nil {
+		return nil, err
+	}
+
+	flagged, err := s.screenDonationImages(ctx, []string{imageId})
+	if err != nil {
+		return nil, err
+	}
+
+	var images []types.DonationImage
+	donationRef := s.Firestore.Collection("donations").Doc(donationId)
+	err = s.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		donationDoc, err := tx.Get(donationRef)
+		if err != nil {
+			return err
+		}
+		imageDoc, err := tx.Get(s.Firestore.Collection("images").Doc(imageId))
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return ErrImageNotFound
+			}
+			return err
+		}
+
+		images = donationImagesFromData(ctx, donationDoc.Data())
+		if len(images) >= MaxDonationImages {
+			return ErrTooManyImages
+		}
+		image, err := attachableDonationImage(imageDoc, userId, altText)
+		if err != nil {
+			return err
+		}
+		images = append(images, image)
+
+		updates := donationImagesUpdate(images)
+		if flagged {
+			updates = append(updates, firestore.Update{Path: "flags", Value: firestore.ArrayUnion(types.DonationFlagBannedImage)})
+		}
+		if err = tx.Update(donationRef, updates); err != nil {
+			return err
+		}
+		return tx.Update(imageDoc.Ref, []firestore.Update{{Path: "donation_id", Value: donationId}})
+	})
+	if err != nil {
+		err = fmt.Errorf("error while adding donation image: %w", err)
+		logging.FromContext(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	return images, nil
+}
+
+// RemoveDonationImage removes an image from a donation and deletes its stored files.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - donationId: the ID of the donation.
+//   - imageId: the ID of the image to remove.
+//
+// Return values:
+//   - the donation's images after the change.
+//   - error, if any occurred during the operation.
+func (s *Service) RemoveDonationImage(ctx context.Context, donationId string, imageId string) ([]types.DonationImage, error) {
+	ctx, span := tracing.Start(ctx, "helpers.RemoveDonationImage", tracing.DonationID(donationId))
+	defer span.End()
+
+	var images []types.DonationImage
+	var imageDoc *firestore.DocumentSnapshot
+	donationRef := s.Firestore.Collection("donations").Doc(donationId)
+	err := s.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		donationDoc, err := tx.Get(donationRef)
+		if err != nil {
+			return err
+		}
+		imageDoc, err = tx.Get(s.Firestore.Collection("images").Doc(imageId))
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+
+		images = donationImagesFromData(ctx, donationDoc.Data())
+		index := slices.IndexFunc(images, func(image types.DonationImage) bool { return image.ImageID == imageId })
+		if index == -1 {
+			return ErrImageNotFound
+		}
+		images = slices.Delete(images, index, index+1)
+
+		if err = tx.Update(donationRef, donationImagesUpdate(images)); err != nil {
+			return err
+		}
+		if imageDoc.Exists() && imageDoc.Data()["donation_id"] == donationId {
+			return tx.Delete(imageDoc.Ref)
+		}
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("error while removing donation image: %w", err)
+		logging.FromContext(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	// Files can only be deleted once the image is no longer referenced
+	if imageDoc.Exists() && imageDoc.Data()["donation_id"] == donationId {
+		s.deleteImageFiles(ctx, imageDoc)
+	}
+	return images, nil
+}
+
+// ReorderDonationImages changes the order a donation's images are shown in.
+// The first image is used as the donation's cover.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - donationId: the ID of the donation.
+//   - imageIds: the IDs of every image of the donation, in their new order.
+//
+// Return values:
+//   - the donation's images after the change.
+//   - error, if any occurred during the operation.
+func (s *Service) ReorderDonationImages(ctx context.Context, donationId string, imageIds []string) ([]types.DonationImage, error) {
+	ctx, span := tracing.Start(ctx, "helpers.ReorderDonationImages", tracing.DonationID(donationId))
+	defer span.End()
+
+	var images []types.DonationImage
+	donationRef := s.Firestore.Collection("donations").Doc(donationId)
+	err := s.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		donationDoc, err := tx.Get(donationRef)
+		if err != nil {
+			return err
+		}
+
+		current := donationImagesFromData(ctx, donationDoc.Data())
+		if len(imageIds) != len(current) {
+			return ErrInvalidImageOrder
+		}
+		images = make([]types.DonationImage, 0, len(current))
+		for _, imageId := range imageIds {
+			index := slices.IndexFunc(current, func(image types.DonationImage) bool { return image.ImageID == imageId })
+			if index == -1 {
+				return ErrInvalidImageOrder
+			}
+			images = append(images, current[index])
+			// Stop the same image from being listed twice
+			current = slices.Delete(current, index, index+1)
+		}
+
+		return tx.Update(donationRef, donationImagesUpdate(images))
+	})
+	if err != nil {
+		err = fmt.Errorf("error while reordering donation images: %w", err)
+		logging.FromContext(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	return images, nil
+}
+
+// SetDonationImageAlt changes the alt text of one of a donation's images.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - donationId: the ID of the donation.
+//   - imageId: the ID of the image.
+//   - altText: the new description of the image.
+//
+// Return values:
+//   - the donation's images after the change.
+//   - error, if any occurred during the operation.
+func (s *Service) SetDonationImageAlt(ctx context.Context, donationId string, imageId string, altText string) ([]types.DonationImage, error) {
+	ctx, span := tracing.Start(ctx, "helpers.SetDonationImageAlt", tracing.DonationID(donationId))
+	defer span.End()
+
+	altText, err := validateAltText(altText)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []types.DonationImage
+	donationRef := s.Firestore.Collection("donations").Doc(donationId)
+	err = s.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		donationDoc, err := tx.Get(donationRef)
+		if err != nil {
+			return err
+		}
+
+		images = donationImagesFromData(ctx, donationDoc.Data())
+		index := slices.IndexFunc(images, func(image types.DonationImage) bool { return image.ImageID == imageId })
+		if index == -1 {
+			return ErrImageNotFound
+		}
+		images[index].AltText = altText
+
+		return tx.Update(donationRef, donationImagesUpdate(images))
+	})
+	if err != nil {
+		err = fmt.Errorf("error while setting image alt text: %w", err)
+		logging.FromContext(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	return images, nil
+}
+
+// DeleteDonationImages deletes every image attached to a donation, along with their stored files.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - donationId: the ID of the donation, which has already been deleted.
+//
+// Return values:
+//   - error, if any occurred during the operation.
+func (s *Service) DeleteDonationImages(ctx context.Context, donationId string) error {
+	ctx, span := tracing.Start(ctx, "helpers.DeleteDonationImages", tracing.DonationID(donationId))
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
+	docs, err := s.Firestore.Collection("images").Where("donation_id", "==", donationId).Documents(ctx).GetAll()
+	if err != nil {
+		err = fmt.Errorf("error while getting donation images: %w", err)
+		logger.Error(err.Error())
+		return err
+	}
+
+	for _, doc := range docs {
+		if _, err = doc.Ref.Delete(ctx); err != nil {
+			err = fmt.Errorf("error while deleting donation image: %w", err)
+			logger.Error(err.Error())
+			return err
+		}
+		s.deleteImageFiles(ctx, doc)
+	}
+	return nil
+}
+
+// resolveDonationImages checks the images given for a new donation and fills in their URLs.
+// Images have to be uploaded ones, referenced by ID, so they've been screened against the blocklist.
+// A legacy URL in donation.Image is refused, since it could be a photo that was blocklisted.
+// The image documents are read in the transaction, so they can't be attached twice.
+func (s *Service) resolveDonationImages(tx *firestore.Transaction, donation types.Donation, userId string) ([]types.DonationImage, []*firestore.DocumentRef, error) {
+	images := make([]types.DonationImage, 0, len(donation.Images))
+	imageRefs := make([]*firestore.DocumentRef, 0, len(donation.Images))
+
+	if donation.Image != "" {
+		return nil, nil, ErrImageURL
+	}
+	if len(donation.Images) > MaxDonationImages {
+		return nil, nil, ErrTooManyImages
+	}
+	for _, requested := range donation.Images {
+		altText, err := validateAltText(requested.AltText)
+		if err != nil {
+			return nil, nil, err
+		}
+		if requested.ImageID == "" || slices.Contains(imageIds(images), requested.ImageID) {
+			return nil, nil, ErrImageNotFound
+		}
+		imageDoc, err := tx.Get(s.Firestore.Collection("images").Doc(requested.ImageID))
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return nil, nil, ErrImageNotFound
+			}
+			return nil, nil, err
+		}
+		image, err := attachableDonationImage(imageDoc, userId, altText)
+		if err != nil {
+			return nil, nil, err
+		}
+		images = append(images, image)
+		imageRefs = append(imageRefs, imageDoc.Ref)
+	}
+	return images, imageRefs, nil
+}
+
+// attachableDonationImage converts an uploaded image's document to a DonationImage,
+// after checking that the user uploaded it and it isn't used by a donation yet.
+func attachableDonationImage(imageDoc *firestore.DocumentSnapshot, userId string, altText string) (types.DonationImage, error) {
+	data := imageDoc.Data()
+	ownerId, _ := data["owner_id"].(string)
+	donationId, _ := data["donation_id"].(string)
+	if ownerId != userId || donationId != "" {
+		return types.DonationImage{}, ErrImageUnavailable
+	}
+
+	image := types.DonationImage{ImageID: imageDoc.Ref.ID, AltText: altText}
+	if urls, ok := data["urls"].(map[string]interface{}); ok {
+		image.URL, _ = urls[RenditionLarge].(string)
+		image.MediumURL, _ = urls[RenditionMedium].(string)
+		image.ThumbnailURL, _ = urls[RenditionThumbnail].(string)
+	}
+	return image, nil
+}
+
+// donationImagesUpdate builds the Firestore updates that store a donation's images.
+// The legacy "img" field is kept pointing at the cover image for older clients.
+func donationImagesUpdate(images []types.DonationImage) []firestore.Update {
+	return []firestore.Update{
+		{Path: "images", Value: donationImagesToData(images)},
+		{Path: "img", Value: coverImageURL(images)},
+	}
+}
+
+// validateAltText trims alt text and checks that it's acceptable.
+func validateAltText(altText string) (string, error) {
+	altText = strings.TrimSpace(altText)
+	if utf8.RuneCountInString(altText) > MaxImageAltTextLength || ContainsProfanity(altText) {
+		return "", ErrInvalidAltText
+	}
+	return altText, nil
+}
+
+// imageIds gets the IDs of a list of donation images.
+func imageIds(images []types.DonationImage) []string {
+	ids := make([]string, 0, len(images))
+	for _, image := range images {
+		ids = append(ids, image.ImageID)
+	}
+	return ids
+}
+
+// deleteImageFiles deletes every stored rendition of an image.
+// Failures are only logged, since the image is no longer referenced anywhere.
+func (s *Service) deleteImageFiles(ctx context.Context, imageDoc *firestore.DocumentSnapshot) {
+	rawKeys, _ := imageDoc.Data()["keys"].(map[string]interface{})
+	for _, rawKey := range rawKeys {
+		if key, ok := rawKey.(string); ok {
+			if err := s.Images.Delete(ctx, key); err != nil {
+				logging.FromContext(ctx).Warn("failed deleting image file: ", err)
+			}
+		}
+	}
+}
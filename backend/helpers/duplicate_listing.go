@@ -0,0 +1,228 @@
+// @cite "MinHash and Jaccard similarity." Mining of Massive Datasets, ch. 3, 2020. [Online].
+// Available: http://www.mmds.org/. [Accessed: 19- October- 2026].
+// This is a file in the package-"helpers" that contains the functions for detecting duplicate listings.
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+	"relief_exchange_backend/types"
+	"strings"
+	"unicode"
+
+	"cloud.google.com/go/firestore"
+	log "github.com/sirupsen/logrus"
+)
+
+// What to do with a new donation that's nearly the same as an existing one, set in the duplicates limits of the config
+const (
+	DuplicateActionReject = "reject" // Refuse to post it
+	DuplicateActionMerge  = "merge"  // Add its images and tags to the existing donation instead
+	DuplicateActionFlag   = "flag"   // Post it, but flag it for moderators
+)
+
+// How much each part of a listing counts towards its similarity
+const (
+	titleSimilarityWeight       = 0.5
+	descriptionSimilarityWeight = 0.35
+	tagSimilarityWeight         = 0.15
+)
+
+// shingleLength is the number of characters in each shingle
+const shingleLength = 4
+
+// nearbyDuplicateLimit is how many donations from the same location are compared
+const nearbyDuplicateLimit = 50
+
+// ErrDuplicateListing is returned when a donation is rejected for being the same as an existing one.
+var ErrDuplicateListing = errors.New("this looks the same as a donation that's already posted")
+
+// ListingSimilarity measures how alike two donations are, from 0 for nothing in common to 1 for the same.
+// Titles and descriptions are compared by the Jaccard similarity of their character shingles,
+// so small edits and reordered words still count as mostly the same, and tags by the
+// Jaccard similarity of the tags themselves. Parts both donations left blank don't count either way.
+// Parameters:
+//   - a, b: the donations to compare.
+//
+// Return values:
+//   - the weighted similarity of the donations.
+func ListingSimilarity(a types.Donation, b types.Donation) float64 {
+	similarity, totalWeight := 0.0, 0.0
+	compare := func(weight float64, setA map[string]struct{}, setB map[string]struct{}) {
+		if len(setA) == 0 && len(setB) == 0 {
+			return
+		}
+		similarity += weight * jaccard(setA, setB)
+		totalWeight += weight
+	}
+	compare(titleSimilarityWeight, shingles(a.Title), shingles(b.Title))
+	compare(descriptionSimilarityWeight, shingles(a.Description), shingles(b.Description))
+	compare(tagSimilarityWeight, tagSet(a.Tags), tagSet(b.Tags))
+
+	if totalWeight == 0 {
+		return 0
+	}
+	return similarity / totalWeight
+}
+
+// FindDuplicateListing looks for an existing donation that a new one is nearly the same as.
+// The user's own donations are always compared, and others' from the same location if nearby is set.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - donation: the new donation.
+//   - userId: the ID of the user posting it.
+//   - threshold: the similarity at which listings are duplicates.
+//   - nearby: whether to compare donations from other users in the same location.
+//
+// Return values:
+//   - the most similar duplicate, or nil if there isn't one.
+//   - error, if any occurred while getting the existing donations.
+func (s *Service) FindDuplicateListing(ctx context.Context, donation types.Donation, userId string, threshold float64, nearby bool) (*types.Donation, error) {
+	ctx, span := tracing.Start(ctx, "helpers.FindDuplicateListing", tracing.UID(userId))
+	defer span.End()
+
+	candidates, err := s.Firestore.Collection("donations").Where("owner_id", "==", userId).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("error while getting user's donations: %w", err)
+	}
+	if nearby && strings.TrimSpace(donation.Location) != "" {
+		nearbyDocs, err := s.Firestore.Collection("donations").
+			Where("location", "==", donation.Location).
+			Limit(nearbyDuplicateLimit).
+			Documents(ctx).GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("error while getting nearby donations: %w", err)
+		}
+		candidates = append(candidates, nearbyDocs...)
+	}
+
+	var duplicate *types.Donation
+	bestSimilarity := threshold
+	for _, doc := range candidates {
+		existing, err := donationFromDoc(ctx, doc)
+		if err != nil {
+			continue
+		}
+		if similarity := ListingSimilarity(donation, existing); similarity >= bestSimilarity {
+			duplicate = &existing
+			bestSimilarity = similarity
+		}
+	}
+
+	if duplicate != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{"duplicate_of": duplicate.ID, "similarity": bestSimilarity}).Warn("duplicate listing detected")
+	}
+	return duplicate, nil
+}
+
+// mergeDuplicateListing adds the images and tags of a new donation to the user's existing one,
+// instead of posting it again. The existing donation keeps its place in the list.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - existingId: the ID of the donation being merged into.
+//   - donation: the new donation.
+//   - userId: the ID of the user posting it.
+//
+// Return values:
+//   - error, if any occurred during the operation.
+func (s *Service) mergeDuplicateListing(ctx context.Context, existingId string, donation types.Donation, userId string) error {
+	existingRef := s.Firestore.Collection("donations").Doc(existingId)
+	err := s.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		existingDoc, err := tx.Get(existingRef)
+		if err != nil {
+			return err
+		}
+		newImages, imageRefs, err := s.resolveDonationImages(tx, donation, userId)
+		if err != nil {
+			return err
+		}
+
+		images := donationImagesFromData(ctx, existingDoc.Data())
+		if len(images)+len(newImages) > MaxDonationImages {
+			return ErrTooManyImages
+		}
+		images = append(images, newImages...)
+
+		updates := donationImagesUpdate(images)
+		if len(donation.Tags) != 0 {
+			tags := make([]interface{}, 0, len(donation.Tags))
+			for _, tag := range donation.Tags {
+				tags = append(tags, tag)
+			}
+			updates = append(updates, firestore.Update{Path: "tags", Value: firestore.ArrayUnion(tags...)})
+		}
+		if err = tx.Update(existingRef, updates); err != nil {
+			return err
+		}
+		for _, imageRef := range imageRefs {
+			if err = tx.Update(imageRef, []firestore.Update{{Path: "donation_id", Value: existingId}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("error while merging duplicate donation: %w", err)
+		logging.FromContext(ctx).Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+// shingles splits text into the set of overlapping runs of shingleLength characters,
+// after lowercasing it and collapsing punctuation and whitespace.
+// Text shorter than a shingle becomes a single shingle.
+func shingles(text string) map[string]struct{} {
+	var normalized []rune
+	lastWasSpace := true
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			normalized = append(normalized, r)
+			lastWasSpace = false
+		} else if !lastWasSpace {
+			normalized = append(normalized, ' ')
+			lastWasSpace = true
+		}
+	}
+	normalized = []rune(strings.TrimSpace(string(normalized)))
+
+	set := map[string]struct{}{}
+	if len(normalized) == 0 {
+		return set
+	}
+	if len(normalized) <= shingleLength {
+		set[string(normalized)] = struct{}{}
+		return set
+	}
+	for i := 0; i+shingleLength <= len(normalized); i++ {
+		set[string(normalized[i:i+shingleLength])] = struct{}{}
+	}
+	return set
+}
+
+// tagSet converts a list of tags to a set, ignoring case.
+func tagSet(tags []string) map[string]struct{} {
+	set := map[string]struct{}{}
+	for _, tag := range tags {
+		set[strings.ToLower(strings.TrimSpace(tag))] = struct{}{}
+	}
+	return set
+}
+
+// jaccard computes the Jaccard similarity of two sets, the size of their intersection over their union.
+// Two empty sets have nothing in common.
+func jaccard(a map[string]struct{}, b map[string]struct{}) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for item := range a {
+		if _, ok := b[item]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
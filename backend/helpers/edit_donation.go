@@ -3,44 +3,52 @@ package helpers
 // @author Aritro Saha
 // This is a file in the package-"helpers" that contains the EditDonation function.
 import (
+	"context"
 	"fmt"
-	"relief_exchange_backend/globals"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
 	"relief_exchange_backend/types"
 
-	log "github.com/sirupsen/logrus"
+	"cloud.google.com/go/firestore"
 )
 
 // EditDonation edits an existing donation record on Firestore.
 // Parameters:
+//   - ctx: the context in which the function is invoked.
 //   - newDonation: the new Donation data.
 //   - currId: the ID of the current donation
 //
 // Return values:
 //   - error, if any occurred during the operation.
-func EditDonation(newDonation types.Donation, currId string) error {
+func (s *Service) EditDonation(ctx context.Context, newDonation types.Donation, currId string) error {
+	ctx, span := tracing.Start(ctx, "helpers.EditDonation", tracing.DonationID(currId))
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
 	// Get a reference to the current Donation doc
-	docRef := globals.FirestoreClient.Collection("donations").Doc(currId)
-	oldData, err := docRef.Get(globals.FirebaseContext)
+	docRef := s.Firestore.Collection("donations").Doc(currId)
+	oldData, err := docRef.Get(ctx)
 	if err != nil {
 		err = fmt.Errorf("err while getting current donation ref: %w", err)
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return err
 	}
 
 	// Change current donation data to new data
-	_, err = docRef.Set(globals.FirebaseContext, map[string]interface{}{
+	// Images are left alone, they're changed through their own endpoints
+	_, err = docRef.Set(ctx, map[string]interface{}{
 		"title":              newDonation.Title,
 		"description":        newDonation.Description,
 		"location":           newDonation.Location,
-		"img":                oldData.Data()["img"].(string), // Don't allow editing photos
 		"owner_id":           oldData.Data()["owner_id"].(string),
 		"creation_timestamp": newDonation.CreationTimestamp,
 		"tags":               newDonation.Tags,
 		"reports":            make([]string, 0),
-	})
+		"report_count":       0,
+	}, firestore.MergeAll)
 	if err != nil {
 		err = fmt.Errorf("error while updating donation: %w", err)
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return err
 	}
 
@@ -0,0 +1,37 @@
+// This is a file in the package-"helpers" that contains the existingPosts function.
+package helpers
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+)
+
+// existingPosts gets the posts of a user whose donations haven't been deleted.
+// Deleting a donation takes it out of its owner's posts, but ones deleted before that was done are left over.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - posts: the user's posts.
+//
+// Return values:
+//   - the posts whose donations still exist, in the same order.
+//   - error, if the donations couldn't be read.
+func (s *Service) existingPosts(ctx context.Context, posts []*firestore.DocumentRef) ([]*firestore.DocumentRef, error) {
+	existing := make([]*firestore.DocumentRef, 0, len(posts))
+	if len(posts) == 0 {
+		return existing, nil
+	}
+
+	// They're all read in one batch
+	docs, err := s.Firestore.GetAll(ctx, posts)
+	if err != nil {
+		return nil, fmt.Errorf("failed getting posts: %w", err)
+	}
+	for _, doc := range docs {
+		if doc.Exists() {
+			existing = append(existing, doc.Ref)
+		}
+	}
+	return existing, nil
+}
@@ -5,11 +5,11 @@
 package helpers
 
 import (
-	"relief_exchange_backend/globals"
+	"context"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
 	"relief_exchange_backend/types"
-	"time"
 
-	log "github.com/sirupsen/logrus"
 	"google.golang.org/api/iterator"
 )
 
@@ -21,9 +21,13 @@ import (
 // Return values:
 //   - Slice of all Donation objects retrieved.
 //   - error, if any occurred during retrieval.
-func GetAllDonations() ([]types.Donation, error) {
+func (s *Service) GetAllDonations(ctx context.Context) ([]types.Donation, error) {
+	ctx, span := tracing.Start(ctx, "helpers.GetAllDonations")
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
 	var donations []types.Donation
-	iter := globals.FirestoreClient.Collection("donations").Documents(globals.FirebaseContext) //.Documents(ctx) returns a iterator
+	iter := s.Firestore.Collection("donations").Documents(ctx) //.Documents(ctx) returns a iterator
 	for {
 		// doc is the firestore document, err stores any potential errors in the iterator (such as if it is finished),
 		//iter.Next() goes to the next document in the iter varible defined above.
@@ -32,26 +36,17 @@ func GetAllDonations() ([]types.Donation, error) {
 			break
 		}
 		if err != nil {
-			log.Error(err.Error())
+			logger.Error(err.Error())
 			return nil, err // no data was retrieved-nil, but there was an error -err
 		}
-		var donation types.Donation
-		err = doc.DataTo(&donation)
-
-		// Override some attributes that don't work with DataTo
-		donation.Image = doc.Data()["img"].(string)
-		donation.OwnerId = doc.Data()["owner_id"].(string)
-		donation.CreationTimestamp = doc.Data()["creation_timestamp"].(time.Time)
-
+		donation, err := donationFromDoc(ctx, doc)
 		if err != nil {
-			log.Error(err.Error())
 			return nil, err
 		}
-		donation.ID = doc.Ref.ID // sets donation struct id to the one in the firebase
 		donations = append(donations, donation)
 	}
 
-	log.Info("donations:%v", donations)
+	logger.WithField("count", len(donations)).Debug("donations retrieved")
 
 	return donations, nil // nil-data was retrived without any errors
 }
@@ -0,0 +1,48 @@
+// This is a file in the package-"helpers" that contains the GetBanRecord function.
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+	"relief_exchange_backend/types"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetBanRecord retrieves the details of a user's ban.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - userId: the ID of the banned user.
+//
+// Return values:
+//   - the ban's details, or nil if there are none (e.g. bans made before details were recorded).
+//   - error, if any occurred during retrieval.
+func (s *Service) GetBanRecord(ctx context.Context, userId string) (*types.BanRecord, error) {
+	ctx, span := tracing.Start(ctx, "helpers.GetBanRecord", tracing.UID(userId))
+	defer span.End()
+
+	doc, err := s.Firestore.Collection("bans").Doc(userId).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		err = fmt.Errorf("failed getting ban record: %w", err)
+		logging.FromContext(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	data := doc.Data()
+	record := types.BanRecord{UID: userId}
+	record.BannedBy, _ = data["banned_by"].(string)
+	record.Reason, _ = data["reason"].(string)
+	record.BannedAt, _ = data["banned_at"].(time.Time)
+	if expiresAt, ok := data["expires_at"].(time.Time); ok {
+		record.ExpiresAt = &expiresAt
+	}
+
+	return &record, nil
+}
@@ -0,0 +1,106 @@
+// This is a file in the package-"helpers" that contains the GetMe function.
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+	"relief_exchange_backend/types"
+
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetMe assembles everything the frontend needs to know about the signed-in user
+// at once, so it doesn't have to make several requests when they sign in.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - userId: the ID of the signed-in user, taken from their verified token.
+//
+// Return values:
+//   - the user's profile, roles, ban status, counts and pending actions.
+//   - error, if any occurred during the operation.
+func (s *Service) GetMe(ctx context.Context, userId string) (types.Me, error) {
+	ctx, span := tracing.Start(ctx, "helpers.GetMe", tracing.UID(userId))
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
+	me := types.Me{
+		UID:            userId,
+		Roles:          make([]string, 0),
+		PendingActions: make([]string, 0),
+	}
+
+	// Get the user's auth data
+	authData, err := s.getAuthUser(ctx, userId)
+	if err != nil {
+		err = fmt.Errorf("failed getting user data from auth server: %w", err)
+		logger.Error(err.Error())
+		return types.Me{}, err
+	}
+	me.Email = authData.Email
+	me.EmailVerified = authData.EmailVerified
+	if !me.EmailVerified {
+		me.PendingActions = append(me.PendingActions, types.PendingActionVerifyEmail)
+	}
+
+	// Get the ban status, along with its details if it has any
+	me.Banned, err = s.CheckIfBanned(ctx, userId)
+	if err != nil {
+		err = fmt.Errorf("err while checking if banned: %w", err)
+		logger.Error(err.Error())
+		return types.Me{}, err
+	}
+	if me.Banned {
+		me.Ban, err = s.GetBanRecord(ctx, userId)
+		if err != nil {
+			return types.Me{}, err
+		}
+	}
+
+	// Get the user's data doc, which won't exist if they haven't finished signing up
+	doc, err := s.Firestore.Collection("users").Doc(userId).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		me.PendingActions = append(me.PendingActions, types.PendingActionCreateProfile)
+		return me, nil
+	}
+	if err != nil {
+		err = fmt.Errorf("failed getting user doc: %w", err)
+		logger.Error(err.Error())
+		return types.Me{}, err
+	}
+
+	profile, err := userDataFromDoc(ctx, doc)
+	if err != nil {
+		return types.Me{}, err
+	}
+
+	me.Profile = &profile
+	me.Roles = profile.Roles
+	me.DonationsMade = profile.DonationsMade
+	posts, err := s.existingPosts(ctx, profile.Posts)
+	if err != nil {
+		logger.Error(err.Error())
+		return types.Me{}, err
+	}
+	me.ActiveDonations = len(posts)
+
+	// Count the donations they've reported, only fetching the refs
+	iter := s.Firestore.Collection("donations").Where("reports", "array-contains", userId).Select().Documents(ctx)
+	for {
+		_, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			err = fmt.Errorf("failed counting reports filed: %w", err)
+			logger.Error(err.Error())
+			return types.Me{}, err
+		}
+		me.ReportsFiled++
+	}
+
+	return me, nil
+}
@@ -0,0 +1,65 @@
+// @cite "Perform simple and compound queries in Cloud Firestore | Firebase." Google, 2023. [Online].
+// Available: https://firebase.google.com/docs/firestore/query-data/queries. [Accessed: 19- October- 2026].
+// This is a file in the package-"helpers" that contains the GetPrivilegedUsers function.
+package helpers
+
+import (
+	"context"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+	"relief_exchange_backend/types"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+// GetPrivilegedUsers retrieves every user that holds at least one role.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//
+// Return values:
+//   - slice of all privileged users.
+//   - error, if any occurred during retrieval.
+func (s *Service) GetPrivilegedUsers(ctx context.Context) ([]types.PrivilegedUser, error) {
+	ctx, span := tracing.Start(ctx, "helpers.GetPrivilegedUsers")
+	defer span.End()
+
+	users := make([]types.PrivilegedUser, 0)
+	seen := map[string]bool{}
+
+	// Admins made before roles existed only have the admin flag, so both fields are queried
+	usersCollection := s.Firestore.Collection("users")
+	queries := []firestore.Query{
+		usersCollection.Where("roles", "array-contains-any", types.ValidRoles),
+		usersCollection.Where("admin", "==", true),
+	}
+
+	for _, query := range queries {
+		iter := query.Documents(ctx)
+		for {
+			doc, err := iter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				logging.FromContext(ctx).Error(err.Error())
+				return nil, err
+			}
+			if seen[doc.Ref.ID] {
+				continue
+			}
+			seen[doc.Ref.ID] = true
+
+			displayName, _ := doc.Data()["display_name"].(string)
+			email, _ := doc.Data()["email"].(string)
+			users = append(users, types.PrivilegedUser{
+				UID:         doc.Ref.ID,
+				DisplayName: displayName,
+				Email:       email,
+				Roles:       extractRoles(doc.Data()),
+			})
+		}
+	}
+
+	return users, nil
+}
@@ -3,14 +3,17 @@ package helpers
 // @author Aritro Saha
 // This is a file in the package-"helpers" that contains the GerUserDataByID function.
 import (
+	"context"
+	"errors"
 	"fmt"
-	"relief_exchange_backend/globals"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
 	"relief_exchange_backend/types"
-	"time"
-
-	log "github.com/sirupsen/logrus"
 )
 
+// ErrUserBanned is returned when looking up a banned user, whose data is hidden.
+var ErrUserBanned = errors.New("user is banned")
+
 // getUserDataByID retrieves user data by the user's ID from Firestore.
 // Parameters:
 //   - ctx: the context in which the function is invoked.
@@ -20,42 +23,60 @@ import (
 // Return values:
 //   - UserData object that corresponds to the provided ID.
 //   - error, if any occurred during retrieval.
-func GetUserDataByID(id string) (types.UserData, error) {
+func (s *Service) GetUserDataByID(ctx context.Context, id string) (types.UserData, error) {
+	ctx, span := tracing.Start(ctx, "helpers.GetUserDataByID", tracing.UID(id))
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
 	// Check if they're already banned
-	banned, err := CheckIfBanned(id)
+	banned, err := s.CheckIfBanned(ctx, id)
 	if err != nil {
 		err = fmt.Errorf("err while checking if banned: %w", err)
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return types.UserData{}, err
 	}
 	if banned {
-		err := fmt.Errorf("user is banned")
-		log.Error(err.Error())
-		return types.UserData{}, err
+		logger.Error(ErrUserBanned.Error())
+		return types.UserData{}, ErrUserBanned
 	}
 
 	var userData types.UserData
-	doc, err := globals.FirestoreClient.Collection("users").Doc(id).Get(globals.FirebaseContext) // Get a single user from its id
+	doc, err := s.Firestore.Collection("users").Doc(id).Get(ctx) // Get a single user from its id
 	if err != nil {
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return userData, err // returns empty user struct
 	}
-	err = doc.DataTo(&userData)
+	userData, err = userDataFromDoc(ctx, doc)
 	if err != nil {
-		log.Error(err.Error())
 		return types.UserData{}, err
 	}
 
-	// Set values that aren't set in the DataTo function
-	var ok_name, ok_date, ok_donations_made bool
-	userData.DisplayName, ok_name = doc.Data()["display_name"].(string)
-	userData.RegistrationTimestamp, ok_date = doc.Data()["registered_date"].(time.Time)
-	userData.DonationsMade, ok_donations_made = doc.Data()["donations_made"].(int64)
-	if !(ok_name && ok_date && ok_donations_made) {
-		log.Warn("user data may have not been converted properly")
-	}
+	logger.WithField("user_id", id).Debug("user data retrieved")
+	return userData, nil
+}
+
+// GetUserProfile retrieves a user's data to show on their profile, with only the donations that haven't been deleted,
+// so their active donations and donation IDs don't include them.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - id: the ID of the user to retrieve.
+//
+// Return values:
+//   - UserData object that corresponds to the provided ID.
+//   - error, ErrUserBanned if they're banned, or any that occurred during retrieval.
+func (s *Service) GetUserProfile(ctx context.Context, id string) (types.UserData, error) {
+	ctx, span := tracing.Start(ctx, "helpers.GetUserProfile", tracing.UID(id))
+	defer span.End()
 
-	userData.UID = doc.Ref.ID // ID is stored in the Ref feild, so DataTo, does not store id in the user data object
-	log.Info("userData: %v", userData)
+	userData, err := s.GetUserDataByID(ctx, id)
+	if err != nil {
+		return types.UserData{}, err
+	}
+	userData.Posts, err = s.existingPosts(ctx, userData.Posts)
+	if err != nil {
+		logging.FromContext(ctx).Error(err.Error())
+		return types.UserData{}, err
+	}
+	userData.DonationIDs = postIDs(userData.Posts)
 	return userData, nil
 }
@@ -0,0 +1,107 @@
+// @cite "Package firestore." Pkg.go.dev, 2023. [Online].
+// Available: https://pkg.go.dev/cloud.google.com/go/firestore#Client.GetAll. [Accessed: 19- October- 2026].
+// This is a file in the package-"helpers" that contains the GetUserDonations function.
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+	"relief_exchange_backend/types"
+	"strconv"
+
+	"cloud.google.com/go/firestore"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor can't be parsed.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Page sizes used when listing a user's donations
+const (
+	DefaultDonationPageSize = 20
+	MaxDonationPageSize     = 100
+)
+
+// GetUserDonations retrieves one page of a user's donations, newest first.
+// All the donations on a page are fetched in a single batched read.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - userId: the ID of the user whose donations to retrieve.
+//   - cursor: where the page starts, taken from the previous page's NextCursor. Empty for the first page.
+//   - limit: the maximum number of donations on the page, DefaultDonationPageSize if it isn't above 0,
+//     and at most MaxDonationPageSize.
+//
+// Return values:
+//   - the page of donations, along with the cursor of the next page.
+//   - error, if any occurred during retrieval.
+func (s *Service) GetUserDonations(ctx context.Context, userId string, cursor string, limit int) (types.DonationPage, error) {
+	ctx, span := tracing.Start(ctx, "helpers.GetUserDonations", tracing.UID(userId))
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
+	// Go through the same checks as getting their profile, so banned users' donations stay hidden
+	userData, err := s.GetUserDataByID(ctx, userId)
+	if err != nil {
+		return types.DonationPage{}, err
+	}
+
+	if limit <= 0 {
+		limit = DefaultDonationPageSize
+	} else if limit > MaxDonationPageSize {
+		limit = MaxDonationPageSize
+	}
+	start := 0
+	if cursor != "" {
+		start, err = strconv.Atoi(cursor)
+		if err != nil || start < 0 {
+			err = fmt.Errorf("%w: %s", ErrInvalidCursor, cursor)
+			logger.Error(err.Error())
+			return types.DonationPage{}, err
+		}
+	}
+
+	// New posts are appended to the end, so go through them backwards to get the newest first
+	var refs []*firestore.DocumentRef
+	for i := len(userData.Posts) - 1 - start; i >= 0 && len(refs) < limit; i-- {
+		refs = append(refs, userData.Posts[i])
+	}
+
+	page := types.DonationPage{Donations: make([]types.Donation, 0, len(refs))}
+	if start+len(refs) < len(userData.Posts) {
+		page.NextCursor = strconv.Itoa(start + len(refs))
+	}
+	if len(refs) == 0 {
+		return page, nil
+	}
+
+	docs, err := s.Firestore.GetAll(ctx, refs)
+	if err != nil {
+		err = fmt.Errorf("failed getting donations: %w", err)
+		logger.Error(err.Error())
+		return types.DonationPage{}, err
+	}
+	for _, doc := range docs {
+		// Posts can still point to donations that were deleted
+		if !doc.Exists() {
+			continue
+		}
+		donation, err := donationFromDoc(ctx, doc)
+		if err != nil {
+			return types.DonationPage{}, err
+		}
+		page.Donations = append(page.Donations, donation)
+	}
+
+	return page, nil
+}
+
+// postIDs converts a user's posts to the IDs of the donations they point to.
+func postIDs(posts []*firestore.DocumentRef) []string {
+	ids := make([]string, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+	return ids
+}
@@ -0,0 +1,54 @@
+// This is a file in the package-"helpers" that contains the GetUserRoles function.
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+	"relief_exchange_backend/types"
+
+	"golang.org/x/exp/slices"
+)
+
+// GetUserRoles retrieves the roles that have been granted to a user.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - userId: the ID of the user to check.
+//
+// Return values:
+//   - the roles of the user, empty if they have none.
+//   - error, if any occurred during the operation.
+func (s *Service) GetUserRoles(ctx context.Context, userId string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "helpers.GetUserRoles", tracing.UID(userId))
+	defer span.End()
+
+	doc, err := s.Firestore.Doc("users/" + userId).Get(ctx)
+	if err != nil {
+		err = fmt.Errorf("failed getting user doc: %w", err)
+		logging.FromContext(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	return extractRoles(doc.Data()), nil
+}
+
+// extractRoles converts the raw "roles" field of a user document to a slice of roles.
+// Documents created before roles existed only have the "admin" flag, so it is
+// treated as the admin role.
+func extractRoles(data map[string]interface{}) []string {
+	roles := make([]string, 0)
+	if rawRoles, ok := data["roles"].([]interface{}); ok {
+		for _, rawRole := range rawRoles {
+			if role, ok := rawRole.(string); ok && !slices.Contains(roles, role) {
+				roles = append(roles, role)
+			}
+		}
+	}
+
+	if isAdmin, _ := data["admin"].(bool); isAdmin && !slices.Contains(roles, types.RoleAdmin) {
+		roles = append(roles, types.RoleAdmin)
+	}
+
+	return roles
+}
@@ -0,0 +1,31 @@
+// This is a file in the package-"helpers" that contains the HasRole function.
+package helpers
+
+import (
+	"context"
+	"relief_exchange_backend/tracing"
+	"relief_exchange_backend/types"
+
+	"golang.org/x/exp/slices"
+)
+
+// HasRole checks if a user has been granted a role.
+// Admins can do anything the other roles can, so they count as having every role.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - userId: the ID of the user to check.
+//   - role: the role to check for, one of types.ValidRoles.
+//
+// Return values:
+//   - true if the user has the role.
+//   - error, if any occurred during the check.
+func (s *Service) HasRole(ctx context.Context, userId string, role string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "helpers.HasRole", tracing.UID(userId))
+	defer span.End()
+
+	roles, err := s.GetUserRoles(ctx, userId)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(roles, role) || slices.Contains(roles, types.RoleAdmin), nil
+}
@@ -0,0 +1,234 @@
+// This is a file in the package-"helpers" that contains the functions for the image blocklist.
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+// What to do with a donation whose image matches a blocked one, set in the image_match limits of the config
+const (
+	ImageMatchActionReject = "reject" // Refuse to post it
+	ImageMatchActionFlag   = "flag"   // Post it, but flag it for moderators
+)
+
+// Why an image was added to the blocklist
+const (
+	BlockReasonDonationRemoved = "donation_removed"
+	BlockReasonUserBanned      = "user_banned"
+)
+
+// blocklistCacheLifetime is how long the blocked hashes are kept in memory before being read again
+const blocklistCacheLifetime = 5 * time.Minute
+
+var ErrBannedImage = errors.New("this image can't be posted")
+
+// blockedImageCache caches the hashes in the blocked_images collection,
+// since every one of them has to be compared against each new image.
+type blockedImageCache struct {
+	sync.Mutex
+	hashes   []uint64
+	loadedAt time.Time
+}
+
+// BlockDonationImages adds every image of a donation to the blocklist.
+// This is done when a moderator removes a donation, so its photos can't be posted again.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - donationId: the ID of the donation, before its images are deleted.
+//   - reason: why the images are being blocked.
+//
+// Return values:
+//   - error, if any occurred during the operation.
+func (s *Service) BlockDonationImages(ctx context.Context, donationId string, reason string) error {
+	ctx, span := tracing.Start(ctx, "helpers.BlockDonationImages", tracing.DonationID(donationId))
+	defer span.End()
+
+	return s.blockImages(ctx, s.Firestore.Collection("images").Where("donation_id", "==", donationId), reason)
+}
+
+// BlockUserImages adds every image a user uploaded to the blocklist.
+// This is done when they're banned, so they can't post the same photos from a new account.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - userId: the ID of the user.
+//   - reason: why the images are being blocked.
+//
+// Return values:
+//   - error, if any occurred during the operation.
+func (s *Service) BlockUserImages(ctx context.Context, userId string, reason string) error {
+	ctx, span := tracing.Start(ctx, "helpers.BlockUserImages", tracing.UID(userId))
+	defer span.End()
+
+	return s.blockImages(ctx, s.Firestore.Collection("images").Where("owner_id", "==", userId), reason)
+}
+
+// blockImages adds the perceptual hash of every image matching a query to the blocklist.
+// Images are keyed by their ID, so blocking one twice doesn't add it twice.
+func (s *Service) blockImages(ctx context.Context, query firestore.Query, reason string) error {
+	logger := logging.FromContext(ctx)
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		err = fmt.Errorf("error while getting images to block: %w", err)
+		logger.Error(err.Error())
+		return err
+	}
+
+	blocked := 0
+	pending := 0
+	batch := s.Firestore.Batch()
+	for _, doc := range docs {
+		data := doc.Data()
+		// Images uploaded before hashing was added can't be matched
+		hash, ok := data["phash"].(string)
+		if !ok {
+			continue
+		}
+		batch.Set(s.Firestore.Collection("blocked_images").Doc(doc.Ref.ID), map[string]interface{}{
+			"phash":       hash,
+			"reason":      reason,
+			"owner_id":    data["owner_id"],
+			"donation_id": data["donation_id"],
+			"blocked_at":  s.Clock.Now().UTC(),
+		})
+		pending++
+
+		// A batch can hold at most 500 writes
+		if pending == 500 {
+			if _, err = batch.Commit(ctx); err != nil {
+				err = fmt.Errorf("error while blocking images: %w", err)
+				logger.Error(err.Error())
+				return err
+			}
+			blocked += pending
+			pending = 0
+			batch = s.Firestore.Batch()
+		}
+	}
+	if pending != 0 {
+		if _, err = batch.Commit(ctx); err != nil {
+			err = fmt.Errorf("error while blocking images: %w", err)
+			logger.Error(err.Error())
+			return err
+		}
+		blocked += pending
+	}
+	if blocked == 0 {
+		return nil
+	}
+
+	// Make sure the new hashes are used straight away
+	s.blockedImageHashes.Lock()
+	s.blockedImageHashes.loadedAt = time.Time{}
+	s.blockedImageHashes.Unlock()
+
+	logger.WithField("blocked", blocked).Info("images blocked")
+	return nil
+}
+
+// matchesBlockedImage checks whether any of the given uploaded images is close enough to a blocked one.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - imageIds: the IDs of the uploaded images.
+//   - maxDistance: the largest Hamming distance that counts as a match.
+//
+// Return values:
+//   - true if at least one image matches.
+//   - error, if any occurred during the operation.
+func (s *Service) matchesBlockedImage(ctx context.Context, imageIds []string, maxDistance int) (bool, error) {
+	if len(imageIds) == 0 {
+		return false, nil
+	}
+
+	blocked, err := s.loadBlockedImageHashes(ctx)
+	if err != nil {
+		return false, err
+	}
+	if len(blocked) == 0 {
+		return false, nil
+	}
+
+	refs := make([]*firestore.DocumentRef, 0, len(imageIds))
+	for _, imageId := range imageIds {
+		refs = append(refs, s.Firestore.Collection("images").Doc(imageId))
+	}
+	docs, err := s.Firestore.GetAll(ctx, refs)
+	if err != nil {
+		return false, fmt.Errorf("error while getting image hashes: %w", err)
+	}
+
+	for _, doc := range docs {
+		if !doc.Exists() {
+			continue
+		}
+		rawHash, _ := doc.Data()["phash"].(string)
+		hash, err := parseImageHash(rawHash)
+		if err != nil {
+			continue
+		}
+		for _, blockedHash := range blocked {
+			if HammingDistance(hash, blockedHash) <= maxDistance {
+				logging.FromContext(ctx).WithField("image", doc.Ref.ID).Warn("image matches a blocked image")
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// screenDonationImages checks new donation images against the blocklist and applies the configured action.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - imageIds: the IDs of the uploaded images being added to a donation.
+//
+// Return values:
+//   - true if the donation should be flagged for moderators.
+//   - ErrBannedImage if the images should be rejected, or any other error that occurred.
+func (s *Service) screenDonationImages(ctx context.Context, imageIds []string) (bool, error) {
+	policy := s.Config.Limits.ImageMatch
+	matched, err := s.matchesBlockedImage(ctx, imageIds, policy.MaxDistance)
+	if err != nil {
+		logging.FromContext(ctx).Error(err.Error())
+		return false, err
+	}
+	if !matched {
+		return false, nil
+	}
+	if policy.Action == ImageMatchActionReject {
+		return false, ErrBannedImage
+	}
+	return true, nil
+}
+
+// loadBlockedImageHashes gets every blocked hash, reading them from Firestore if the cache is out of date.
+func (s *Service) loadBlockedImageHashes(ctx context.Context) ([]uint64, error) {
+	s.blockedImageHashes.Lock()
+	defer s.blockedImageHashes.Unlock()
+
+	if s.Clock.Now().Sub(s.blockedImageHashes.loadedAt) < blocklistCacheLifetime {
+		return s.blockedImageHashes.hashes, nil
+	}
+
+	docs, err := s.Firestore.Collection("blocked_images").Select("phash").Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("error while getting blocked images: %w", err)
+	}
+	hashes := make([]uint64, 0, len(docs))
+	for _, doc := range docs {
+		rawHash, _ := doc.Data()["phash"].(string)
+		if hash, err := parseImageHash(rawHash); err == nil {
+			hashes = append(hashes, hash)
+		}
+	}
+
+	s.blockedImageHashes.hashes = hashes
+	s.blockedImageHashes.loadedAt = s.Clock.Now()
+	return hashes, nil
+}
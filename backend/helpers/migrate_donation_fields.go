@@ -0,0 +1,81 @@
+// This is a file in the package-"helpers" that contains the migrateDonationFields migration.
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"relief_exchange_backend/logging"
+
+	"cloud.google.com/go/firestore"
+	"golang.org/x/exp/slices"
+)
+
+// migrateDonationFields fixes the fields of a donation that older code left missing or with the wrong type,
+// so it can be read with DataTo:
+//   - owner_id, which is found from the posts of the user who made it.
+//   - reports and flags, which have to be lists of unique strings.
+//   - img, which has to be the URL of the first of its images.
+//
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - doc: the donation's document.
+//   - batch: unused, nothing else is written.
+//
+// Return values:
+//   - the updates to the donation, none if it's already fine.
+//   - error, if any occurred while looking for its owner.
+func (s *Service) migrateDonationFields(ctx context.Context, doc *firestore.DocumentSnapshot, batch *firestore.WriteBatch) ([]firestore.Update, error) {
+	data := doc.Data()
+	var updates []firestore.Update
+
+	if ownerId, _ := data["owner_id"].(string); ownerId == "" {
+		owners, err := s.Firestore.Collection("users").Where("posts", "array-contains", doc.Ref).Limit(1).Documents(ctx).GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed finding owner: %w", err)
+		}
+		if len(owners) != 0 {
+			updates = append(updates, firestore.Update{Path: "owner_id", Value: owners[0].Ref.ID})
+		} else {
+			logging.FromContext(ctx).WithField("donation_id", doc.Ref.ID).Warn("donation has no owner, no user has it in their posts")
+		}
+	}
+
+	for _, field := range []string{"reports", "flags"} {
+		if values, ok := stringList(data[field]); !ok {
+			updates = append(updates, firestore.Update{Path: field, Value: values})
+		}
+	}
+
+	// Only donations with an images list are checked, one without is left to migrateDonationImages
+	if _, hasImages := data["images"]; hasImages {
+		coverImage := coverImageURL(donationImagesFromData(ctx, data))
+		if img, ok := data["img"].(string); !ok || img != coverImage {
+			updates = append(updates, firestore.Update{Path: "img", Value: coverImage})
+		}
+	}
+
+	return updates, nil
+}
+
+// stringList converts a list field of a document to a list of unique strings.
+// Values that aren't strings are formatted as one, like they were when donations were read before they were migrated.
+// Return values:
+//   - the strings.
+//   - whether the field was already that list, false if it's missing or had to be changed.
+func stringList(raw interface{}) ([]string, bool) {
+	rawValues, ok := raw.([]interface{})
+	values := make([]string, 0, len(rawValues))
+	for _, rawValue := range rawValues {
+		value, isString := rawValue.(string)
+		if !isString {
+			value = fmt.Sprintf("%+v", rawValue)
+			ok = false
+		}
+		if slices.Contains(values, value) {
+			ok = false
+			continue
+		}
+		values = append(values, value)
+	}
+	return values, ok
+}
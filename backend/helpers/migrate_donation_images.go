@@ -0,0 +1,47 @@
+// This is a file in the package-"helpers" that contains the migrateDonationImages migration.
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+// migrateDonationImages moves a donation made before multiple images were supported
+// to the "images" field. Its single "img" URL becomes an image document, so it can be
+// reordered, described and removed like an uploaded one, and the donation's title is used
+// as its alt text until the owner writes a better one.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - doc: the donation's document.
+//   - batch: the batch the image document is created in.
+//
+// Return values:
+//   - the updates to the donation, none if it already has an "images" field.
+//   - error, if any occurred during the operation.
+func (s *Service) migrateDonationImages(ctx context.Context, doc *firestore.DocumentSnapshot, batch *firestore.WriteBatch) ([]firestore.Update, error) {
+	data := doc.Data()
+	if _, ok := data["images"]; ok {
+		return nil, nil
+	}
+
+	images := donationImagesFromData(ctx, data)
+	if len(images) != 0 {
+		ownerId, _ := data["owner_id"].(string)
+		createdAt, ok := data["creation_timestamp"].(time.Time)
+		if !ok {
+			createdAt = s.Clock.Now().UTC()
+		}
+		imageRef := s.Firestore.Collection("images").NewDoc()
+		batch.Create(imageRef, map[string]interface{}{
+			"owner_id":    ownerId,
+			"donation_id": doc.Ref.ID,
+			"urls":        map[string]string{RenditionLarge: images[0].URL},
+			"keys":        map[string]string{}, // Legacy images weren't stored by the backend
+			"created_at":  createdAt,
+		})
+		images[0].ImageID = imageRef.ID
+	}
+	return donationImagesUpdate(images), nil
+}
@@ -0,0 +1,28 @@
+// This is a file in the package-"helpers" that contains the migrateDonationReportCount migration.
+package helpers
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+)
+
+// migrateDonationReportCount sets the report_count of a donation to the length of its reports,
+// so GetReportedDonations can query on it.
+// Parameters:
+//   - ctx: unused, nothing else is read.
+//   - doc: the donation's document.
+//   - batch: unused, nothing else is written.
+//
+// Return values:
+//   - the update to report_count, none if it's already right.
+//   - error, never, since nothing is read.
+func (s *Service) migrateDonationReportCount(ctx context.Context, doc *firestore.DocumentSnapshot, batch *firestore.WriteBatch) ([]firestore.Update, error) {
+	data := doc.Data()
+	// Reports are already lists of unique strings, migrateDonationFields ran before this
+	reports, _ := data["reports"].([]interface{})
+	if count, ok := data["report_count"].(int64); ok && count == int64(len(reports)) {
+		return nil, nil
+	}
+	return []firestore.Update{{Path: "report_count", Value: len(reports)}}, nil
+}
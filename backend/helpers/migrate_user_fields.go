@@ -0,0 +1,55 @@
+// This is a file in the package-"helpers" that contains the migrateUserFields migration.
+package helpers
+
+import (
+	"context"
+	"relief_exchange_backend/types"
+
+	"cloud.google.com/go/firestore"
+	"golang.org/x/exp/slices"
+)
+
+// migrateUserFields fixes the fields of a user that older code left missing or with the wrong type,
+// so it can be read with DataTo:
+//   - posts, which is an empty list for users who never had one.
+//   - donations_made, which has to be an integer, and is the number of posts if it's missing.
+//   - roles, which include admin for users who only had the admin flag, which is kept in step with them.
+//
+// Missing public_fields and contact_preferences are left alone, which keeps those users' profiles private
+// until they choose what to share themselves.
+//
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - doc: the user's document.
+//   - batch: unused, nothing else is written.
+//
+// Return values:
+//   - the updates to the user, none if they're already fine.
+//   - error, always nil.
+func (s *Service) migrateUserFields(ctx context.Context, doc *firestore.DocumentSnapshot, batch *firestore.WriteBatch) ([]firestore.Update, error) {
+	data := doc.Data()
+	var updates []firestore.Update
+
+	posts, ok := data["posts"].([]interface{})
+	if !ok {
+		updates = append(updates, firestore.Update{Path: "posts", Value: []*firestore.DocumentRef{}})
+	}
+
+	switch donationsMade := data["donations_made"].(type) {
+	case int64:
+	case float64:
+		updates = append(updates, firestore.Update{Path: "donations_made", Value: int64(donationsMade)})
+	default:
+		updates = append(updates, firestore.Update{Path: "donations_made", Value: int64(len(posts))})
+	}
+
+	roles := extractRoles(data)
+	if storedRoles, ok := stringList(data["roles"]); !ok || !slices.Equal(storedRoles, roles) {
+		updates = append(updates, firestore.Update{Path: "roles", Value: roles})
+	}
+	if isAdmin, ok := data["admin"].(bool); !ok || isAdmin != slices.Contains(roles, types.RoleAdmin) {
+		updates = append(updates, firestore.Update{Path: "admin", Value: slices.Contains(roles, types.RoleAdmin)})
+	}
+
+	return updates, nil
+}
@@ -0,0 +1,344 @@
+// This is a file in the package-"helpers" that contains the schema migrations and the RunMigrations function.
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+	"relief_exchange_backend/types"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// schemaVersionField is the field of each migrated document holding the Version of the last migration applied to it
+	schemaVersionField = "schema_version"
+
+	// DefaultMigrationBatchSize is how many documents are migrated per batch, unless told otherwise.
+	// Each document takes up to two writes along with the progress of the migration, and a batch can hold at most 500.
+	DefaultMigrationBatchSize = 200
+
+	// migrationStaleAfter is how long a migration can go without finishing a batch before it's assumed the server running it died
+	migrationStaleAfter = 10 * time.Minute
+
+	// migrationBatchAttempts is how many times a batch is tried, since it fails if one of its documents was changed while it was being migrated
+	migrationBatchAttempts = 3
+)
+
+// ErrMigrationRunning is returned when a migration is already being run, e.g. by another server.
+var ErrMigrationRunning = errors.New("migration is already running")
+
+// Migration changes the documents of a collection that were written by older code
+// to the shape the current code expects.
+type Migration struct {
+	Version     int // Unique and increasing across every collection
+	Collection  string
+	Description string
+	// Migrate gets the changes to one document, which are written along with its new schema version.
+	// Anything else that has to be written with them, like a new document, is added to the batch.
+	// It returns no changes if the document is already fine.
+	Migrate func(s *Service, ctx context.Context, doc *firestore.DocumentSnapshot, batch *firestore.WriteBatch) ([]firestore.Update, error)
+}
+
+// Migrations are every schema migration, in the order they're run.
+// New ones have to be added at the end with a higher Version, and never changed once they've run.
+var Migrations = []Migration{
+	{Version: 1, Collection: "donations", Description: "move the single img URL into the images list", Migrate: (*Service).migrateDonationImages},
+	{Version: 2, Collection: "donations", Description: "fill in owner_id and clean up reports, flags and img", Migrate: (*Service).migrateDonationFields},
+	{Version: 3, Collection: "users", Description: "fix donations_made and fill in posts and roles", Migrate: (*Service).migrateUserFields},
+	{Version: 4, Collection: "donations", Description: "count the reports of each donation in report_count", Migrate: (*Service).migrateDonationReportCount},
+}
+
+// SchemaVersion gets the schema version that new documents of a collection are written with,
+// which is the Version of its last migration, or 0 if it doesn't have any.
+func SchemaVersion(collection string) int {
+	version := 0
+	for _, migration := range Migrations {
+		if migration.Collection == collection {
+			version = migration.Version
+		}
+	}
+	return version
+}
+
+// schemaVersionOf gets the schema version a document was last migrated to, 0 if it never was.
+func schemaVersionOf(data map[string]interface{}) int {
+	version, _ := data[schemaVersionField].(int64)
+	return int(version)
+}
+
+// MigrationOptions are the settings of a RunMigrations call.
+type MigrationOptions struct {
+	DryRun    bool // Find what would change without writing anything
+	BatchSize int  // How many documents are read and written at once, defaults to DefaultMigrationBatchSize
+	// Progress is called after every batch, can be nil
+	Progress func(progress types.MigrationProgress)
+}
+
+// RunMigrations runs every migration that hasn't been completed, in order.
+// A migration that stopped part way, e.g. because the server was restarted, carries on after the last
+// batch it wrote. Documents that were already migrated are skipped, so running them again is safe.
+// A dry run starts every migration that isn't complete from the beginning, and since nothing is written,
+// later migrations see documents as they are rather than how earlier ones would leave them.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - options: the settings of the run.
+//
+// Return values:
+//   - the progress of every migration.
+//   - error, ErrMigrationRunning if one of them is already being run elsewhere.
+func (s *Service) RunMigrations(ctx context.Context, options MigrationOptions) ([]types.MigrationProgress, error) {
+	ctx, span := tracing.Start(ctx, "helpers.RunMigrations")
+	defer span.End()
+
+	if options.BatchSize <= 0 || options.BatchSize > DefaultMigrationBatchSize {
+		options.BatchSize = DefaultMigrationBatchSize
+	}
+
+	results := make([]types.MigrationProgress, 0, len(Migrations))
+	for _, migration := range Migrations {
+		progress, err := s.runMigration(ctx, migration, options)
+		results = append(results, progress)
+		if err != nil {
+			return results, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+	}
+	return results, nil
+}
+
+// GetMigrationProgress gets how far every migration has got.
+func (s *Service) GetMigrationProgress(ctx context.Context) ([]types.MigrationProgress, error) {
+	results := make([]types.MigrationProgress, 0, len(Migrations))
+	for _, migration := range Migrations {
+		doc, err := s.migrationRef(migration).Get(ctx)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return nil, fmt.Errorf("failed getting the progress of migration %d: %w", migration.Version, err)
+		}
+		results = append(results, migrationProgressFromDoc(migration, doc))
+	}
+	return results, nil
+}
+
+// migrationRef gets the document a migration's progress is stored in.
+func (s *Service) migrationRef(migration Migration) *firestore.DocumentRef {
+	return s.Firestore.Collection("migrations").Doc(fmt.Sprintf("%04d", migration.Version))
+}
+
+// migrationProgressFromDoc converts the document of a migration's progress, which may not exist yet.
+func migrationProgressFromDoc(migration Migration, doc *firestore.DocumentSnapshot) types.MigrationProgress {
+	progress := types.MigrationProgress{
+		Version:     migration.Version,
+		Collection:  migration.Collection,
+		Description: migration.Description,
+		Status:      types.JobStatusPending,
+	}
+	if doc == nil || !doc.Exists() {
+		return progress
+	}
+
+	data := doc.Data()
+	progress.Status, _ = data["status"].(string)
+	scanned, _ := data["scanned"].(int64)
+	migrated, _ := data["migrated"].(int64)
+	progress.Scanned, progress.Migrated = int(scanned), int(migrated)
+	progress.LastDocID, _ = data["last_doc_id"].(string)
+	progress.Error, _ = data["error"].(string)
+	if startedAt, ok := data["started_at"].(time.Time); ok {
+		progress.StartedAt = &startedAt
+	}
+	if completedAt, ok := data["completed_at"].(time.Time); ok {
+		progress.CompletedAt = &completedAt
+	}
+	return progress
+}
+
+// claimMigration marks a migration as running inside a transaction, so only one server runs it at a time.
+// Like claimJob, a migration that has been running for longer than migrationStaleAfter can be taken over.
+// Return values:
+//   - the progress it has made so far.
+//   - error, ErrMigrationRunning if it's being run elsewhere.
+func (s *Service) claimMigration(ctx context.Context, migration Migration) (types.MigrationProgress, error) {
+	ref := s.migrationRef(migration)
+	var progress types.MigrationProgress
+	err := s.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		progress = migrationProgressFromDoc(migration, doc)
+
+		now := s.Clock.Now().UTC()
+		switch progress.Status {
+		case types.JobStatusComplete:
+			return nil
+		case types.JobStatusRunning:
+			if claimedAt, _ := doc.Data()["claimed_at"].(time.Time); now.Sub(claimedAt) < migrationStaleAfter {
+				return ErrMigrationRunning
+			}
+		}
+
+		if progress.StartedAt == nil {
+			progress.StartedAt = &now
+		}
+		progress.Status = types.JobStatusRunning
+		progress.Error = ""
+		return tx.Set(ref, map[string]interface{}{
+			"version":     migration.Version,
+			"collection":  migration.Collection,
+			"description": migration.Description,
+			"status":      progress.Status,
+			"started_at":  *progress.StartedAt,
+			"claimed_at":  now,
+			"error":       "",
+		}, firestore.MergeAll)
+	})
+	return progress, err
+}
+
+// runMigration runs one migration over its collection, a batch at a time, in the order of the documents' IDs.
+// Each batch writes the migrated documents along with how far the migration has got, so it can't lose its place.
+func (s *Service) runMigration(ctx context.Context, migration Migration, options MigrationOptions) (types.MigrationProgress, error) {
+	logger := logging.FromContext(ctx).WithField("migration", migration.Version)
+	ref := s.migrationRef(migration)
+
+	var progress types.MigrationProgress
+	var err error
+	if options.DryRun {
+		doc, err := ref.Get(ctx)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return types.MigrationProgress{}, fmt.Errorf("failed getting progress: %w", err)
+		}
+		progress = migrationProgressFromDoc(migration, doc)
+		if progress.Status != types.JobStatusComplete {
+			progress.Scanned, progress.Migrated, progress.LastDocID = 0, 0, ""
+		}
+		progress.DryRun = true
+	} else {
+		progress, err = s.claimMigration(ctx, migration)
+		if err != nil {
+			return progress, err
+		}
+	}
+	if progress.Status == types.JobStatusComplete {
+		return progress, nil
+	}
+
+	query := s.Firestore.Collection(migration.Collection).OrderBy(firestore.DocumentID, firestore.Asc).Limit(options.BatchSize)
+	for {
+		var scanned int
+		for attempt := 1; ; attempt++ {
+			scanned, err = s.migrateBatch(ctx, migration, query, &progress, options.DryRun)
+			if status.Code(err) != codes.FailedPrecondition || attempt == migrationBatchAttempts {
+				break
+			}
+			// One of the documents was changed since it was read, so the batch is read again
+			logger.WithField("attempt", attempt).Warn("documents changed while they were being migrated, retrying the batch")
+		}
+		if err != nil {
+			if !options.DryRun {
+				s.failMigration(ctx, ref, err)
+			}
+			progress.Status = types.JobStatusFailed
+			progress.Error = err.Error()
+			return progress, err
+		}
+		if options.Progress != nil {
+			options.Progress(progress)
+		}
+		if scanned < options.BatchSize {
+			break
+		}
+	}
+
+	now := s.Clock.Now().UTC()
+	progress.Status = types.JobStatusComplete
+	progress.CompletedAt = &now
+	if !options.DryRun {
+		_, err = ref.Update(ctx, []firestore.Update{
+			{Path: "status", Value: types.JobStatusComplete},
+			{Path: "completed_at", Value: now},
+		})
+		if err != nil {
+			return progress, fmt.Errorf("failed marking migration complete: %w", err)
+		}
+	}
+
+	logger.WithFields(log.Fields{
+		"scanned":  progress.Scanned,
+		"migrated": progress.Migrated,
+		"dry_run":  options.DryRun,
+	}).Info("migration complete")
+	return progress, nil
+}
+
+// migrateBatch migrates the next batch of documents after progress.LastDocID, and moves progress past them.
+// Progress is only changed if the batch was written, so a failed batch can be tried again.
+// Return values:
+//   - how many documents were in the batch.
+//   - error, if any occurred during the operation.
+func (s *Service) migrateBatch(ctx context.Context, migration Migration, query firestore.Query, progress *types.MigrationProgress, dryRun bool) (int, error) {
+	if progress.LastDocID != "" {
+		query = query.StartAfter(progress.LastDocID)
+	}
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return 0, fmt.Errorf("failed listing %s: %w", migration.Collection, err)
+	}
+	if len(docs) == 0 {
+		return 0, nil
+	}
+
+	batch := s.Firestore.Batch()
+	migrated := 0
+	for _, doc := range docs {
+		if schemaVersionOf(doc.Data()) >= migration.Version {
+			continue
+		}
+		updates, err := migration.Migrate(s, ctx, doc, batch)
+		if err != nil {
+			return 0, fmt.Errorf("failed migrating %s/%s: %w", migration.Collection, doc.Ref.ID, err)
+		}
+		if len(updates) != 0 {
+			migrated++
+		}
+		// Documents that were already fine are still marked, so they aren't looked at again
+		updates = append(updates, firestore.Update{Path: schemaVersionField, Value: migration.Version})
+		batch.Update(doc.Ref, updates, firestore.LastUpdateTime(doc.UpdateTime))
+	}
+
+	next := *progress
+	next.Scanned += len(docs)
+	next.Migrated += migrated
+	next.LastDocID = docs[len(docs)-1].Ref.ID
+	if !dryRun {
+		batch.Set(s.migrationRef(migration), map[string]interface{}{
+			"scanned":     next.Scanned,
+			"migrated":    next.Migrated,
+			"last_doc_id": next.LastDocID,
+			"claimed_at":  s.Clock.Now().UTC(),
+		}, firestore.MergeAll)
+		if _, err = batch.Commit(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	*progress = next
+	return len(docs), nil
+}
+
+// failMigration records why a migration stopped, so it can be run again straight away rather than after it goes stale.
+func (s *Service) failMigration(ctx context.Context, ref *firestore.DocumentRef, cause error) {
+	_, err := ref.Update(ctx, []firestore.Update{
+		{Path: "status", Value: types.JobStatusFailed},
+		{Path: "error", Value: cause.Error()},
+	})
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).Error("failed recording migration failure")
+	}
+}
@@ -0,0 +1,56 @@
+// @cite "Kind of Like That." The Hacker Factor Blog, 2013. [Online].
+// Available: https://www.hackerfactor.com/blog/index.php?/archives/529-Kind-of-Like-That.html. [Accessed: 19- October- 2026].
+// This is a file in the package-"helpers" that contains the PerceptualHash function.
+package helpers
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math/bits"
+	"strconv"
+
+	"golang.org/x/image/draw"
+)
+
+// PerceptualHash computes the difference hash (dHash) of an image.
+// Each bit records whether a pixel is brighter than its right neighbour in a 9x8 grayscale
+// copy of the image, so resizing, recompressing or slightly editing a photo barely changes it.
+// Parameters:
+//   - img: the image to hash.
+//
+// Return values:
+//   - the 64 bit hash of the image.
+func PerceptualHash(img image.Image) uint64 {
+	small := image.NewGray(image.Rect(0, 0, 9, 8))
+	draw.Draw(small, small.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.BiLinear.Scale(small, small.Bounds(), img, img.Bounds(), draw.Over, nil)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if small.GrayAt(x, y).Y > small.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// HammingDistance counts the bits that differ between two perceptual hashes.
+// Photos of the same thing are usually within 10 of each other.
+func HammingDistance(a uint64, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// formatImageHash converts a perceptual hash to the hex string it's stored as.
+// Firestore integers are signed, so hashes are stored as strings instead.
+func formatImageHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+// parseImageHash converts a stored perceptual hash back to a number.
+func parseImageHash(hash string) (uint64, error) {
+	return strconv.ParseUint(hash, 16, 64)
+}
@@ -0,0 +1,288 @@
+// @cite "Package zip." Pkg.go.dev, 2023. [Online].
+// Available: https://pkg.go.dev/archive/zip. [Accessed: 19- October- 2026].
+// This is a file in the package-"helpers" that contains the ProcessDataExport and ProcessPendingDataExports functions.
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+	"relief_exchange_backend/types"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// How long finished exports can be downloaded for, and how long an export can be
+// running before it's assumed the server working on it died
+const (
+	DataExportLifetime   = 7 * 24 * time.Hour
+	dataExportStaleAfter = 15 * time.Minute
+)
+
+// dataExportKey gets the key the ZIP file of an export is stored under in s.Exports.
+func dataExportKey(exportId string) string {
+	return "exports/" + exportId + ".zip"
+}
+
+// ReadDataExport reads the ZIP file of a finished export.
+// It's read from s.Exports, so any instance can send it, not just the one that made it.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - exportId: the ID of the export.
+//
+// Return values:
+//   - the contents of the ZIP file.
+//   - error, which matches storage.ErrNotFound if the file is gone.
+func (s *Service) ReadDataExport(ctx context.Context, exportId string) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "helpers.ReadDataExport")
+	defer span.End()
+
+	return s.Exports.Get(ctx, dataExportKey(exportId))
+}
+
+// ProcessDataExport generates the ZIP file of a pending export.
+// Nothing happens if another worker is already generating it, so this is safe to call more than once.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - exportId: the ID of the export.
+//
+// Return values:
+//   - error, if any occurred during the operation.
+func (s *Service) ProcessDataExport(ctx context.Context, exportId string) error {
+	ctx, span := tracing.Start(ctx, "helpers.ProcessDataExport")
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
+	exportRef := s.Firestore.Collection("exports").Doc(exportId)
+
+	// Claim the export so no other worker generates it at the same time
+	data, err := s.claimJob(ctx, exportRef, dataExportStaleAfter)
+	if errors.Is(err, errJobClaimed) {
+		return nil
+	}
+	if err != nil {
+		err = fmt.Errorf("failed claiming export: %w", err)
+		logger.Error(err.Error())
+		return err
+	}
+	userId, _ := data["uid"].(string)
+
+	if err = s.writeDataExport(ctx, userId, dataExportKey(exportId)); err != nil {
+		err = fmt.Errorf("failed generating export: %w", err)
+		logger.Error(err.Error())
+		_, updateErr := exportRef.Update(ctx, []firestore.Update{
+			{Path: "status", Value: types.JobStatusFailed},
+			{Path: "error", Value: "There was an error generating your export. Please request a new one."},
+		})
+		if updateErr != nil {
+			logger.Error(updateErr.Error())
+		}
+		return err
+	}
+
+	completedAt := s.Clock.Now().UTC()
+	_, err = exportRef.Update(ctx, []firestore.Update{
+		{Path: "status", Value: types.JobStatusComplete},
+		{Path: "completed_at", Value: completedAt},
+		{Path: "expires_at", Value: completedAt.Add(DataExportLifetime)},
+	})
+	if err != nil {
+		err = fmt.Errorf("failed marking export as complete: %w", err)
+		logger.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// ProcessPendingDataExports generates every export that is waiting or whose worker died,
+// and deletes the files of exports that have expired.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//
+// Return values:
+//   - error, if any occurred during the operation.
+func (s *Service) ProcessPendingDataExports(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "helpers.ProcessPendingDataExports")
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
+	exports := s.Firestore.Collection("exports")
+
+	// Pending exports are normally started as soon as they're requested,
+	// so these are ones that were interrupted by a restart
+	for _, exportStatus := range []string{types.JobStatusPending, types.JobStatusRunning} {
+		iter := exports.Where("status", "==", exportStatus).Documents(ctx)
+		for {
+			doc, err := iter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				logger.Error(err.Error())
+				return err
+			}
+			if err = s.ProcessDataExport(ctx, doc.Ref.ID); err != nil {
+				logger.Warn("failed processing export: ", err)
+			}
+		}
+	}
+
+	// Delete expired exports
+	iter := exports.Where("status", "==", types.JobStatusComplete).Where("expires_at", "<", s.Clock.Now().UTC()).Documents(ctx)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			logger.Error(err.Error())
+			return err
+		}
+		if err = s.Exports.Delete(ctx, dataExportKey(doc.Ref.ID)); err != nil {
+			logger.Warn("failed deleting expired export: ", err)
+			continue
+		}
+		if _, err = doc.Ref.Update(ctx, []firestore.Update{{Path: "status", Value: types.JobStatusExpired}}); err != nil {
+			logger.Warn("failed marking export as expired: ", err)
+		}
+	}
+
+	return nil
+}
+
+// writeDataExport gathers all of a user's data and stores it in s.Exports as a ZIP file of JSON files.
+// The ZIP file is built in memory, so a half-written export is never stored.
+func (s *Service) writeDataExport(ctx context.Context, userId string, key string) error {
+	files, err := s.gatherDataExport(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, name := range []string{"account.json", "profile.json", "donations.json", "reports_filed.json", "moderation.json"} {
+		writer, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err = encoder.Encode(files[name]); err != nil {
+			return err
+		}
+	}
+	if err = archive.Close(); err != nil {
+		return err
+	}
+
+	_, err = s.Exports.Put(ctx, key, buf.Bytes(), "application/zip")
+	return err
+}
+
+// gatherDataExport collects everything stored about a user, keyed by the file it goes in.
+func (s *Service) gatherDataExport(ctx context.Context, userId string) (map[string]interface{}, error) {
+	files := map[string]interface{}{}
+
+	// Their account details from Firebase Auth
+	authData, err := s.getAuthUser(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed getting user data from auth server: %w", err)
+	}
+	account := map[string]interface{}{
+		"uid":            authData.UID,
+		"email":          authData.Email,
+		"email_verified": authData.EmailVerified,
+		"display_name":   authData.DisplayName,
+		"photo_url":      authData.PhotoURL,
+		"created_at":     time.UnixMilli(authData.UserMetadata.CreationTimestamp).UTC(),
+		"last_sign_in":   time.UnixMilli(authData.UserMetadata.LastLogInTimestamp).UTC(),
+		"providers":      make([]string, 0),
+	}
+	for _, provider := range authData.ProviderUserInfo {
+		account["providers"] = append(account["providers"].([]string), provider.ProviderID)
+	}
+	files["account.json"] = account
+
+	// Their profile, which may not exist if they never finished signing up
+	userDoc, err := s.Firestore.Collection("users").Doc(userId).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		files["profile.json"] = nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed getting user doc: %w", err)
+	} else {
+		profile, err := userDataFromDoc(ctx, userDoc)
+		if err != nil {
+			return nil, err
+		}
+		files["profile.json"] = profile
+	}
+
+	// Every donation they've made, along with how many times each was reported.
+	// Who reported them is someone else's data, so it isn't included.
+	donations := make([]types.Donation, 0)
+	reportsReceived := make([]map[string]interface{}, 0)
+	iter := s.Firestore.Collection("donations").Where("owner_id", "==", userId).Documents(ctx)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed getting donations: %w", err)
+		}
+		donation, err := donationFromDoc(ctx, doc)
+		if err != nil {
+			return nil, err
+		}
+		if len(donation.Reports) != 0 {
+			reportsReceived = append(reportsReceived, map[string]interface{}{
+				"donation_id": donation.ID,
+				"reports":     len(donation.Reports),
+			})
+		}
+		donation.Reports = nil
+		donations = append(donations, donation)
+	}
+	files["donations.json"] = donations
+
+	// Every donation they've reported
+	reportsFiled := make([]map[string]interface{}, 0)
+	iter = s.Firestore.Collection("donations").Where("reports", "array-contains", userId).Documents(ctx)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed getting reports filed: %w", err)
+		}
+		title, _ := doc.Data()["title"].(string)
+		reportsFiled = append(reportsFiled, map[string]interface{}{
+			"donation_id": doc.Ref.ID,
+			"title":       title,
+		})
+	}
+	files["reports_filed.json"] = reportsFiled
+
+	// Their moderation history
+	banRecord, err := s.GetBanRecord(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	files["moderation.json"] = map[string]interface{}{
+		"ban":              banRecord,
+		"reports_received": reportsReceived,
+	}
+
+	return files, nil
+}
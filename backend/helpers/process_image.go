@@ -0,0 +1,151 @@
+// @cite "Package draw." Pkg.go.dev, 2023. [Online].
+// Available: https://pkg.go.dev/golang.org/x/image/draw. [Accessed: 19- October- 2026].
+// @cite "Package http, func DetectContentType." Pkg.go.dev, 2023. [Online].
+// Available: https://pkg.go.dev/net/http#DetectContentType. [Accessed: 19- October- 2026].
+// This is a file in the package-"helpers" that contains the ProcessImage function.
+package helpers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+
+	_ "image/gif"
+	_ "image/png"
+
+	"golang.org/x/exp/slices"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Errors returned when an uploaded image isn't accepted
+var (
+	ErrUnsupportedImageType = errors.New("unsupported image type")
+	ErrImageTooLarge        = errors.New("image is too large")
+	ErrInvalidImage         = errors.New("invalid image")
+)
+
+// Limits on uploaded images
+const (
+	MaxImageUploadBytes = 10 << 20 // 10 MiB
+	MaxImageDimension   = 8000     // Pixels on the longest side, before resizing
+	MinImageDimension   = 32       // Pixels on the shortest side
+	imageJPEGQuality    = 85
+)
+
+// Renditions that every image is stored in
+const (
+	RenditionLarge     = "large"
+	RenditionMedium    = "medium"
+	RenditionThumbnail = "thumbnail"
+)
+
+// imageRenditions maps each rendition to the maximum length of its longest side, in pixels.
+var imageRenditions = map[string]int{
+	RenditionLarge:     2048,
+	RenditionMedium:    1024,
+	RenditionThumbnail: 256,
+}
+
+// allowedImageTypes includes the content types that can be uploaded, as detected from the file itself.
+var allowedImageTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// ProcessedImage is an uploaded image after it's been checked, cleaned and resized.
+type ProcessedImage struct {
+	Renditions map[string][]byte // JPEG data of each rendition
+	Width      int               // Of the large rendition
+	Height     int               // Of the large rendition
+	Hash       uint64            // Perceptual hash, for finding copies of the image
+}
+
+// ProcessImage checks that an uploaded image is acceptable, then re-encodes it into each rendition.
+// Re-encoding removes all metadata, including EXIF location data, after applying the
+// photo's orientation so it still displays upright.
+// Parameters:
+//   - data: the raw bytes of the uploaded file.
+//
+// Return values:
+//   - the renditions of the image.
+//   - error, if the image isn't acceptable or couldn't be processed.
+func ProcessImage(data []byte) (ProcessedImage, error) {
+	if len(data) > MaxImageUploadBytes {
+		return ProcessedImage{}, fmt.Errorf("%w: must be at most %d bytes", ErrImageTooLarge, MaxImageUploadBytes)
+	}
+
+	// Check the actual contents of the file instead of trusting its name or the client
+	contentType := http.DetectContentType(data)
+	if !slices.Contains(allowedImageTypes, contentType) {
+		return ProcessedImage{}, fmt.Errorf("%w: %s", ErrUnsupportedImageType, contentType)
+	}
+
+	// Check the dimensions before decoding, so a small file claiming to be huge isn't loaded into memory
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ProcessedImage{}, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if config.Width > MaxImageDimension || config.Height > MaxImageDimension {
+		return ProcessedImage{}, fmt.Errorf("%w: must be at most %dx%d pixels", ErrImageTooLarge, MaxImageDimension, MaxImageDimension)
+	}
+	if config.Width < MinImageDimension || config.Height < MinImageDimension {
+		return ProcessedImage{}, fmt.Errorf("%w: must be at least %dx%d pixels", ErrInvalidImage, MinImageDimension, MinImageDimension)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return ProcessedImage{}, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if contentType == "image/jpeg" {
+		img = applyOrientation(img, exifOrientation(data))
+	}
+
+	processed := ProcessedImage{Renditions: map[string][]byte{}}
+	for rendition, maxSide := range imageRenditions {
+		resized := resizeToFit(img, maxSide)
+		switch rendition {
+		case RenditionLarge:
+			processed.Width, processed.Height = resized.Bounds().Dx(), resized.Bounds().Dy()
+		case RenditionThumbnail:
+			// Hashing the small copy is much faster, and gives the same result
+			processed.Hash = PerceptualHash(resized)
+		}
+
+		var buf bytes.Buffer
+		if err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: imageJPEGQuality}); err != nil {
+			return ProcessedImage{}, fmt.Errorf("failed encoding %s rendition: %w", rendition, err)
+		}
+		processed.Renditions[rendition] = buf.Bytes()
+	}
+
+	return processed, nil
+}
+
+// resizeToFit scales an image down so its longest side is at most maxSide pixels,
+// drawing it over a white background since JPEGs can't be transparent.
+// Images that are already small enough keep their size.
+func resizeToFit(img image.Image, maxSide int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w > maxSide || h > maxSide {
+		if w >= h {
+			w, h = maxSide, h*maxSide/w
+		} else {
+			w, h = w*maxSide/h, maxSide
+		}
+	}
+	// Very long, thin images could otherwise end up with no pixels on one side
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+	return dst
+}
@@ -3,38 +3,49 @@ package helpers
 // @author Aritro Saha
 // This is a file in the package-"helpers" that contains the ReportDonation function.
 import (
+	"context"
+	"errors"
 	"fmt"
-	"relief_exchange_backend/globals"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/metrics"
+	"relief_exchange_backend/tracing"
 
 	"cloud.google.com/go/firestore"
-	log "github.com/sirupsen/logrus"
 )
 
+// ErrAlreadyReported is returned when a user reports a donation they've already reported.
+var ErrAlreadyReported = errors.New("user has already sent a report")
+
 // ReportDonation adds a report to a specific donation record.
 // Parameters:
+//   - ctx: the context in which the function is invoked.
 //   - donationID: the ID of the donation to report.
 //   - userUID: the UID of the user making the report.
 //
 // Return values:
-//   - error, if any occurred during the operation.
-func ReportDonation(donationID string, userUID string) error {
+//   - error, ErrAlreadyReported if they've reported it before, or any that occurred during the operation.
+func (s *Service) ReportDonation(ctx context.Context, donationID string, userUID string) error {
+	ctx, span := tracing.Start(ctx, "helpers.ReportDonation", tracing.DonationID(donationID), tracing.UID(userUID))
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
 	// Check if they're already banned
 	//banned users cannot report donations.
-	banned, err := CheckIfBanned(userUID)
+	banned, err := s.CheckIfBanned(ctx, userUID)
 	if err != nil {
 		err = fmt.Errorf("err while checking if banned: %w", err)
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return err
 	}
 	if banned {
 		err := fmt.Errorf("user is already banned")
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return err
 	}
 
-	doc, err := globals.FirestoreClient.Collection("donations").Doc(donationID).Get(globals.FirebaseContext) // Get the donation's data
+	doc, err := s.Firestore.Collection("donations").Doc(donationID).Get(ctx) // Get the donation's data
 	if err != nil {
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return err
 	}
 
@@ -51,25 +62,29 @@ func ReportDonation(donationID string, userUID string) error {
 	// Check whether they've already made a report
 	for _, report := range currentReports {
 		if report == userUID {
-			err := fmt.Errorf("user has already sent a report")
-			log.Error(err)
-			return err
+			logger.Error(ErrAlreadyReported.Error())
+			return ErrAlreadyReported
 		}
 	}
 
 	// Add their UID to report list of donation, and update the doc
 	newReports := append(currentReports, userUID)
-	_, err = globals.FirestoreClient.Collection("donations").Doc(donationID).Update(globals.FirebaseContext, []firestore.Update{
+	_, err = s.Firestore.Collection("donations").Doc(donationID).Update(ctx, []firestore.Update{
 		{
 			Path:  "reports",
 			Value: newReports,
 		},
+		{
+			Path:  "report_count",
+			Value: len(newReports),
+		},
 	})
 	if err != nil {
 		err = fmt.Errorf("failed adding report to donation doc: %w", err)
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return err
 	}
 
+	metrics.Reports.Inc()
 	return nil
 }
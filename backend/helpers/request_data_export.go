@@ -0,0 +1,117 @@
+// This is a file in the package-"helpers" that contains the RequestDataExport and GetDataExport functions.
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+	"relief_exchange_backend/types"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrExportNotFound is returned when a data export doesn't exist, or belongs to someone else.
+var ErrExportNotFound = errors.New("data export not found")
+
+// RequestDataExport queues up a new export of all of a user's data.
+// If they already have an export that hasn't finished, that one is returned instead.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - userId: the ID of the user requesting their data.
+//
+// Return values:
+//   - the export that will contain their data.
+//   - error, if any occurred during the operation.
+func (s *Service) RequestDataExport(ctx context.Context, userId string) (types.DataExport, error) {
+	ctx, span := tracing.Start(ctx, "helpers.RequestDataExport", tracing.UID(userId))
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
+	// Don't queue up duplicate exports
+	iter := s.Firestore.Collection("exports").
+		Where("uid", "==", userId).
+		Where("status", "in", []string{types.JobStatusPending, types.JobStatusRunning}).
+		Limit(1).
+		Documents(ctx)
+	doc, err := iter.Next()
+	if err == nil {
+		return dataExportFromDoc(doc), nil
+	}
+	if err != iterator.Done {
+		err = fmt.Errorf("failed checking for existing exports: %w", err)
+		logger.Error(err.Error())
+		return types.DataExport{}, err
+	}
+
+	requestedAt := s.Clock.Now().UTC()
+	docRef, _, err := s.Firestore.Collection("exports").Add(ctx, map[string]interface{}{
+		"uid":          userId,
+		"status":       types.JobStatusPending,
+		"requested_at": requestedAt,
+	})
+	if err != nil {
+		err = fmt.Errorf("failed creating export: %w", err)
+		logger.Error(err.Error())
+		return types.DataExport{}, err
+	}
+
+	return types.DataExport{
+		ID:          docRef.ID,
+		UID:         userId,
+		Status:      types.JobStatusPending,
+		RequestedAt: requestedAt,
+	}, nil
+}
+
+// GetDataExport retrieves the status of one of a user's data exports.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - userId: the ID of the user who requested the export.
+//   - exportId: the ID of the export.
+//
+// Return values:
+//   - the export.
+//   - error, ErrExportNotFound if it doesn't exist or belongs to another user.
+func (s *Service) GetDataExport(ctx context.Context, userId string, exportId string) (types.DataExport, error) {
+	ctx, span := tracing.Start(ctx, "helpers.GetDataExport", tracing.UID(userId))
+	defer span.End()
+
+	doc, err := s.Firestore.Collection("exports").Doc(exportId).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return types.DataExport{}, ErrExportNotFound
+	}
+	if err != nil {
+		err = fmt.Errorf("failed getting export: %w", err)
+		logging.FromContext(ctx).Error(err.Error())
+		return types.DataExport{}, err
+	}
+
+	export := dataExportFromDoc(doc)
+	if export.UID != userId {
+		return types.DataExport{}, ErrExportNotFound
+	}
+	return export, nil
+}
+
+// dataExportFromDoc converts an export's Firestore document to a DataExport object.
+func dataExportFromDoc(doc *firestore.DocumentSnapshot) types.DataExport {
+	data := doc.Data()
+	export := types.DataExport{ID: doc.Ref.ID}
+	export.UID, _ = data["uid"].(string)
+	export.Status, _ = data["status"].(string)
+	export.RequestedAt, _ = data["requested_at"].(time.Time)
+	export.Error, _ = data["error"].(string)
+	if completedAt, ok := data["completed_at"].(time.Time); ok {
+		export.CompletedAt = &completedAt
+	}
+	if expiresAt, ok := data["expires_at"].(time.Time); ok {
+		export.ExpiresAt = &expiresAt
+	}
+	return export
+}
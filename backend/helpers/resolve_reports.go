@@ -0,0 +1,97 @@
+// This is a file in the package-"helpers" that contains the GetReportedDonations and ResolveReports functions.
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+	"relief_exchange_backend/types"
+
+	"cloud.google.com/go/firestore"
+)
+
+// How a moderator can resolve the reports of a donation
+const (
+	ReportResolutionDismiss = "dismiss" // The donation is fine, so its reports are cleared
+	ReportResolutionRemove  = "remove"  // The donation is taken down and its photos are blocked
+)
+
+// ErrInvalidResolution is returned when reports are resolved with something other than a ReportResolution constant.
+var ErrInvalidResolution = errors.New("reports can only be dismissed or removed")
+
+// GetReportedDonations gets the donations with at least one report, for moderators to look through.
+// Donations reported before report_count was added are only included once the schema migrations have run.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - minReports: the fewest reports a donation needs to be included, at least 1.
+//
+// Return values:
+//   - the reported donations, the most reported first.
+//   - error, if any occurred during retrieval.
+func (s *Service) GetReportedDonations(ctx context.Context, minReports int) ([]types.Donation, error) {
+	ctx, span := tracing.Start(ctx, "helpers.GetReportedDonations")
+	defer span.End()
+
+	if minReports < 1 {
+		minReports = 1
+	}
+	// Firestore can't filter on the length of the reports list, so report_count is kept alongside it
+	iter := s.Firestore.Collection("donations").
+		Where("report_count", ">=", minReports).
+		OrderBy("report_count", firestore.Desc).
+		Documents(ctx)
+	docs, err := iter.GetAll()
+	if err != nil {
+		err = fmt.Errorf("failed getting reported donations: %w", err)
+		logging.FromContext(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	reported := make([]types.Donation, 0, len(docs))
+	for _, doc := range docs {
+		donation, err := donationFromDoc(ctx, doc)
+		if err != nil {
+			return nil, err
+		}
+		reported = append(reported, donation)
+	}
+	return reported, nil
+}
+
+// ResolveReports acts on the reports of a donation.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - donationId: the ID of the reported donation.
+//   - resolution: one of the ReportResolution constants.
+//
+// Return values:
+//   - error, if the resolution isn't valid or the donation couldn't be changed.
+func (s *Service) ResolveReports(ctx context.Context, donationId string, resolution string) error {
+	ctx, span := tracing.Start(ctx, "helpers.ResolveReports", tracing.DonationID(donationId))
+	defer span.End()
+	logger := logging.FromContext(ctx).WithField("resolution", resolution)
+
+	switch resolution {
+	case ReportResolutionDismiss:
+		_, err := s.Firestore.Collection("donations").Doc(donationId).Update(ctx, []firestore.Update{
+			{Path: "reports", Value: []string{}},
+			{Path: "report_count", Value: 0},
+		})
+		if err != nil {
+			err = fmt.Errorf("failed clearing reports: %w", err)
+			logger.Error(err.Error())
+			return err
+		}
+	case ReportResolutionRemove:
+		if err := s.DeleteDonation(ctx, donationId, true); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w, got %q", ErrInvalidResolution, resolution)
+	}
+
+	logger.Info("reports resolved")
+	return nil
+}
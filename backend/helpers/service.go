@@ -0,0 +1,74 @@
+// This is a file in the package-"helpers" that contains the Service the helpers are run on.
+package helpers
+
+import (
+	"context"
+	"relief_exchange_backend/config"
+	"relief_exchange_backend/storage"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"firebase.google.com/go/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// AuthClient is the part of the Firebase Auth client the helpers use.
+// It's satisfied by *auth.Client, and lets tests swap in a fake one.
+type AuthClient interface {
+	VerifyIDToken(ctx context.Context, idToken string) (*auth.Token, error)
+	GetUser(ctx context.Context, uid string) (*auth.UserRecord, error)
+	GetUsers(ctx context.Context, identifiers []auth.UserIdentifier) (*auth.GetUsersResult, error)
+	DeleteUser(ctx context.Context, uid string) error
+	SetCustomUserClaims(ctx context.Context, uid string, customClaims map[string]interface{}) error
+}
+
+// Clock tells the helpers the current time, so tests can control it.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock that reads the system time.
+type SystemClock struct{}
+
+// Now gets the current time.
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Service holds everything the helpers read and write, so several can run in one process
+// without sharing anything, e.g. one per test.
+type Service struct {
+	Firestore *firestore.Client
+	Auth      AuthClient
+	Images    storage.Store
+	Exports   storage.Store
+	Config    config.Config
+	Clock     Clock
+	Logger    *log.Logger
+
+	blockedImageHashes blockedImageCache
+}
+
+// NewService creates a Service.
+// Parameters:
+//   - firestoreClient: the Firestore database.
+//   - authClient: the Firebase Auth client, which also verifies ID tokens.
+//   - images: where uploaded images are kept.
+//   - exports: where the ZIP files of personal data exports are kept, which mustn't be public.
+//   - cfg: the settings the backend was started with.
+//   - clock: the current time, SystemClock outside of tests.
+//   - logger: the logger used when there's no request to log for, like in background jobs.
+//
+// Return values:
+//   - the Service.
+func NewService(firestoreClient *firestore.Client, authClient AuthClient, images storage.Store, exports storage.Store, cfg config.Config, clock Clock, logger *log.Logger) *Service {
+	return &Service{
+		Firestore: firestoreClient,
+		Auth:      authClient,
+		Images:    images,
+		Exports:   exports,
+		Config:    cfg,
+		Clock:     clock,
+		Logger:    logger,
+	}
+}
@@ -0,0 +1,74 @@
+// This is a file in the package-"helpers" that contains the SetPublicFields function.
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+	"relief_exchange_backend/types"
+
+	"cloud.google.com/go/firestore"
+	"golang.org/x/exp/slices"
+)
+
+// ErrInvalidPublicField is returned when a user tries to make a field public that can't be.
+var ErrInvalidPublicField = errors.New("field cannot be made public")
+
+// SetPublicFields changes which optional fields are shown on a user's public profile.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - userId: the ID of the user.
+//   - fields: the optional fields to make public, all others are hidden.
+//
+// Return values:
+//   - error, if any occurred during the operation.
+func (s *Service) SetPublicFields(ctx context.Context, userId string, fields []string) error {
+	ctx, span := tracing.Start(ctx, "helpers.SetPublicFields", tracing.UID(userId))
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
+	publicFields := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if !slices.Contains(types.OptionalPublicFields, field) {
+			err := fmt.Errorf("%w: %s", ErrInvalidPublicField, field)
+			logger.Error(err.Error())
+			return err
+		}
+		if !slices.Contains(publicFields, field) {
+			publicFields = append(publicFields, field)
+		}
+	}
+
+	_, err := s.Firestore.Doc("users/"+userId).Update(ctx, []firestore.Update{
+		{
+			Path:  "public_fields",
+			Value: publicFields,
+		},
+	})
+	if err != nil {
+		err = fmt.Errorf("failed updating public fields: %w", err)
+		logger.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// extractPublicFields converts the raw "public_fields" field of a user document to a slice of fields.
+// Users who have never chosen get types.DefaultPublicFields, so nothing optional is public.
+func extractPublicFields(data map[string]interface{}) []string {
+	rawFields, ok := data["public_fields"].([]interface{})
+	if !ok {
+		return slices.Clone(types.DefaultPublicFields)
+	}
+
+	fields := make([]string, 0, len(rawFields))
+	for _, rawField := range rawFields {
+		if field, ok := rawField.(string); ok {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
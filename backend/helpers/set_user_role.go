@@ -0,0 +1,146 @@
+// @cite "Control Access with Custom Claims and Security Rules | Firebase." Google, 2023. [Online].
+// Available: https://firebase.google.com/docs/auth/admin/custom-claims. [Accessed: 19- October- 2026].
+// @cite "Transactions and batched writes | Firebase." Google, 2023. [Online].
+// Available: https://firebase.google.com/docs/firestore/manage-data/transactions. [Accessed: 19- October- 2026].
+// This is a file in the package-"helpers" that contains the GrantRole and RevokeRole functions.
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+	"relief_exchange_backend/types"
+
+	"cloud.google.com/go/firestore"
+	"golang.org/x/exp/slices"
+)
+
+// ErrInvalidRole is returned when a role that doesn't exist is granted or revoked.
+var ErrInvalidRole = errors.New("invalid role")
+
+// ErrLastAdmin is returned when revoking the admin role would leave the platform without any admins.
+var ErrLastAdmin = errors.New("cannot revoke the admin role from the last admin")
+
+// GrantRole gives a role to a user, and mirrors their roles into their Firebase custom claims.
+// Granting a role the user already has only re-syncs their custom claims.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - userId: the ID of the user to grant the role to.
+//   - role: the role to grant, one of types.ValidRoles.
+//
+// Return values:
+//   - error, if any occurred during the operation.
+func (s *Service) GrantRole(ctx context.Context, userId string, role string) error {
+	ctx, span := tracing.Start(ctx, "helpers.GrantRole", tracing.UID(userId))
+	defer span.End()
+
+	return s.setUserRole(ctx, userId, role, true)
+}
+
+// RevokeRole removes a role from a user, and mirrors their roles into their Firebase custom claims.
+// The admin role cannot be revoked from the last remaining admin.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - userId: the ID of the user to revoke the role from.
+//   - role: the role to revoke, one of types.ValidRoles.
+//
+// Return values:
+//   - error, if any occurred during the operation.
+func (s *Service) RevokeRole(ctx context.Context, userId string, role string) error {
+	ctx, span := tracing.Start(ctx, "helpers.RevokeRole", tracing.UID(userId))
+	defer span.End()
+
+	return s.setUserRole(ctx, userId, role, false)
+}
+
+// setUserRole adds or removes a role from a user's document inside a transaction,
+// then updates their custom claims to match.
+func (s *Service) setUserRole(ctx context.Context, userId string, role string, grant bool) error {
+	logger := logging.FromContext(ctx)
+	if !slices.Contains(types.ValidRoles, role) {
+		err := fmt.Errorf("%w: %s", ErrInvalidRole, role)
+		logger.Error(err.Error())
+		return err
+	}
+
+	userRef := s.Firestore.Doc("users/" + userId)
+	var roles []string
+
+	// The last admin check and the update have to happen in one transaction,
+	// otherwise two admins could revoke each other at the same time.
+	err := s.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		userDoc, err := tx.Get(userRef)
+		if err != nil {
+			return fmt.Errorf("failed getting user doc: %w", err)
+		}
+
+		roles = extractRoles(userDoc.Data())
+		if grant {
+			if !slices.Contains(roles, role) {
+				roles = append(roles, role)
+			}
+		} else {
+			if idx := slices.Index(roles, role); idx != -1 {
+				roles = slices.Delete(roles, idx, idx+1)
+			}
+
+			if role == types.RoleAdmin {
+				admins, err := tx.Documents(s.Firestore.Collection("users").Where("admin", "==", true)).GetAll()
+				if err != nil {
+					return fmt.Errorf("failed getting admins: %w", err)
+				}
+				otherAdmins := 0
+				for _, admin := range admins {
+					if admin.Ref.ID != userId {
+						otherAdmins++
+					}
+				}
+				if otherAdmins == 0 {
+					return ErrLastAdmin
+				}
+			}
+		}
+
+		// The admin flag is kept alongside the roles since the rest of the backend checks it
+		return tx.Set(userRef, map[string]interface{}{
+			"roles": roles,
+			"admin": slices.Contains(roles, types.RoleAdmin),
+		}, firestore.MergeAll)
+	})
+	if err != nil {
+		err = fmt.Errorf("failed updating roles: %w", err)
+		logger.Error(err.Error())
+		return err
+	}
+
+	return s.syncRoleClaims(ctx, userId, roles)
+}
+
+// syncRoleClaims mirrors a user's roles into their Firebase custom claims,
+// keeping any other claims they already have.
+func (s *Service) syncRoleClaims(ctx context.Context, userId string, roles []string) error {
+	logger := logging.FromContext(ctx)
+	userRecord, err := s.getAuthUser(ctx, userId)
+	if err != nil {
+		err = fmt.Errorf("failed getting user data from auth server: %w", err)
+		logger.Error(err.Error())
+		return err
+	}
+
+	claims := userRecord.CustomClaims
+	if claims == nil {
+		claims = map[string]interface{}{}
+	}
+	claims["roles"] = roles
+	claims["admin"] = slices.Contains(roles, types.RoleAdmin)
+
+	if err = s.setAuthClaims(ctx, userId, claims); err != nil {
+		err = fmt.Errorf("failed setting custom claims: %w", err)
+		logger.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+// @cite "Manage Users | Firebase." Google, 2023. [Online].
+// Available: https://firebase.google.com/docs/auth/admin/manage-users#bulk_retrieve_user_data. [Accessed: 19- October- 2026].
+// This is a file in the package-"helpers" that contains the SyncAuthProfiles function.
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+
+	"cloud.google.com/go/firestore"
+	"firebase.google.com/go/auth"
+	"golang.org/x/exp/slices"
+	"google.golang.org/api/iterator"
+)
+
+// authLookupBatchSize is the maximum number of users Firebase Auth can look up at once
+const authLookupBatchSize = 100
+
+// SyncAuthProfiles re-copies the profile fields that still come from Firebase Auth
+// (email, and display name and avatar unless the user has edited them) into every user's document.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//
+// Return values:
+//   - the number of user documents that were changed.
+//   - error, if any occurred during the operation.
+func (s *Service) SyncAuthProfiles(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "helpers.SyncAuthProfiles")
+	defer span.End()
+
+	synced := 0
+	var batch []*firestore.DocumentSnapshot
+
+	iter := s.Firestore.Collection("users").Documents(ctx)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			err = fmt.Errorf("failed getting users: %w", err)
+			logging.FromContext(ctx).Error(err.Error())
+			return synced, err
+		}
+
+		batch = append(batch, doc)
+		if len(batch) == authLookupBatchSize {
+			count, err := s.syncAuthProfileBatch(ctx, batch)
+			synced += count
+			if err != nil {
+				return synced, err
+			}
+			batch = nil
+		}
+	}
+
+	count, err := s.syncAuthProfileBatch(ctx, batch)
+	return synced + count, err
+}
+
+// syncAuthProfileBatch looks up a batch of users in Firebase Auth and updates their
+// documents with whatever changed.
+func (s *Service) syncAuthProfileBatch(ctx context.Context, docs []*firestore.DocumentSnapshot) (int, error) {
+	logger := logging.FromContext(ctx)
+	if len(docs) == 0 {
+		return 0, nil
+	}
+
+	identifiers := make([]auth.UserIdentifier, 0, len(docs))
+	for _, doc := range docs {
+		identifiers = append(identifiers, auth.UIDIdentifier{UID: doc.Ref.ID})
+	}
+	result, err := s.getAuthUsers(ctx, identifiers)
+	if err != nil {
+		err = fmt.Errorf("failed getting users from auth server: %w", err)
+		logger.Error(err.Error())
+		return 0, err
+	}
+	authUsers := map[string]*auth.UserRecord{}
+	for _, authUser := range result.Users {
+		authUsers[authUser.UID] = authUser
+	}
+
+	synced := 0
+	for _, doc := range docs {
+		authUser, ok := authUsers[doc.Ref.ID]
+		if !ok {
+			logger.Warn("user doc has no matching auth user: ", doc.Ref.ID)
+			continue
+		}
+
+		data := doc.Data()
+		var overridden []string
+		if rawOverridden, ok := data[overriddenFieldsKey].([]interface{}); ok {
+			for _, rawField := range rawOverridden {
+				if field, ok := rawField.(string); ok {
+					overridden = append(overridden, field)
+				}
+			}
+		}
+
+		// Only write the fields that are out of date
+		var updates []firestore.Update
+		if email, _ := data["email"].(string); email != authUser.Email {
+			updates = append(updates, firestore.Update{Path: "email", Value: authUser.Email})
+		}
+		if displayName, _ := data["display_name"].(string); !slices.Contains(overridden, "display_name") && displayName != authUser.DisplayName {
+			updates = append(updates, firestore.Update{Path: "display_name", Value: authUser.DisplayName})
+		}
+		if avatar, _ := data["avatar"].(string); !slices.Contains(overridden, "avatar") && avatar != authUser.PhotoURL {
+			updates = append(updates, firestore.Update{Path: "avatar", Value: authUser.PhotoURL})
+		}
+		if len(updates) == 0 {
+			continue
+		}
+
+		if _, err := doc.Ref.Update(ctx, updates); err != nil {
+			logger.Warn("failed syncing user profile: ", err)
+			continue
+		}
+		synced++
+	}
+
+	return synced, nil
+}
@@ -0,0 +1,56 @@
+// This is a file in the package-"helpers" that contains the UnbanUser function.
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+
+	"cloud.google.com/go/firestore"
+)
+
+// ErrNotBanned is returned when unbanning a user who isn't banned.
+var ErrNotBanned = errors.New("user isn't banned")
+
+// UnbanUser lifts a user's ban, removing them from the ban list along with the details of their ban.
+// Their donations were deleted when they were banned, and their images stay on the image blocklist.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - userId: the ID of the banned user.
+//
+// Return values:
+//   - error, ErrNotBanned if they aren't banned, or any that occurred during the operation.
+func (s *Service) UnbanUser(ctx context.Context, userId string) error {
+	ctx, span := tracing.Start(ctx, "helpers.UnbanUser", tracing.UID(userId))
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
+	banned, err := s.CheckIfBanned(ctx, userId)
+	if err != nil {
+		err = fmt.Errorf("err while checking if banned: %w", err)
+		logger.Error(err.Error())
+		return err
+	}
+	if !banned {
+		return ErrNotBanned
+	}
+
+	_, err = s.Firestore.Doc("config/bans").Update(ctx, []firestore.Update{
+		{Path: "users", Value: firestore.ArrayRemove(userId)},
+	})
+	if err != nil {
+		err = fmt.Errorf("failed removing user from ban list: %w", err)
+		logger.Error(err.Error())
+		return err
+	}
+	if _, err = s.Firestore.Collection("bans").Doc(userId).Delete(ctx); err != nil {
+		err = fmt.Errorf("failed deleting ban details: %w", err)
+		logger.Error(err.Error())
+		return err
+	}
+
+	logger.Info("user unbanned")
+	return nil
+}
@@ -0,0 +1,135 @@
+// This is a file in the package-"helpers" that contains the UpdateProfile function.
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+	"relief_exchange_backend/types"
+	"strings"
+	"unicode/utf8"
+
+	"cloud.google.com/go/firestore"
+)
+
+// ErrInvalidProfile is returned when a profile update doesn't pass validation.
+var ErrInvalidProfile = errors.New("invalid profile")
+
+// Limits on the length of profile fields, in characters
+const (
+	MaxDisplayNameLength = 50
+	MaxBioLength         = 500
+	MaxAreaLength        = 100
+	MaxContactNoteLength = 200
+	MaxAvatarURLLength   = 2048
+)
+
+// overriddenFieldsKey is the field of a user document listing the fields they've edited themselves
+const overriddenFieldsKey = "overridden_fields"
+
+// profileTextField is a user-written text field of a profile update, along with its length limit.
+type profileTextField struct {
+	name      string
+	value     *string
+	maxLength int
+}
+
+// UpdateProfile changes the details on a user's profile.
+// Fields that are edited here stop being re-synced from Firebase Auth.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - userId: the ID of the user.
+//   - update: the fields to change, nil fields are left as is.
+//
+// Return values:
+//   - error, if any occurred during the operation.
+func (s *Service) UpdateProfile(ctx context.Context, userId string, update types.ProfileUpdate) error {
+	ctx, span := tracing.Start(ctx, "helpers.UpdateProfile", tracing.UID(userId))
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
+	if err := validateProfileUpdate(update); err != nil {
+		logger.Error(err.Error())
+		return err
+	}
+
+	var updates []firestore.Update
+	var overridden []interface{}
+	if update.DisplayName != nil {
+		updates = append(updates, firestore.Update{Path: "display_name", Value: strings.TrimSpace(*update.DisplayName)})
+		overridden = append(overridden, "display_name")
+	}
+	if update.Bio != nil {
+		updates = append(updates, firestore.Update{Path: "bio", Value: strings.TrimSpace(*update.Bio)})
+	}
+	if update.AvatarURL != nil {
+		updates = append(updates, firestore.Update{Path: "avatar", Value: strings.TrimSpace(*update.AvatarURL)})
+		overridden = append(overridden, "avatar")
+	}
+	if update.Area != nil {
+		updates = append(updates, firestore.Update{Path: "area", Value: strings.TrimSpace(*update.Area)})
+	}
+	if update.ContactPreferences != nil {
+		updates = append(updates, firestore.Update{Path: "contact_preferences", Value: map[string]interface{}{
+			"email": update.ContactPreferences.Email,
+			"note":  strings.TrimSpace(update.ContactPreferences.Note),
+		}})
+	}
+	if len(overridden) != 0 {
+		updates = append(updates, firestore.Update{Path: overriddenFieldsKey, Value: firestore.ArrayUnion(overridden...)})
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	if _, err := s.Firestore.Doc("users/"+userId).Update(ctx, updates); err != nil {
+		err = fmt.Errorf("failed updating profile: %w", err)
+		logger.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// validateProfileUpdate checks the lengths and contents of every field in a profile update.
+func validateProfileUpdate(update types.ProfileUpdate) error {
+	// Check text fields for their lengths and profanity
+	textFields := []profileTextField{
+		{"display_name", update.DisplayName, MaxDisplayNameLength},
+		{"bio", update.Bio, MaxBioLength},
+		{"area", update.Area, MaxAreaLength},
+	}
+	if update.ContactPreferences != nil {
+		textFields = append(textFields, profileTextField{"contact_preferences.note", &update.ContactPreferences.Note, MaxContactNoteLength})
+	}
+	for _, field := range textFields {
+		if field.value == nil {
+			continue
+		}
+		value := strings.TrimSpace(*field.value)
+		if utf8.RuneCountInString(value) > field.maxLength {
+			return fmt.Errorf("%w: %s must be at most %d characters", ErrInvalidProfile, field.name, field.maxLength)
+		}
+		if ContainsProfanity(value) {
+			return fmt.Errorf("%w: %s contains inappropriate language", ErrInvalidProfile, field.name)
+		}
+	}
+
+	if update.DisplayName != nil && strings.TrimSpace(*update.DisplayName) == "" {
+		return fmt.Errorf("%w: display_name cannot be empty", ErrInvalidProfile)
+	}
+
+	// Avatars have to be HTTPS links, an empty one clears it
+	if update.AvatarURL != nil && strings.TrimSpace(*update.AvatarURL) != "" {
+		avatarURL := strings.TrimSpace(*update.AvatarURL)
+		parsed, err := url.Parse(avatarURL)
+		if len(avatarURL) > MaxAvatarURLLength || err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return fmt.Errorf("%w: avatar must be an https link", ErrInvalidProfile)
+		}
+	}
+
+	return nil
+}
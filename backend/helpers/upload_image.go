@@ -0,0 +1,92 @@
+// This is a file in the package-"helpers" that contains the UploadImage function.
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+	"relief_exchange_backend/types"
+	"time"
+)
+
+// cleanupTimeout is how long cleaning up after a failed upload can take
+const cleanupTimeout = 30 * time.Second
+
+// UploadImage processes an uploaded image and stores each of its renditions.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - ownerId: the ID of the user uploading the image.
+//   - data: the raw bytes of the uploaded file.
+//
+// Return values:
+//   - the stored image, with the URLs of its renditions.
+//   - error, if the image isn't acceptable or couldn't be stored.
+func (s *Service) UploadImage(ctx context.Context, ownerId string, data []byte) (types.Image, error) {
+	ctx, span := tracing.Start(ctx, "helpers.UploadImage", tracing.UID(ownerId))
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
+	processed, err := ProcessImage(data)
+	if err != nil {
+		logger.Error(err.Error())
+		return types.Image{}, err
+	}
+
+	imageRef := s.Firestore.Collection("images").NewDoc()
+	urls := map[string]string{}
+	keys := map[string]string{}
+	for rendition, renditionData := range processed.Renditions {
+		key := fmt.Sprintf("images/%s/%s/%s.jpg", ownerId, imageRef.ID, rendition)
+		url, err := s.Images.Put(ctx, key, renditionData, "image/jpeg")
+		if err != nil {
+			err = fmt.Errorf("failed storing %s rendition: %w", rendition, err)
+			logger.Error(err.Error())
+			s.deleteStoredFiles(ctx, keys)
+			return types.Image{}, err
+		}
+		urls[rendition] = url
+		keys[rendition] = key
+	}
+
+	image := types.Image{
+		ID:           imageRef.ID,
+		OwnerId:      ownerId,
+		URL:          urls[RenditionLarge],
+		MediumURL:    urls[RenditionMedium],
+		ThumbnailURL: urls[RenditionThumbnail],
+		Width:        processed.Width,
+		Height:       processed.Height,
+		CreatedAt:    s.Clock.Now().UTC(),
+	}
+	_, err = imageRef.Create(ctx, map[string]interface{}{
+		"owner_id":   image.OwnerId,
+		"urls":       urls,
+		"keys":       keys,
+		"width":      image.Width,
+		"height":     image.Height,
+		"phash":      formatImageHash(processed.Hash),
+		"created_at": image.CreatedAt,
+	})
+	if err != nil {
+		err = fmt.Errorf("failed saving image: %w", err)
+		logger.Error(err.Error())
+		s.deleteStoredFiles(ctx, keys)
+		return types.Image{}, err
+	}
+
+	return image, nil
+}
+
+// deleteStoredFiles cleans up files that were stored for an upload that failed partway through.
+// The upload may have failed because the request ran out of time, so the cleanup gets its own.
+func (s *Service) deleteStoredFiles(ctx context.Context, keys map[string]string) {
+	ctx, cancel := context.WithTimeout(logging.NewContext(tracing.Detach(ctx), logging.FromContext(ctx)), cleanupTimeout)
+	defer cancel()
+
+	for _, key := range keys {
+		if err := s.Images.Delete(ctx, key); err != nil {
+			logging.FromContext(ctx).Warn("failed cleaning up stored file: ", err)
+		}
+	}
+}
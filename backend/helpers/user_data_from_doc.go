@@ -0,0 +1,39 @@
+// This is a file in the package-"helpers" that contains the userDataFromDoc function.
+package helpers
+
+import (
+	"context"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/types"
+
+	"cloud.google.com/go/firestore"
+)
+
+// userDataFromDoc converts a user's Firestore document to a UserData object.
+// Parameters:
+//   - doc: the snapshot of the user's document.
+//
+// Return values:
+//   - UserData object stored in the document.
+//   - error, if any occurred during the conversion.
+func userDataFromDoc(ctx context.Context, doc *firestore.DocumentSnapshot) (types.UserData, error) {
+	logger := logging.FromContext(ctx)
+	var userData types.UserData
+	err := doc.DataTo(&userData)
+	if err != nil {
+		logger.Error(err.Error())
+		return types.UserData{}, err
+	}
+
+	// Documents older than the migrations may be missing fields, or have them with the wrong types
+	data := doc.Data()
+	if schemaVersionOf(data) < SchemaVersion("users") {
+		logger.WithField("uid", doc.Ref.ID).Warn("user hasn't been migrated, run the schema migrations")
+	}
+	userData.Roles = extractRoles(data)
+	userData.PublicFields = extractPublicFields(data)
+	userData.DonationIDs = postIDs(userData.Posts)
+
+	userData.UID = doc.Ref.ID // ID is stored in the Ref feild, so DataTo, does not store id in the user data object
+	return userData, nil
+}
@@ -0,0 +1,70 @@
+// @cite "How can I read a header from an HTTP request in Golang?" Stack Overflow, 2017. [Online].
+// Available: https://stackoverflow.com/questions/46021330/how-can-i-read-a-header-from-an-http-request-in-golang. [Accessed: 15- May- 2023].
+// This is a file in the package-"helpers" that contains the VerifyAuthHeader and VerifyIDToken functions.
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+	"strings"
+
+	"firebase.google.com/go/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrMissingAuthHeader is returned when a request doesn't carry an Authorization header.
+var ErrMissingAuthHeader = errors.New("no authorization header provided")
+
+// ErrMalformedAuthHeader is returned when the Authorization header isn't of the form "Bearer <token>".
+var ErrMalformedAuthHeader = errors.New("incorrect format for authorization header")
+
+// VerifyAuthHeader verifies the ID token inside an Authorization header.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - authHeader: the raw value of the Authorization header, in the format "Bearer <token>".
+//
+// Return values:
+//   - the decoded token of the user who sent the request.
+//   - error, if the header is missing, malformed, or the token is invalid.
+func (s *Service) VerifyAuthHeader(ctx context.Context, authHeader string) (*auth.Token, error) {
+	if authHeader == "" {
+		return nil, ErrMissingAuthHeader
+	}
+
+	// The Authorization header follows the format "Bearer <token>",
+	// so anything else is rejected before it reaches Firebase.
+	tokenString, found := strings.CutPrefix(authHeader, "Bearer ")
+	if !found || tokenString == "" {
+		return nil, ErrMalformedAuthHeader
+	}
+
+	return s.VerifyIDToken(ctx, tokenString)
+}
+
+// VerifyIDToken verifies a Firebase ID token, and tags the request's span with the UID of its user.
+// Parameters:
+//   - ctx: the context in which the function is invoked.
+//   - idToken: the ID token sent by the user.
+//
+// Return values:
+//   - the decoded token of the user who sent the request.
+//   - error, if the token is invalid.
+func (s *Service) VerifyIDToken(ctx context.Context, idToken string) (*auth.Token, error) {
+	spanCtx, span := tracing.StartClient(ctx, "firebase.auth/VerifyIDToken")
+	defer span.End()
+
+	token, err := s.Auth.VerifyIDToken(spanCtx, idToken)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("failed verifying id token: %w", err)
+	}
+
+	// Everything else the request does is for this user
+	tracing.SetAttributes(ctx, tracing.UID(token.UID))
+	logging.AddFields(ctx, log.Fields{"uid": token.UID})
+	span.SetAttributes(tracing.UID(token.UID))
+	return token, nil
+}
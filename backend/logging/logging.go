@@ -0,0 +1,50 @@
+// This is a file in the package-"logging" that contains the logger carried by each request's context.
+package logging
+
+import (
+	"context"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// contextKey is the key the request's logger is stored under in its context
+type contextKey struct{}
+
+// requestLogger holds the logger of a request, which gains fields as more is known about the request,
+// e.g. the UID of its user once their token is verified.
+type requestLogger struct {
+	mu    sync.Mutex
+	entry *log.Entry
+}
+
+// NewContext gets a context carrying a logger, which everything called with the context logs through.
+// Parameters:
+//   - ctx: the context to add the logger to.
+//   - entry: the logger, with the fields every line should have.
+//
+// Return values:
+//   - ctx with the logger in it.
+func NewContext(ctx context.Context, entry *log.Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, &requestLogger{entry: entry})
+}
+
+// FromContext gets the logger carried by ctx, or the global logger if it doesn't carry one.
+func FromContext(ctx context.Context) *log.Entry {
+	if logger, ok := ctx.Value(contextKey{}).(*requestLogger); ok {
+		logger.mu.Lock()
+		defer logger.mu.Unlock()
+		return logger.entry
+	}
+	return log.NewEntry(log.StandardLogger())
+}
+
+// AddFields adds fields to the logger carried by ctx, so everything logged with it from now on has them.
+// It does nothing if ctx doesn't carry a logger.
+func AddFields(ctx context.Context, fields log.Fields) {
+	if logger, ok := ctx.Value(contextKey{}).(*requestLogger); ok {
+		logger.mu.Lock()
+		defer logger.mu.Unlock()
+		logger.entry = logger.entry.WithFields(fields)
+	}
+}
@@ -1,89 +1,189 @@
-// @file main.go initializes the log objects, sentry, and routes used in the backend
+// @file main.go initializes the log objects, sentry, and the server used in the backend
 // @authors Aritro Saha, Joshua Chou
-// @cite [1] Stack Overflow.(2015). "Go Gin framework CORS," Stack Overflow [Online].
-// Available: https://stackoverflow.com/questions/29418478/go-gin-framework-cors.  [Accessed: 16-May-2023].
 package main
 
 import (
-	endpointsGet "relief_exchange_backend/endpoints/get"
-	endpointsPost "relief_exchange_backend/endpoints/post"
-	globals "relief_exchange_backend/globals"
+	"relief_exchange_backend/captcha"
+	"relief_exchange_backend/config"
+	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/metrics"
+	"relief_exchange_backend/middleware"
+	"relief_exchange_backend/server"
+	"relief_exchange_backend/tracing"
 
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/getsentry/sentry-go"
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
 	_ "github.com/joho/godotenv/autoload"
 	log "github.com/sirupsen/logrus"
 )
 
-// init function sets up logging and Firebase connections
-func init() {
-	// Log as JSON instead of the default ASCII formatter.
-	log.SetFormatter(&log.JSONFormatter{})
+// How long shutting down can take before giving up
+const (
+	shutdownTimeout    = 30 * time.Second
+	sentryFlushTimeout = 5 * time.Second
+)
+
+// main function loads the config, sets up logging, Sentry, tracing and the Firebase connections,
+// then wires them into the server and runs it until it's told to stop.
+func main() {
+	// Load the config, which can be moved with the CONFIG_FILE environment variable
+	configPath := os.Getenv("CONFIG_FILE")
+	if configPath == "" {
+		configPath = "config.json"
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Log as JSON by default, which is easier for log collectors to read
+	logger := log.StandardLogger()
+	if cfg.Log.Format == "text" {
+		logger.SetFormatter(&log.TextFormatter{})
+	} else {
+		logger.SetFormatter(&log.JSONFormatter{})
+	}
 
 	// Output to stdout instead of the default stderr
 	// Can be any io.Writer, see below for File example
-	log.SetOutput(os.Stdout)
+	logger.SetOutput(os.Stdout)
 
-	// Only log the warning severity or above.
-	log.SetLevel(log.WarnLevel)
+	// Only log the configured severity or above, the level was checked when loading the config
+	level, _ := log.ParseLevel(cfg.Log.Level)
+	logger.SetLevel(level)
 
-	// Initialize Firebase globals
-	err := globals.InitializeFirebaseGlobals()
+	// Set up Sentry, which does nothing without a DSN
+	err = sentry.Init(sentry.ClientOptions{
+		Dsn:              cfg.Sentry.DSN,
+		TracesSampleRate: cfg.Sentry.TracesSampleRate,
+	})
 	if err != nil {
-		log.Error(err)
+		logger.Fatalf("Error initializing Sentry: %s", err)
+	}
+	// Set up tracing, continuing the traces started by the frontend
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing)
+	if err != nil {
+		logger.Fatalf("Error initializing tracing: %s", err)
 	}
-}
 
-// main function initializes Firebase, Sentry, Firestore client, Auth client, and
-// sets up the server routes.
-func main() {
-	// Set up Sentry
-	err := sentry.Init(sentry.ClientOptions{
-		Dsn:              "https://4044f25736934d42862ea077a1283931@o924596.ingest.sentry.io/4505213654073344",
-		TracesSampleRate: 1.0,
+	// Connect to Firebase
+	fb, err := server.ConnectFirebase(context.Background(), cfg)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	// Initialize the CAPTCHA verifier
+	captchaVerifier, err := captcha.New(cfg.CAPTCHA)
+	if err != nil {
+		logger.Fatalf("Error initializing CAPTCHA verifier: %s", err)
+	}
+
+	// Load the rate limits of each route
+	rateLimits, err := middleware.LoadRateLimits(cfg.Limits.RateLimitsFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		logger.Warn("no rate limits file, using the default limit for every route")
+		rateLimits = middleware.DefaultRateLimits
+	} else if err != nil {
+		logger.Fatalf("Error loading rate limits: %s", err)
+	}
+
+	// Wire everything the server uses into it
+	backend, err := server.New(cfg, server.Deps{
+		Firestore:      fb.Firestore,
+		Auth:           fb.Auth,
+		Images:         fb.Images,
+		Exports:        fb.Exports,
+		CAPTCHA:        captchaVerifier,
+		RateLimitStore: middleware.NewMemoryStore(),
+		RateLimits:     rateLimits,
+		Clock:          helpers.SystemClock{},
+		Logger:         logger,
 	})
 	if err != nil {
-		log.Fatalf("Error initializing Sentry: %s", err)
-	}
-	// Initialize web server
-	r := gin.Default()
-
-	// Set up CORS middleware for all requests
-	//citations.txt: [2]
-	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "DELETE"},
-		AllowHeaders:     []string{"*"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}))
-
-	// Set up all GET endpoints
-	r.GET("/donations/list", endpointsGet.GetDonationsList)
-	r.GET("/donations/:id", endpointsGet.GetDonationByID)
-	r.GET("/users/:id", endpointsGet.GetUserDataByID)
-	r.GET("/users/banned", endpointsGet.GetIfBanned)
-	r.GET("/users/admin", endpointsGet.GetIfAdmin)
-
-	// Set up all POST endpoints
-	r.POST("/confirmCAPTCHA", endpointsPost.ValidateCAPTCHAToken)
-	r.POST("/donations/new", endpointsPost.AddDonation)
-	r.POST("/users/new", endpointsPost.AddUser)
-	r.POST("/users/delete", endpointsPost.DeleteUser)
-	r.POST("/users/ban", endpointsPost.BanUser)
-	r.POST("/donations/report", endpointsPost.ReportDonation)
-	r.POST("/donations/edit", endpointsPost.EditDonation)
-	r.POST("/donations/:id/delete", endpointsPost.DeleteDonation)
+		logger.Fatal(err)
+	}
+
+	// Serve metrics on their own internal listener, or behind a token on the main one
+	var metricsSrv *http.Server
+	if cfg.Metrics.Address != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", metrics.Handler())
+		metricsSrv = &http.Server{Addr: cfg.Metrics.Address, Handler: metricsMux, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := metricsSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("metrics server failed: ", err)
+			}
+		}()
+	} else if cfg.Metrics.Token == "" {
+		logger.Warn("metrics aren't served, set metrics.address or METRICS_TOKEN to serve them")
+	}
+
+	// Start background jobs, which stop when the server shuts down
+	jobs := backend.StartBackgroundJobs(context.Background())
 
 	// Start the server
-	err = r.Run()
-	if err != nil {
-		log.Error(err)
-		return
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           backend.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- srv.ListenAndServe()
+	}()
+
+	// Wait until we're told to stop, or the server fails to start
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	select {
+	case err = <-serverErr:
+		logger.Error(err)
+	case <-signalCtx.Done():
+		logger.Warn("shutting down")
+	}
+
+	// Stop taking new traffic and starting job runs, then let requests, the tasks they started and job runs
+	// that already started finish. Tasks and job runs still going at the deadline are cancelled, and picked
+	// up again by the background jobs on the next start.
+	backend.MarkShuttingDown()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	jobsErr := make(chan error, 1)
+	go func() {
+		jobsErr <- jobs.Shutdown(shutdownCtx)
+	}()
+	if err = srv.Shutdown(shutdownCtx); err != nil {
+		logger.Error("failed draining requests: ", err)
+	}
+	// Requests can start tasks, like deleting an account, which have to finish before Firestore is closed
+	if err = backend.ShutdownTasks(shutdownCtx); err != nil {
+		logger.Warn("tasks started by requests didn't finish in time, they were cancelled and are left to the background jobs")
+	}
+	if metricsSrv != nil {
+		if err = metricsSrv.Shutdown(shutdownCtx); err != nil {
+			logger.Error("failed stopping metrics server: ", err)
+		}
+	}
+
+	if err = <-jobsErr; err != nil {
+		logger.Warn("background jobs didn't stop in time, the runs in progress were cancelled")
+	}
+
+	if err = fb.Firestore.Close(); err != nil {
+		logger.Error("failed closing Firestore client: ", err)
+	}
+	// Send any errors and spans that haven't been reported yet
+	sentry.Flush(sentryFlushTimeout)
+	if err = shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("failed flushing traces: ", err)
 	}
 }
@@ -6,12 +6,30 @@ package main
 
 //@author Joshua
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
 	"log"
-	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
-	"relief_exchange_backend/globals"
+	"relief_exchange_backend/captcha"
+	"relief_exchange_backend/client"
+	"relief_exchange_backend/config"
+	endpointsPost "relief_exchange_backend/endpoints/post"
 	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/metrics"
+	"relief_exchange_backend/middleware"
+	"relief_exchange_backend/openapi"
+	"relief_exchange_backend/server"
+	"relief_exchange_backend/storage"
 	"relief_exchange_backend/types"
+	"strings"
 	"time"
 
 	"testing"
@@ -19,87 +37,153 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"cloud.google.com/go/firestore"
+	"firebase.google.com/go/auth"
 	"github.com/getsentry/sentry-go"
+	"github.com/gin-gonic/gin"
 	_ "github.com/joho/godotenv/autoload"
+	"github.com/sirupsen/logrus"
 
 	mockfs "github.com/weathersource/go-mockfs"
 
 	pb "google.golang.org/genproto/googleapis/firestore/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 var setup bool
 
+// service runs the helpers against the mock Firestore server
+var service *helpers.Service
+
+// mockServer is the mock Firestore server. It answers requests with the responses tests give it, in order,
+// and panics if it gets a request it wasn't given a response for, so every test using it has to reset it first.
+var mockServer *mockfs.MockServer
+
+const (
+	mockDatabase  = "projects/projectID/databases/(default)"
+	mockDocuments = mockDatabase + "/documents"
+)
+
+var (
+	mockCreateTime = timestamppb.New(time.Date(2017, 1, 26, 0, 0, 0, 0, time.UTC))
+	mockReadTime   = timestamppb.New(time.Date(2017, 2, 5, 0, 0, 0, 0, time.UTC))
+)
+
 func TestMain(m *testing.M) {
 	if !setup {
-		err := sentry.Init(sentry.ClientOptions{
-			Dsn:              "https://4044f25736934d42862ea077a1283931@o924596.ingest.sentry.io/4505213654073344",
-			TracesSampleRate: 1.0,
+		// Tests report to the same Sentry project as the server, if one is configured.
+		// They never contact a CAPTCHA provider, so they don't need its secret.
+		os.Setenv("DEVELOPMENT", "true")
+		os.Setenv("CAPTCHA_PROVIDER", "stub")
+		cfg, err := config.Load("config.json")
+		if err != nil {
+			log.Fatalf("Error loading config: %s", err)
+		}
+		err = sentry.Init(sentry.ClientOptions{
+			Dsn:              cfg.Sentry.DSN,
+			TracesSampleRate: cfg.Sentry.TracesSampleRate,
 		})
 		if err != nil {
 			log.Fatalf("Error initializing Sentry: %s", err)
 		}
-		var t *testing.T
 		client, server, err := mockfs.New()
-		assert.NotNil(t, client)
-		assert.NotNil(t, server)
-		assert.Nil(t, err)
-
-		globals.FirestoreClient = client
-
-		// Initialize Firebase globals
-		// Populate a mock document "b" in collection "C"
-		var (
-			aTime       = time.Date(2017, 1, 26, 0, 0, 0, 0, time.UTC)
-			aTime2      = time.Date(2017, 2, 5, 0, 0, 0, 0, time.UTC)
-			aTimestamp  = timestamppb.New(aTime)
-			aTimestamp2 = timestamppb.New(aTime2)
-			dbPath      = "projects/projectID/databases/(default)"
-			// collectionPath        = "projects/projectID/databases/(default)/documents/donations"
-			docPath = "projects/projectID/databases/(default)/documents/donations/interesting"
-			pdoc    = &pb.Document{
-				Name:       docPath,
-				CreateTime: aTimestamp,
-				UpdateTime: aTimestamp,
-				Fields: map[string]*pb.Value{
-					"id":                 {ValueType: &pb.Value_StringValue{"interesting"}},
-					"title":              {ValueType: &pb.Value_StringValue{"interesting"}},
-					"description":        {ValueType: &pb.Value_StringValue{"interesting"}},
-					"location":           {ValueType: &pb.Value_StringValue{"interesting"}},
-					"img":                {ValueType: &pb.Value_StringValue{"interesting"}},
-					"creation_timestamp": {ValueType: &pb.Value_TimestampValue{aTimestamp}},
-					"owner_id":           {ValueType: &pb.Value_StringValue{"interesting"}},
-					"tags":               {ValueType: &pb.Value_ArrayValue{&pb.ArrayValue{}}},
-					"reports":            {ValueType: &pb.Value_ArrayValue{&pb.ArrayValue{}}},
-				},
-			}
-		)
-		server.AddRPC(
-			&pb.BatchGetDocumentsRequest{
-				Database:  dbPath,
-				Documents: []string{docPath},
-			},
-			[]interface{}{
-				&pb.BatchGetDocumentsResponse{
-					Result:   &pb.BatchGetDocumentsResponse_Found{pdoc},
-					ReadTime: aTimestamp2,
-				},
-			},
-		)
+		if err != nil {
+			log.Fatalf("Error starting the mock Firestore server: %s", err)
+		}
+		mockServer = server
 
+		service = helpers.NewService(client, nil, nil, nil, config.Default(), helpers.SystemClock{}, logrus.StandardLogger())
 		setup = true
 	}
 
 	os.Exit(m.Run())
 }
 
+// mockDoc creates a document for the mock Firestore server to send.
+// Parameters:
+//   - path: the path of the document, e.g. "donations/interesting".
+//   - fields: the fields of the document.
+func mockDoc(path string, fields map[string]*pb.Value) *pb.Document {
+	return &pb.Document{
+		Name:       mockDocuments + "/" + path,
+		CreateTime: mockCreateTime,
+		UpdateTime: mockCreateTime,
+		Fields:     fields,
+	}
+}
+
+// mockGet makes the mock Firestore server send a document the next time it's fetched.
+func mockGet(doc *pb.Document) {
+	mockServer.AddRPC(
+		&pb.BatchGetDocumentsRequest{Database: mockDatabase, Documents: []string{doc.Name}},
+		[]interface{}{&pb.BatchGetDocumentsResponse{Result: &pb.BatchGetDocumentsResponse_Found{Found: doc}, ReadTime: mockReadTime}},
+	)
+}
+
+// mockDonation is the donation the mock Firestore server sends
+func mockDonation() *pb.Document {
+	return mockDoc("donations/interesting", map[string]*pb.Value{
+		"title":              {ValueType: &pb.Value_StringValue{StringValue: "interesting"}},
+		"description":        {ValueType: &pb.Value_StringValue{StringValue: "interesting"}},
+		"location":           {ValueType: &pb.Value_StringValue{StringValue: "interesting"}},
+		"img":                {ValueType: &pb.Value_StringValue{StringValue: "interesting"}},
+		"creation_timestamp": {ValueType: &pb.Value_TimestampValue{TimestampValue: mockCreateTime}},
+		"owner_id":           {ValueType: &pb.Value_StringValue{StringValue: "interesting"}},
+		"tags":               {ValueType: &pb.Value_ArrayValue{ArrayValue: &pb.ArrayValue{}}},
+		"reports":            {ValueType: &pb.Value_ArrayValue{ArrayValue: &pb.ArrayValue{}}},
+	})
+}
+
+// emulatorService creates a Service on the Firestore emulator, for tests that need a real database
+// because of the transactions and queries they make. They're skipped unless FIRESTORE_EMULATOR_HOST is set.
+func emulatorService(t *testing.T) *helpers.Service {
+	if os.Getenv("FIRESTORE_EMULATOR_HOST") == "" {
+		t.Skip("needs the Firestore emulator, set FIRESTORE_EMULATOR_HOST to run it")
+	}
+	client, err := firestore.NewClient(context.Background(), "relief-exchange-test")
+	if err != nil {
+		t.Fatalf("Error connecting to the Firestore emulator: %s", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return helpers.NewService(client, nil, nil, nil, config.Default(), helpers.SystemClock{}, logrus.StandardLogger())
+}
+
 func TestGetAllDonations(t *testing.T) {
-	donations, err := helpers.GetAllDonations()
+	mockServer.Reset()
+	mockServer.AddRPC(
+		&pb.RunQueryRequest{
+			Parent: mockDocuments,
+			QueryType: &pb.RunQueryRequest_StructuredQuery{StructuredQuery: &pb.StructuredQuery{
+				From: []*pb.StructuredQuery_CollectionSelector{{CollectionId: "donations"}},
+			}},
+		},
+		[]interface{}{&pb.RunQueryResponse{Document: mockDonation(), ReadTime: mockReadTime}},
+	)
+
+	donations, err := service.GetAllDonations(context.Background())
 	assert.NoError(t, err, "getAllDonations function should return without error")
-	assert.NotEmpty(t, donations, "getAllDonations should return at least one donation")
+	if assert.Len(t, donations, 1, "getAllDonations should return every donation") {
+		assert.Equal(t, "interesting", donations[0].ID, "The donation's ID should be set")
+		assert.Equal(t, "interesting", donations[0].OwnerId, "The donation's owner should be set")
+	}
 }
 
 func TestAddDonation(t *testing.T) {
+	service := emulatorService(t)
+	ctx := context.Background()
+	test_user_id := "p48oQ0SAYPeqculMRp2UBNJl03d2" //Joshua.C
+	_, err := service.Firestore.Doc("config/bans").Set(ctx, map[string]interface{}{"users": []string{}})
+	assert.NoError(t, err, "The ban list should be created")
+	_, err = service.Firestore.Doc("users/"+test_user_id).Set(ctx, map[string]interface{}{
+		"admin":          true,
+		"posts":          []*firestore.DocumentRef{},
+		"donations_made": 0,
+	})
+	assert.NoError(t, err, "The owner should be created")
+
 	donation := types.Donation{
 		ID:                "testID",
 		Title:             "testTitle",
@@ -111,12 +195,14 @@ func TestAddDonation(t *testing.T) {
 		Tags:              []string{"tag1", "tag2"},
 		Reports:           []string{"report1", "report2"},
 	}
-	test_user_id := "p48oQ0SAYPeqculMRp2UBNJl03d2" //Joshua.C
-	donationId, err := helpers.AddDonation(donation, test_user_id)
+	// The test donation is the same every run, so only flag it as a duplicate
+	service.Config.Limits.Duplicates.Action = helpers.DuplicateActionFlag
+	donationId, merged, err := service.AddDonation(ctx, donation, test_user_id)
 	assert.NoError(t, err, "addDonation function should return without error")
+	assert.False(t, merged, "addDonation should add a new donation")
 	assert.NotEmpty(t, donationId, "addDonation should return a donation id ")
 
-	owner, err := globals.FirestoreClient.Doc("users/" + test_user_id).Get(globals.FirebaseContext)
+	owner, err := service.Firestore.Doc("users/" + test_user_id).Get(ctx)
 	assert.NoError(t, err, "Owner should have been retrieved properly")
 
 	rawPosts := owner.Data()["posts"].([]interface{})
@@ -131,33 +217,848 @@ func TestAddDonation(t *testing.T) {
 	}
 
 	assert.Contains(t, posts, donationId, "add Donation should add the donation to the user posts feild")
+
+	// Linked images can't be screened, so only uploaded ones are accepted
+	donation.Image = "https://example.com/blocklisted.jpg"
+	_, _, err = service.AddDonation(ctx, donation, test_user_id)
+	assert.ErrorIs(t, err, helpers.ErrImageURL, "Donations with a linked image should be refused")
 }
 
 func TestGetDonationById(t *testing.T) {
-	test_user_id := "p48oQ0SAYPeqculMRp2UBNJl03d2" //Joshua.C
-	owner, err := globals.FirestoreClient.Doc("users/" + test_user_id).Get(globals.FirebaseContext)
-	assert.NoError(t, err, "Owner should have been retrieved properly")
-
-	rawPosts := owner.Data()["posts"].([]interface{})
-	var posts []string
-	for _, value := range rawPosts {
-		docRef, ok := value.(*firestore.DocumentRef)
-		if !ok {
-			t.Errorf("Error: value is not a *firestore.DocumentRef")
-			continue
-		}
-		posts = append(posts, docRef.ID)
-	}
+	mockServer.Reset()
+	mockGet(mockDonation())
 
-	donation, err := helpers.GetDonationByID(posts[rand.Intn(len(posts)-1)])
+	donation, err := service.GetDonationByID(context.Background(), "interesting")
 	assert.NoError(t, err, "GetDonationById function should return without error")
-	assert.NotEmpty(t, donation, "GetDonationsById should return at least one donation")
+	assert.Equal(t, "interesting", donation.ID, "GetDonationsById should return the donation")
+	assert.Equal(t, "interesting", donation.Image, "The legacy image should be read")
 	assert.False(t, donation.CreationTimestamp.IsZero(), "CreationTimestamp should be set")
 }
 
 func TestCheckIfAdmin(t *testing.T) {
 	test_user_id := "p48oQ0SAYPeqculMRp2UBNJl03d2" //Joshua.C
-	isAdmin, err := helpers.CheckIfAdmin(test_user_id)
+	mockServer.Reset()
+	mockGet(mockDoc("users/"+test_user_id, map[string]*pb.Value{
+		"admin": {ValueType: &pb.Value_BooleanValue{BooleanValue: true}},
+	}))
+
+	isAdmin, err := service.CheckIfAdmin(context.Background(), test_user_id)
 	assert.NoError(t, err, "GetDonationById function should return without error")
 	assert.True(t, isAdmin, "Joshua.C is an admin")
 }
+
+func TestHasRole(t *testing.T) {
+	moderator := mockDoc("users/moderator", map[string]*pb.Value{
+		"roles": {ValueType: &pb.Value_ArrayValue{ArrayValue: &pb.ArrayValue{Values: []*pb.Value{
+			{ValueType: &pb.Value_StringValue{StringValue: types.RoleModerator}},
+		}}}},
+	})
+	admin := mockDoc("users/admin", map[string]*pb.Value{
+		"admin": {ValueType: &pb.Value_BooleanValue{BooleanValue: true}},
+	})
+	mockServer.Reset()
+	mockGet(moderator)
+	mockGet(moderator)
+	mockGet(admin)
+
+	ctx := context.Background()
+	isModerator, err := service.HasRole(ctx, "moderator", types.RoleModerator)
+	assert.NoError(t, err, "HasRole should return without error")
+	assert.True(t, isModerator, "Users granted a role should have it")
+	isAdmin, err := service.HasRole(ctx, "moderator", types.RoleAdmin)
+	assert.NoError(t, err, "HasRole should return without error")
+	assert.False(t, isAdmin, "Moderators shouldn't be admins")
+	isModerator, err = service.HasRole(ctx, "admin", types.RoleModerator)
+	assert.NoError(t, err, "HasRole should return without error")
+	assert.True(t, isModerator, "Admins should have every role")
+}
+
+// mockAnswer makes the mock Firestore server send a response to the next request of a type, whatever it asks for.
+// Parameters:
+//   - empty: an empty request of the type, e.g. &pb.CommitRequest{}.
+//   - resp: the response.
+//   - check: gets the request before it's answered, can be nil.
+func mockAnswer(empty protoiface.MessageV1, resp interface{}, check func(req protoiface.MessageV1)) {
+	mockServer.AddRPCAdjust(empty, resp, func(req protoiface.MessageV1) {
+		if check != nil {
+			check(req)
+		}
+		req.Reset() // So it matches the empty request
+	})
+}
+
+// mockCommitResponse is what the mock Firestore server sends when writes are committed
+var mockCommitResponse = &pb.CommitResponse{
+	WriteResults: []*pb.WriteResult{{UpdateTime: mockReadTime}, {UpdateTime: mockReadTime}, {UpdateTime: mockReadTime}},
+	CommitTime:   mockReadTime,
+}
+
+// mockQuery makes the mock Firestore server send documents for the next query.
+func mockQuery(check func(req protoiface.MessageV1), docs ...*pb.Document) {
+	var responses []interface{}
+	for _, doc := range docs {
+		responses = append(responses, &pb.RunQueryResponse{Document: doc, ReadTime: mockReadTime})
+	}
+	mockAnswer(&pb.RunQueryRequest{}, responses, check)
+}
+
+// testMigration replaces the schema migrations with one that renames every donation, until the test ends.
+func testMigration(t *testing.T) {
+	migrations := helpers.Migrations
+	helpers.Migrations = []helpers.Migration{{
+		Version:     7,
+		Collection:  "donations",
+		Description: "rename every donation",
+		Migrate: func(s *helpers.Service, ctx context.Context, doc *firestore.DocumentSnapshot, batch *firestore.WriteBatch) ([]firestore.Update, error) {
+			return []firestore.Update{{Path: "title", Value: "migrated"}}, nil
+		},
+	}}
+	t.Cleanup(func() { helpers.Migrations = migrations })
+}
+
+// migratedDonation is a donation the test migration has already been applied to
+func migratedDonation(id string) *pb.Document {
+	return mockDoc("donations/"+id, map[string]*pb.Value{
+		"schema_version": {ValueType: &pb.Value_IntegerValue{IntegerValue: 7}},
+	})
+}
+
+func TestRunMigrationsDryRun(t *testing.T) {
+	testMigration(t)
+	mockServer.Reset()
+	progressPath := mockDocuments + "/migrations/0007"
+	mockServer.AddRPC(
+		&pb.BatchGetDocumentsRequest{Database: mockDatabase, Documents: []string{progressPath}},
+		[]interface{}{&pb.BatchGetDocumentsResponse{Result: &pb.BatchGetDocumentsResponse_Missing{Missing: progressPath}, ReadTime: mockReadTime}},
+	)
+	mockQuery(nil, mockDoc("donations/a", nil), migratedDonation("b"))
+	committed := false
+	mockAnswer(&pb.CommitRequest{}, mockCommitResponse, func(req protoiface.MessageV1) { committed = true })
+
+	progress, err := service.RunMigrations(context.Background(), helpers.MigrationOptions{DryRun: true, BatchSize: 10})
+	assert.NoError(t, err, "A dry run shouldn't fail")
+	assert.False(t, committed, "A dry run shouldn't write anything")
+	if assert.Len(t, progress, 1, "The progress of every migration should be returned") {
+		assert.True(t, progress[0].DryRun, "The progress should say it was a dry run")
+		assert.Equal(t, 2, progress[0].Scanned, "Every donation should be looked at")
+		assert.Equal(t, 1, progress[0].Migrated, "Only donations that weren't migrated should be counted")
+	}
+}
+
+func TestRunMigrationsResume(t *testing.T) {
+	testMigration(t)
+	mockServer.Reset()
+	ctx := context.Background()
+
+	// The migration stopped after donation "a", so it's claimed again and carries on after it
+	mockAnswer(&pb.BeginTransactionRequest{}, &pb.BeginTransactionResponse{Transaction: []byte("claim")}, nil)
+	mockAnswer(&pb.BatchGetDocumentsRequest{}, []interface{}{&pb.BatchGetDocumentsResponse{
+		Result: &pb.BatchGetDocumentsResponse_Found{Found: mockDoc("migrations/0007", map[string]*pb.Value{
+			"status":      {ValueType: &pb.Value_StringValue{StringValue: types.JobStatusFailed}},
+			"last_doc_id": {ValueType: &pb.Value_StringValue{StringValue: "a"}},
+			"scanned":     {ValueType: &pb.Value_IntegerValue{IntegerValue: 1}},
+			"migrated":    {ValueType: &pb.Value_IntegerValue{IntegerValue: 1}},
+			"started_at":  {ValueType: &pb.Value_TimestampValue{TimestampValue: mockCreateTime}},
+		})},
+		ReadTime: mockReadTime,
+	}}, nil)
+	mockAnswer(&pb.CommitRequest{}, mockCommitResponse, nil)
+
+	var startAfter string
+	mockQuery(func(req protoiface.MessageV1) {
+		if values := req.(*pb.RunQueryRequest).GetStructuredQuery().GetStartAt().GetValues(); len(values) == 1 {
+			startAfter = values[0].GetReferenceValue()
+		}
+	}, mockDoc("donations/b", nil), migratedDonation("c"))
+
+	var writes []*pb.Write
+	mockAnswer(&pb.CommitRequest{}, mockCommitResponse, func(req protoiface.MessageV1) {
+		writes = append(writes, req.(*pb.CommitRequest).Writes...)
+	})
+	completed := false
+	mockAnswer(&pb.CommitRequest{}, mockCommitResponse, func(req protoiface.MessageV1) {
+		completed = len(req.(*pb.CommitRequest).Writes) == 1
+	})
+
+	batches := 0
+	progress, err := service.RunMigrations(ctx, helpers.MigrationOptions{
+		BatchSize: 10,
+		Progress:  func(types.MigrationProgress) { batches++ },
+	})
+	assert.NoError(t, err, "The migration should carry on without failing")
+	assert.Equal(t, mockDocuments+"/donations/a", startAfter, "The migration should start after the last donation it got to")
+	assert.Equal(t, 1, batches, "Progress should be reported after the batch")
+	assert.True(t, completed, "The migration should be marked complete")
+	if assert.Len(t, progress, 1, "The progress of every migration should be returned") {
+		assert.Equal(t, types.JobStatusComplete, progress[0].Status, "The migration should be complete")
+		assert.Equal(t, 3, progress[0].Scanned, "Donations looked at before it stopped should be counted")
+		assert.Equal(t, 2, progress[0].Migrated, "Donations migrated before it stopped should be counted")
+	}
+
+	// Donation "b" is migrated along with the progress, and "c" was already migrated so it isn't written
+	if assert.Len(t, writes, 2, "The unmigrated donation and the progress should be written in one batch") {
+		assert.Equal(t, mockDocuments+"/donations/b", writes[0].GetUpdate().GetName(), "The unmigrated donation should be updated")
+		assert.Equal(t, "migrated", writes[0].GetUpdate().GetFields()["title"].GetStringValue(), "The migration's changes should be written")
+		assert.Equal(t, int64(7), writes[0].GetUpdate().GetFields()["schema_version"].GetIntegerValue(), "The donation should be marked as migrated")
+		assert.NotNil(t, writes[0].GetCurrentDocument().GetUpdateTime(), "The donation shouldn't be overwritten if it changed")
+		assert.Equal(t, "c", writes[1].GetUpdate().GetFields()["last_doc_id"].GetStringValue(), "The progress should move past the batch")
+	}
+}
+
+// fixedClock is a helpers.Clock that's always at the same time
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func TestClaimJobUsesClock(t *testing.T) {
+	mockServer.Reset()
+	// The export was claimed a minute ago by the service's clock, though years ago by the system's
+	clock := fixedClock(mockCreateTime.AsTime().Add(time.Minute))
+	clocked := helpers.NewService(service.Firestore, nil, nil, nil, config.Default(), clock, logrus.StandardLogger())
+
+	mockAnswer(&pb.BeginTransactionRequest{}, &pb.BeginTransactionResponse{Transaction: []byte("claim")}, nil)
+	mockAnswer(&pb.BatchGetDocumentsRequest{}, []interface{}{&pb.BatchGetDocumentsResponse{
+		Result: &pb.BatchGetDocumentsResponse_Found{Found: mockDoc("exports/running", map[string]*pb.Value{
+			"uid":        {ValueType: &pb.Value_StringValue{StringValue: "someone"}},
+			"status":     {ValueType: &pb.Value_StringValue{StringValue: types.JobStatusRunning}},
+			"claimed_at": {ValueType: &pb.Value_TimestampValue{TimestampValue: mockCreateTime}},
+		})},
+		ReadTime: mockReadTime,
+	}}, nil)
+	rolledBack := false
+	mockAnswer(&pb.RollbackRequest{}, &emptypb.Empty{}, func(protoiface.MessageV1) { rolledBack = true })
+
+	assert.NoError(t, clocked.ProcessDataExport(context.Background(), "running"), "An export another worker is on should be skipped")
+	assert.True(t, rolledBack, "An export claimed recently by the service's clock shouldn't be claimed again")
+}
+
+func TestPublicProfileDefaults(t *testing.T) {
+	user := types.UserData{
+		UID:                "user",
+		DisplayName:        "Donor",
+		Email:              "donor@example.com",
+		Bio:                "Clearing out the garage",
+		PublicFields:       types.DefaultPublicFields,
+		ContactPreferences: types.ContactPreferences{Email: true},
+	}
+	profile := user.ToPublicProfile()
+	assert.Empty(t, profile.Email, "Emails shouldn't be public until the user chooses it")
+	assert.Empty(t, profile.Bio, "Optional fields shouldn't be public until the user chooses them")
+
+	user.PublicFields = []string{types.PublicFieldEmail}
+	assert.Equal(t, user.Email, user.ToPublicProfile().Email, "Emails should be public once the user chooses it")
+}
+
+func TestContainsProfanity(t *testing.T) {
+	assert.True(t, helpers.ContainsProfanity("what the fuck"), "Plain profanity should be caught")
+	assert.True(t, helpers.ContainsProfanity("sh1iiit"), "Substituted and repeated letters should be caught")
+	assert.False(t, helpers.ContainsProfanity("Winter jackets for a class of 30"), "Words containing profanity should be allowed")
+}
+
+func TestProcessImage(t *testing.T) {
+	// Make a landscape photo with EXIF data saying it has to be rotated 90 degrees clockwise
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 300, 100)), nil)
+	assert.NoError(t, err, "Test image should be encoded properly")
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1, 0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, 6, 0, 0, 0, 0, 0, 0, 0, 0}
+	app1 := append([]byte{0xFF, 0xE1, 0, byte(2 + 6 + len(tiff))}, append([]byte("Exif\x00\x00"), tiff...)...)
+	photo := append(append([]byte{0xFF, 0xD8}, app1...), buf.Bytes()[2:]...)
+
+	processed, err := helpers.ProcessImage(photo)
+	assert.NoError(t, err, "ProcessImage should accept a valid JPEG")
+	assert.Equal(t, 100, processed.Width, "Photo should have been rotated upright")
+	assert.Equal(t, 300, processed.Height, "Photo should have been rotated upright")
+	for _, rendition := range processed.Renditions {
+		assert.NotContains(t, string(rendition), "Exif", "Renditions should have no EXIF data")
+	}
+
+	_, err = helpers.ProcessImage([]byte("definitely not an image"))
+	assert.ErrorIs(t, err, helpers.ErrUnsupportedImageType, "Files that aren't images should be rejected")
+}
+
+func TestPerceptualHash(t *testing.T) {
+	// Make a photo with a horizontal gradient, and a resized copy of it
+	gradient := func(w, h int, reversed bool) image.Image {
+		img := image.NewGray(image.Rect(0, 0, w, h))
+		for x := 0; x < w; x++ {
+			shade := uint8(x * 255 / w)
+			if reversed {
+				shade = 255 - shade
+			}
+			for y := 0; y < h; y++ {
+				img.SetGray(x, y, color.Gray{Y: shade})
+			}
+		}
+		return img
+	}
+
+	original := helpers.PerceptualHash(gradient(400, 300, false))
+	resized := helpers.PerceptualHash(gradient(200, 150, false))
+	different := helpers.PerceptualHash(gradient(400, 300, true))
+
+	assert.LessOrEqual(t, helpers.HammingDistance(original, resized), config.Default().Limits.ImageMatch.MaxDistance, "Resized copies should match")
+	assert.Greater(t, helpers.HammingDistance(original, different), config.Default().Limits.ImageMatch.MaxDistance, "Different images shouldn't match")
+}
+
+func TestListingSimilarity(t *testing.T) {
+	original := types.Donation{
+		Title:       "Winter coat, size M",
+		Description: "Warm winter coat in good condition. Pick up downtown.",
+		Tags:        []string{"clothing", "winter"},
+	}
+	repost := types.Donation{
+		Title:       "winter coat (size M)!",
+		Description: "Warm winter coat in good condition, pick up downtown",
+		Tags:        []string{"Clothing", "winter"},
+	}
+	different := types.Donation{
+		Title:       "Canned soup",
+		Description: "Six cans of tomato soup, best before next year.",
+		Tags:        []string{"food"},
+	}
+
+	assert.GreaterOrEqual(t, helpers.ListingSimilarity(original, repost), config.Default().Limits.Duplicates.Threshold, "Reposts with small edits should be duplicates")
+	assert.Less(t, helpers.ListingSimilarity(original, different), config.Default().Limits.Duplicates.Threshold, "Different items shouldn't be duplicates")
+
+	// Blank descriptions and tags don't make listings any more alike
+	coat := types.Donation{Title: "Kids winter coat"}
+	set := types.Donation{Title: "Kids winter coat and hat"}
+	assert.Less(t, helpers.ListingSimilarity(coat, set), config.Default().Limits.Duplicates.Threshold, "Listings with only similar titles shouldn't be duplicates")
+	assert.Equal(t, 1.0, helpers.ListingSimilarity(coat, coat), "Listings with only the same title should be duplicates")
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	store := middleware.NewMemoryStore()
+	limit := middleware.Limit{Requests: 1, Per: time.Hour, Burst: 2}
+
+	for i := 0; i < limit.Burst; i++ {
+		allowed, _, err := store.Take(context.Background(), "test", limit)
+		assert.NoError(t, err, "Taking a token shouldn't fail")
+		assert.True(t, allowed, "Requests up to the burst should be allowed")
+	}
+
+	allowed, retryAfter, err := store.Take(context.Background(), "test", limit)
+	assert.NoError(t, err, "Taking a token shouldn't fail")
+	assert.False(t, allowed, "Requests past the burst should be limited")
+	assert.Greater(t, retryAfter, time.Duration(0), "Limited requests should be told when to retry")
+
+	allowed, _, _ = store.Take(context.Background(), "other", limit)
+	assert.True(t, allowed, "Other keys should have their own bucket")
+}
+
+func TestStubCAPTCHAVerifier(t *testing.T) {
+	var verifier captcha.Verifier = captcha.Stub{}
+
+	_, err := verifier.Verify(context.Background(), captcha.StubPassToken, captcha.ActionDonate, "127.0.0.1")
+	assert.NoError(t, err, "The stub should accept its pass token")
+
+	_, err = verifier.Verify(context.Background(), "", captcha.ActionDonate, "127.0.0.1")
+	assert.ErrorIs(t, err, captcha.ErrFailed, "Missing tokens should fail")
+
+	_, err = verifier.Verify(context.Background(), "not a real token", captcha.ActionDonate, "127.0.0.1")
+	assert.ErrorIs(t, err, captcha.ErrFailed, "Other tokens should fail")
+}
+
+func TestReCAPTCHAVerifier(t *testing.T) {
+	var response string
+	var form url.Values
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = r.PostForm
+		w.Write([]byte(response))
+	}))
+	defer provider.Close()
+	verifier := &captcha.ReCAPTCHA{Secret: "secret", MinScore: 0.5, VerifyURL: provider.URL}
+	verify := func(body string) error {
+		response = body
+		_, err := verifier.Verify(context.Background(), "token", captcha.ActionDonate, "127.0.0.1")
+		return err
+	}
+
+	assert.NoError(t, verify(`{"success": true, "score": 0.9, "action": "donate"}`), "Tokens with a high score for the action should pass")
+	assert.Equal(t, "secret", form.Get("secret"), "The secret should be sent in the form")
+	assert.Equal(t, "token", form.Get("response"), "The token should be sent in the form")
+	assert.ErrorIs(t, verify(`{"success": true, "score": 0.1, "action": "donate"}`), captcha.ErrLowScore, "Tokens with a low score should fail")
+	assert.ErrorIs(t, verify(`{"success": true, "score": 0.9, "action": "signup"}`), captcha.ErrActionMismatch, "Tokens for another action should fail")
+	err := verify(`{"success": false, "error-codes": ["timeout-or-duplicate"]}`)
+	assert.ErrorIs(t, err, captcha.ErrInvalidToken, "Tokens the provider rejects should fail")
+	assert.ErrorContains(t, err, "timeout-or-duplicate", "The provider's reason should be kept")
+
+	// reCAPTCHA v2 only passes or fails
+	verifier.MinScore = 0
+	assert.NoError(t, verify(`{"success": true}`), "v2 tokens without a score should pass")
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := config.Default()
+	assert.ErrorContains(t, cfg.Validate(), "RECAPTCHA_SECRET_KEY", "The CAPTCHA secret should be required")
+	cfg.CAPTCHA.ReCAPTCHASecret = "secret"
+	assert.NoError(t, cfg.Validate(), "The default config should be valid once the CAPTCHA secret is set")
+
+	cfg.CAPTCHA.Provider = "stub"
+	assert.ErrorContains(t, cfg.Validate(), "captcha.provider", "The stub CAPTCHA shouldn't be allowed outside development")
+	cfg.Development = true
+	assert.NoError(t, cfg.Validate(), "The stub CAPTCHA should be allowed in development")
+
+	cfg = config.Default()
+	cfg.CORS.AllowedOrigins = []string{"*"}
+	cfg.Log.Level = "loud"
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, "cors.allowed_origins", "Wildcard origins shouldn't be allowed with credentials")
+	assert.ErrorContains(t, err, "log.level", "Every invalid setting should be reported")
+
+	// Tools like reliefctl don't serve requests, so they don't need the server's settings
+	cfg = config.Default()
+	cfg.Port = 0
+	assert.NoError(t, cfg.ValidateTool(), "Tools shouldn't need a CAPTCHA secret or a valid port")
+	cfg.Log.Level = "loud"
+	assert.ErrorContains(t, cfg.ValidateTool(), "log.level", "Tools should still check the settings they use")
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Metrics())
+	r.GET("/metrics", middleware.RequireToken("secret"), gin.WrapH(metrics.Handler()))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "Metrics shouldn't be served without the token")
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "Metrics should be served with the token")
+	assert.Contains(t, w.Body.String(), `relief_exchange_http_requests_total{method="GET",route="/metrics",status="401"} 1`,
+		"Earlier requests should be counted by route and status")
+}
+
+func TestRequestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.RequestLogger(logrus.StandardLogger()))
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(middleware.RequestIDHeader, "from-proxy-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "from-proxy-1", w.Header().Get(middleware.RequestIDHeader), "Valid request IDs should be kept")
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(middleware.RequestIDHeader, "not\na valid id")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Regexp(t, "^[0-9a-f]{32}$", w.Header().Get(middleware.RequestIDHeader), "Invalid request IDs should be replaced")
+}
+
+func TestDeadline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Deadline(config.TimeoutsConfig{Default: config.Duration(10 * time.Millisecond)}))
+	r.GET("/", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code, "Requests that run out of time should get a 504")
+	assert.NotContains(t, w.Body.String(), "internal server error", "The handler's error shouldn't be sent")
+}
+
+func TestServerInstances(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	first, err := server.New(config.Default(), server.Deps{CAPTCHA: captcha.Stub{}})
+	assert.NoError(t, err, "Creating a server shouldn't fail")
+	second, err := server.New(config.Default(), server.Deps{CAPTCHA: captcha.Stub{}})
+	assert.NoError(t, err, "Creating a second server shouldn't fail")
+
+	first.MarkShuttingDown()
+
+	w := httptest.NewRecorder()
+	first.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code, "A server shutting down shouldn't be ready")
+
+	w = httptest.NewRecorder()
+	second.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code, "Other servers shouldn't be affected")
+}
+
+func TestBackgroundJobsShutdown(t *testing.T) {
+	started := make(chan struct{}, 1)
+	cancelled := make(chan struct{})
+	jobs := server.Jobs
+	server.Jobs = []server.Job{{Name: "slow", Interval: time.Millisecond, Run: func(ctx context.Context, service *helpers.Service) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	}}}
+	t.Cleanup(func() { server.Jobs = jobs })
+
+	srv, err := server.New(config.Default(), server.Deps{CAPTCHA: captcha.Stub{}})
+	assert.NoError(t, err, "Creating a server shouldn't fail")
+	running := srv.StartBackgroundJobs(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, running.Shutdown(ctx), context.DeadlineExceeded, "Shutdown should give up at the deadline")
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("The run in progress should be cancelled once the deadline passes")
+	}
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.Metrics.Token = "secret" // So /metrics is served by the main router
+	srv, err := server.New(cfg, server.Deps{CAPTCHA: captcha.Stub{}})
+	assert.NoError(t, err, "Creating a server shouldn't fail")
+
+	doc, err := openapi.Load()
+	assert.NoError(t, err, "The OpenAPI document should be valid")
+	documented := map[string]bool{}
+	for path, pathItem := range doc.Paths {
+		for method := range pathItem.Operations() {
+			documented[method+" "+middleware.GinPath(path)] = true
+		}
+	}
+
+	for _, route := range srv.Routes() {
+		// Uploaded files are served straight from disk, they aren't part of the API
+		if strings.HasPrefix(route.Path, "/uploads/") {
+			continue
+		}
+		assert.True(t, documented[route.Method+" "+route.Path], "%s %s should be in the OpenAPI document", route.Method, route.Path)
+	}
+}
+
+// rejectingAuth is a Firebase Auth client that rejects every token
+type rejectingAuth struct {
+	helpers.AuthClient
+}
+
+func (rejectingAuth) VerifyIDToken(ctx context.Context, idToken string) (*auth.Token, error) {
+	return nil, errors.New("invalid token")
+}
+
+// acceptingAuth is a Firebase Auth client that accepts every token as the user it names
+type acceptingAuth struct {
+	helpers.AuthClient
+}
+
+func (acceptingAuth) VerifyIDToken(ctx context.Context, idToken string) (*auth.Token, error) {
+	return &auth.Token{UID: idToken}, nil
+}
+
+func TestDownloadDataExport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	exports, err := storage.NewLocalStore(t.TempDir(), "")
+	assert.NoError(t, err, "Creating the export store shouldn't fail")
+	srv, err := server.New(config.Default(), server.Deps{
+		Firestore: service.Firestore,
+		Auth:      acceptingAuth{},
+		Exports:   exports,
+		CAPTCHA:   captcha.Stub{},
+	})
+	assert.NoError(t, err, "Creating a server shouldn't fail")
+	_, err = exports.Put(context.Background(), "exports/export.zip", []byte("zip"), "application/zip")
+	assert.NoError(t, err, "Storing the export shouldn't fail")
+
+	download := func(expiresAt time.Time) *httptest.ResponseRecorder {
+		mockServer.Reset()
+		mockGet(mockDoc("exports/export", map[string]*pb.Value{
+			"uid":        {ValueType: &pb.Value_StringValue{StringValue: "owner"}},
+			"status":     {ValueType: &pb.Value_StringValue{StringValue: types.JobStatusComplete}},
+			"expires_at": {ValueType: &pb.Value_TimestampValue{TimestampValue: timestamppb.New(expiresAt)}},
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/v1/me/exports/export/download", nil)
+		req.Header.Set("Authorization", "Bearer owner")
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	w := download(time.Now().Add(time.Hour))
+	assert.Equal(t, http.StatusOK, w.Code, "Exports should be downloadable until they expire")
+	assert.Equal(t, "zip", w.Body.String(), "The export should be read from the store")
+	assert.Equal(t, http.StatusGone, download(time.Now().Add(-time.Hour)).Code, "Expired exports shouldn't be downloadable")
+}
+
+func TestReportDonationTwice(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv, err := server.New(config.Default(), server.Deps{Firestore: service.Firestore, Auth: acceptingAuth{}, CAPTCHA: captcha.Stub{}})
+	assert.NoError(t, err, "Creating a server shouldn't fail")
+
+	mockServer.Reset()
+	mockGet(mockDoc("config/bans", map[string]*pb.Value{
+		"users": {ValueType: &pb.Value_ArrayValue{ArrayValue: &pb.ArrayValue{}}},
+	}))
+	mockGet(mockDoc("donations/interesting", map[string]*pb.Value{
+		"reports": {ValueType: &pb.Value_ArrayValue{ArrayValue: &pb.ArrayValue{Values: []*pb.Value{{ValueType: &pb.Value_StringValue{StringValue: "reporter"}}}}}},
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/v1/donations/interesting/reports", nil)
+	req.Header.Set("Authorization", "Bearer reporter")
+	req.Header.Set(endpointsPost.CAPTCHAHeader, captcha.StubPassToken)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code, "Reporting a donation twice should be a conflict")
+	assert.Contains(t, w.Body.String(), helpers.ErrAlreadyReported.Error())
+}
+
+func TestDeleteDonationRoleError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv, err := server.New(config.Default(), server.Deps{Firestore: service.Firestore, Auth: acceptingAuth{}, CAPTCHA: captcha.Stub{}})
+	assert.NoError(t, err, "Creating a server shouldn't fail")
+
+	// The donation is from before the migrations, so it has no owner_id, and checking the sender's roles fails
+	mockServer.Reset()
+	mockGet(mockDoc("donations/unmigrated", map[string]*pb.Value{
+		"title": {ValueType: &pb.Value_StringValue{StringValue: "unmigrated"}},
+	}))
+	mockAnswer(&pb.BatchGetDocumentsRequest{}, []interface{}{status.Error(codes.Unavailable, "secret details")}, nil)
+	req := httptest.NewRequest(http.MethodDelete, "/v1/donations/unmigrated", nil)
+	req.Header.Set("Authorization", "Bearer moderator")
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code, "Failing to check the sender's roles shouldn't look like they aren't a moderator")
+	assert.NotContains(t, w.Body.String(), "secret details", "Database errors shouldn't be sent to the client")
+}
+
+func TestGetUserDonationsErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv, err := server.New(config.Default(), server.Deps{Firestore: service.Firestore, CAPTCHA: captcha.Stub{}})
+	assert.NoError(t, err, "Creating a server shouldn't fail")
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/users/missing/donations", nil))
+		return w
+	}
+
+	// The user doesn't exist
+	mockServer.Reset()
+	mockGet(mockDoc("config/bans", map[string]*pb.Value{
+		"users": {ValueType: &pb.Value_ArrayValue{ArrayValue: &pb.ArrayValue{}}},
+	}))
+	missing := mockDocuments + "/users/missing"
+	mockServer.AddRPC(
+		&pb.BatchGetDocumentsRequest{Database: mockDatabase, Documents: []string{missing}},
+		[]interface{}{&pb.BatchGetDocumentsResponse{Result: &pb.BatchGetDocumentsResponse_Missing{Missing: missing}, ReadTime: mockReadTime}},
+	)
+	assert.Equal(t, http.StatusNotFound, get().Code, "Users that don't exist should be not found")
+
+	// Firestore fails
+	mockServer.Reset()
+	mockAnswer(&pb.BatchGetDocumentsRequest{}, []interface{}{status.Error(codes.PermissionDenied, "secret details")}, nil)
+	w := get()
+	assert.Equal(t, http.StatusInternalServerError, w.Code, "Database errors shouldn't look like the user wasn't found")
+	assert.NotContains(t, w.Body.String(), "secret details", "Database errors shouldn't be sent to the client")
+}
+
+func TestDeleteDonationRemovesPost(t *testing.T) {
+	mockServer.Reset()
+	donation := mockDocuments + "/donations/interesting"
+	var owners *pb.StructuredQuery
+	mockQuery(func(req protoiface.MessageV1) { owners = req.(*pb.RunQueryRequest).GetStructuredQuery() }, mockDoc("users/owner", nil))
+	var writes []*pb.Write
+	mockAnswer(&pb.CommitRequest{}, mockCommitResponse, func(req protoiface.MessageV1) {
+		writes = append(writes, req.(*pb.CommitRequest).Writes...)
+	})
+	mockQuery(nil) // The donation's images
+
+	assert.NoError(t, service.DeleteDonation(context.Background(), "interesting", false))
+	assert.Equal(t, donation, owners.GetWhere().GetFieldFilter().GetValue().GetReferenceValue(), "The owner should be found by their posts")
+	if assert.Len(t, writes, 2, "The donation and its owner's posts should be written together") {
+		assert.Equal(t, donation, writes[0].GetDelete(), "The donation should be deleted")
+		transforms := writes[1].GetUpdateTransforms()
+		if assert.Len(t, transforms, 1) {
+			assert.Equal(t, "posts", transforms[0].GetFieldPath())
+			assert.Equal(t, donation, transforms[0].GetRemoveAllFromArray().GetValues()[0].GetReferenceValue(), "The donation should be taken out of its owner's posts")
+		}
+	}
+}
+
+func TestGetReportedDonations(t *testing.T) {
+	mockServer.Reset()
+	var query *pb.StructuredQuery
+	reported := mockDoc("donations/reported", map[string]*pb.Value{
+		"reports":      {ValueType: &pb.Value_ArrayValue{ArrayValue: &pb.ArrayValue{Values: []*pb.Value{{ValueType: &pb.Value_StringValue{StringValue: "someone"}}, {ValueType: &pb.Value_StringValue{StringValue: "someone else"}}}}}},
+		"report_count": {ValueType: &pb.Value_IntegerValue{IntegerValue: 2}},
+	})
+	mockQuery(func(req protoiface.MessageV1) { query = req.(*pb.RunQueryRequest).GetStructuredQuery() }, reported)
+
+	donations, err := service.GetReportedDonations(context.Background(), 2)
+	assert.NoError(t, err)
+	if assert.Len(t, donations, 1) {
+		assert.Equal(t, "reported", donations[0].ID)
+	}
+
+	// Only reported donations should be read, rather than every donation
+	filter := query.GetWhere().GetFieldFilter()
+	assert.Equal(t, "report_count", filter.GetField().GetFieldPath())
+	assert.Equal(t, pb.StructuredQuery_FieldFilter_GREATER_THAN_OR_EQUAL, filter.GetOp())
+	assert.Equal(t, int64(2), filter.GetValue().GetIntegerValue())
+	if assert.Len(t, query.GetOrderBy(), 1) {
+		assert.Equal(t, pb.StructuredQuery_DESCENDING, query.GetOrderBy()[0].GetDirection(), "The most reported should be first")
+	}
+}
+
+func TestGetUserProfile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv, err := server.New(config.Default(), server.Deps{Firestore: service.Firestore, CAPTCHA: captcha.Stub{}})
+	assert.NoError(t, err, "Creating a server shouldn't fail")
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/users/owner", nil))
+		return w
+	}
+	bans := func(uids ...string) {
+		var values []*pb.Value
+		for _, uid := range uids {
+			values = append(values, &pb.Value{ValueType: &pb.Value_StringValue{StringValue: uid}})
+		}
+		mockGet(mockDoc("config/bans", map[string]*pb.Value{
+			"users": {ValueType: &pb.Value_ArrayValue{ArrayValue: &pb.ArrayValue{Values: values}}},
+		}))
+	}
+	post := func(id string) *pb.Value {
+		return &pb.Value{ValueType: &pb.Value_ReferenceValue{ReferenceValue: mockDocuments + "/donations/" + id}}
+	}
+
+	// One of their posts was deleted before deleting a donation took it out of them
+	mockServer.Reset()
+	bans()
+	mockGet(mockDoc("users/owner", map[string]*pb.Value{
+		"posts": {ValueType: &pb.Value_ArrayValue{ArrayValue: &pb.ArrayValue{Values: []*pb.Value{post("kept"), post("deleted")}}}},
+	}))
+	mockAnswer(&pb.BatchGetDocumentsRequest{}, []interface{}{
+		&pb.BatchGetDocumentsResponse{Result: &pb.BatchGetDocumentsResponse_Found{Found: mockDoc("donations/kept", nil)}, ReadTime: mockReadTime},
+		&pb.BatchGetDocumentsResponse{Result: &pb.BatchGetDocumentsResponse_Missing{Missing: mockDocuments + "/donations/deleted"}, ReadTime: mockReadTime},
+	}, nil)
+	w := get()
+	if assert.Equal(t, http.StatusOK, w.Code) {
+		var profile types.PublicProfile
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &profile))
+		assert.Equal(t, []string{"kept"}, profile.DonationIDs, "Deleted donations shouldn't be listed")
+		assert.Equal(t, 1, profile.ActiveDonations, "Deleted donations shouldn't be counted")
+	}
+
+	// Banned users are hidden
+	mockServer.Reset()
+	bans("owner")
+	mockGet(mockDoc("bans/owner", map[string]*pb.Value{
+		"banned_by": {ValueType: &pb.Value_StringValue{StringValue: "admin"}},
+	}))
+	w = get()
+	assert.Equal(t, http.StatusNotFound, w.Code, "Banned users should be not found")
+	assert.NotContains(t, w.Body.String(), helpers.ErrUserBanned.Error(), "Whether a user is banned shouldn't be given away")
+
+	// Firestore fails
+	mockServer.Reset()
+	mockAnswer(&pb.BatchGetDocumentsRequest{}, []interface{}{status.Error(codes.PermissionDenied, "secret details")}, nil)
+	w = get()
+	assert.Equal(t, http.StatusInternalServerError, w.Code, "Database errors shouldn't look like the user wasn't found")
+	assert.NotContains(t, w.Body.String(), "secret details", "Database errors shouldn't be sent to the client")
+}
+
+func TestValidateRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv, err := server.New(config.Default(), server.Deps{Auth: rejectingAuth{}, CAPTCHA: captcha.Stub{}})
+	assert.NoError(t, err, "Creating a server shouldn't fail")
+
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/someone/donations?limit=lots", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Query parameters of the wrong type should be rejected")
+	assert.Contains(t, w.Body.String(), "limit", "The invalid parameter should be named")
+
+	body := strings.NewReader(`{"uid": "someone", "role": "owner", "token": "token"}`)
+	req := httptest.NewRequest(http.MethodPost, "/admin/roles/grant", body)
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Bodies that don't match the document should be rejected")
+	assert.Contains(t, w.Body.String(), "body.role", "The invalid field should be named")
+
+	body = strings.NewReader(`{"uid": "someone", "role": "owner", "token": "token"}`)
+	req = httptest.NewRequest(http.MethodPost, "/admin/roles/grant", body)
+	req.Header.Set("Content-Type", "text/plain")
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code, "JSON bodies sent as something else shouldn't get around the checks")
+
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/roles/grant", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Missing bodies should be rejected")
+
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, openapi.SpecPath, nil))
+	assert.Equal(t, http.StatusOK, w.Code, "The OpenAPI document should be served")
+}
+
+func TestLegacyRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv, err := server.New(config.Default(), server.Deps{
+		Auth:       rejectingAuth{},
+		CAPTCHA:    captcha.Stub{},
+		RateLimits: middleware.RateLimits{Default: middleware.Limit{Requests: 1, Per: time.Hour, Burst: 1}},
+	})
+	assert.NoError(t, err, "Creating a server shouldn't fail")
+
+	req := httptest.NewRequest(http.MethodPost, "/users/delete", strings.NewReader(`{"token": "token"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code, "The original route should still be handled")
+	assert.Equal(t, "true", w.Header().Get("Deprecation"), "The original route should be marked as deprecated")
+	assert.Equal(t, `</v1/me>; rel="successor-version"`, w.Header().Get("Link"), "The original route should link to its replacement")
+
+	req = httptest.NewRequest(http.MethodDelete, "/v1/me", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "The original route should share its rate limit with its replacement")
+	assert.Empty(t, w.Header().Get("Deprecation"), "The /v1 route shouldn't be marked as deprecated")
+}
+
+func TestClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv, err := server.New(config.Default(), server.Deps{Auth: rejectingAuth{}, CAPTCHA: captcha.Stub{}})
+	assert.NoError(t, err, "Creating a server shouldn't fail")
+	backend := httptest.NewServer(srv.Handler())
+	defer backend.Close()
+
+	c, err := client.New(backend.URL, client.Options{Token: client.StaticToken("token")})
+	assert.NoError(t, err, "Creating a client shouldn't fail")
+	_, err = c.Me(context.Background())
+	assert.ErrorIs(t, err, client.ErrUnauthorized, "Errors should match the status the server sent")
+	var apiErr *client.APIError
+	assert.ErrorAs(t, err, &apiErr, "Errors from the server should be an APIError")
+	assert.NotEmpty(t, apiErr.RequestID, "Errors should have the ID of the request")
+
+	// Rate limited requests are retried until they go through
+	attempts := 0
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"banned": true}`))
+	}))
+	defer flaky.Close()
+	c, err = client.New(flaky.URL, client.Options{MinBackoff: time.Millisecond})
+	assert.NoError(t, err, "Creating a client shouldn't fail")
+	banned, err := c.IsBanned(context.Background(), "someone")
+	assert.NoError(t, err, "Rate limited requests should be retried")
+	assert.True(t, banned, "The response should be decoded")
+	assert.Equal(t, 3, attempts, "The request should be sent until it goes through")
+}
@@ -0,0 +1,76 @@
+// @cite "gRPC interceptors." gRPC Go documentation, 2023. [Online].
+// Available: https://pkg.go.dev/google.golang.org/grpc#UnaryClientInterceptor. [Accessed: 19- October- 2026].
+// This is a file in the package-"metrics" that contains the interceptors measuring Firestore calls.
+package metrics
+
+import (
+	"context"
+	"path"
+	"runtime"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// unknownHelper labels calls that weren't made from a helper
+const unknownHelper = "other"
+
+// maxCallerDepth is how far up the stack the helper making a call is looked for
+const maxCallerDepth = 64
+
+// FirestoreDialOptions gets the gRPC options that measure every Firestore call.
+// Calls are labelled with the function in helperPackage that made them, so the cost
+// of each helper shows up without every helper having to time its own calls.
+// Parameters:
+//   - helperPackage: the import path of the package the helpers are in.
+//
+// Return values:
+//   - the options to create the Firestore client with.
+func FirestoreDialOptions(helperPackage string) []grpc.DialOption {
+	prefix := helperPackage + "."
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			started := time.Now()
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			observeFirestore(callingHelper(prefix), method, started, err)
+			return err
+		}),
+		// Streams are queries and batch gets, timed until the stream is opened
+		grpc.WithChainStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			started := time.Now()
+			stream, err := streamer(ctx, desc, cc, method, opts...)
+			observeFirestore(callingHelper(prefix), method, started, err)
+			return stream, err
+		}),
+	}
+}
+
+// observeFirestore records a Firestore call.
+func observeFirestore(helper string, method string, started time.Time, err error) {
+	method = path.Base(method) // e.g. "/google.firestore.v1.Firestore/Commit" becomes "Commit"
+	FirestoreCalls.WithLabelValues(helper, method, Outcome(err)).Inc()
+	FirestoreCallDuration.WithLabelValues(helper, method).Observe(time.Since(started).Seconds())
+}
+
+// callingHelper finds the outermost function in the helpers package on the stack,
+// so a call made by a helper that another helper called is counted towards the one the endpoint used.
+func callingHelper(prefix string) string {
+	pcs := make([]uintptr, maxCallerDepth)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	helper := unknownHelper
+	for {
+		frame, more := frames.Next()
+		if name, ok := strings.CutPrefix(frame.Function, prefix); ok {
+			// Methods are named after their type, e.g. "(*Service).AddDonation"
+			if strings.HasPrefix(name, "(") {
+				_, name, _ = strings.Cut(name, ").")
+			}
+			// Closures are named after the function they're in, e.g. "AddDonation.func1"
+			helper, _, _ = strings.Cut(name, ".")
+		}
+		if !more {
+			return helper
+		}
+	}
+}
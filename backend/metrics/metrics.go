@@ -0,0 +1,151 @@
+// @cite "Prometheus Go client library." Pkg.go.dev, 2023. [Online].
+// Available: https://pkg.go.dev/github.com/prometheus/client_golang/prometheus. [Accessed: 19- October- 2026].
+// This is a file in the package-"metrics" that contains the Prometheus metrics of the backend.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace is put in front of every metric name
+const namespace = "relief_exchange"
+
+// Outcomes of a Firestore call or background job run
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+// What happened to a new donation
+const (
+	DonationCreated  = "created"
+	DonationMerged   = "merged"
+	DonationFlagged  = "flagged"
+	DonationRejected = "rejected"
+)
+
+// registry holds every metric of the backend, along with the Go runtime and process ones
+var registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts the requests handled by each route, by their status code
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled, by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	// HTTPRequestDuration is how long each route takes to respond
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to respond to HTTP requests, by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	// FirestoreCalls counts the calls made to Firestore by each helper
+	FirestoreCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "firestore_calls_total",
+		Help:      "Firestore RPCs made, by the helper that made them, RPC method and outcome.",
+	}, []string{"helper", "method", "outcome"})
+
+	// FirestoreCallDuration is how long the calls made to Firestore by each helper take
+	FirestoreCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "firestore_call_duration_seconds",
+		Help:      "Time taken by Firestore RPCs, by the helper that made them and RPC method.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"helper", "method"})
+
+	// Bans counts the users banned
+	Bans = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bans_total",
+		Help:      "Users banned.",
+	})
+
+	// Reports counts the reports made on donations
+	Reports = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reports_total",
+		Help:      "Reports made on donations.",
+	})
+
+	// Donations counts new donations, by what happened to them
+	Donations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "donations_total",
+		Help:      "New donations, by whether they were created, merged into a duplicate, flagged or rejected.",
+	}, []string{"outcome"})
+
+	// JobRuns counts the runs of each background job, by whether they failed
+	JobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "job_runs_total",
+		Help:      "Background job runs, by job and outcome.",
+	}, []string{"job", "outcome"})
+
+	// JobDuration is how long each background job takes to run
+	JobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_duration_seconds",
+		Help:      "Time taken by background job runs, by job.",
+		Buckets:   []float64{.1, .5, 1, 5, 15, 30, 60, 300, 900},
+	}, []string{"job"})
+
+	// JobLastSuccess is when each background job last ran without failing, so stuck jobs can be alerted on
+	JobLastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "job_last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful run of each background job.",
+	}, []string{"job"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		FirestoreCalls,
+		FirestoreCallDuration,
+		Bans,
+		Reports,
+		Donations,
+		JobRuns,
+		JobDuration,
+		JobLastSuccess,
+	)
+}
+
+// Handler serves every metric in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Outcome gets the outcome label of a call that returned err.
+func Outcome(err error) string {
+	if err != nil {
+		return OutcomeError
+	}
+	return OutcomeSuccess
+}
+
+// ObserveJob records a run of a background job.
+// Parameters:
+//   - job: the name of the job.
+//   - started: when the run started.
+//   - err: the error the run returned, if any.
+func ObserveJob(job string, started time.Time, err error) {
+	JobRuns.WithLabelValues(job, Outcome(err)).Inc()
+	JobDuration.WithLabelValues(job).Observe(time.Since(started).Seconds())
+	if err == nil {
+		JobLastSuccess.WithLabelValues(job).SetToCurrentTime()
+	}
+}
@@ -0,0 +1,113 @@
+// This is a file in the package-"middleware" that contains the Deadline middleware.
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"relief_exchange_backend/config"
+	"relief_exchange_backend/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatusClientClosedRequest is sent when the client went away before its request was handled.
+// It isn't a standard status, but it's what nginx logs in that case, so it stands out from real failures.
+const StatusClientClosedRequest = 499
+
+// Deadline gives every request a deadline, after which the Firestore and Auth calls made for it are cancelled.
+// The request's context is also cancelled if the client disconnects, so work nobody is waiting for stops.
+// If a handler fails after either, a 504 or 499 is sent instead of the error it responded with,
+// since e.g. a token that couldn't be verified in time isn't really unauthorized.
+// Parameters:
+//   - timeouts: how long each route can take.
+//
+// Return values:
+//   - the middleware.
+func Deadline(timeouts config.TimeoutsConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeouts.ForRoute(routeNames(c)...))
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Writer = &deadlineWriter{ResponseWriter: c.Writer, ctx: ctx}
+
+		c.Next()
+
+		// Handlers that gave up without responding still need to send something
+		if !c.Writer.Written() && ctx.Err() != nil {
+			c.Status(http.StatusInternalServerError)
+			c.Writer.WriteHeaderNow()
+		}
+	}
+}
+
+// deadlineStatus gets the status to send for a request whose context is done, or 0 if it isn't.
+func deadlineStatus(ctx context.Context) int {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(ctx.Err(), context.Canceled):
+		return StatusClientClosedRequest
+	default:
+		return 0
+	}
+}
+
+// deadlineWriter replaces errors sent after a request's context is done with a 504 or 499,
+// since the handler failed because it ran out of time rather than because of the request or a bug.
+type deadlineWriter struct {
+	gin.ResponseWriter
+	ctx      context.Context
+	replaced bool
+}
+
+// WriteHeader sets the status of the response, replacing errors caused by the deadline.
+func (w *deadlineWriter) WriteHeader(code int) {
+	if w.replaced {
+		return
+	}
+	if status := deadlineStatus(w.ctx); code >= http.StatusBadRequest && status != 0 {
+		w.replaced = true
+		logging.FromContext(w.ctx).WithField("status", status).Warn("request ran out of time: ", w.ctx.Err())
+
+		message := "The request took too long, please try again."
+		if status == StatusClientClosedRequest {
+			message = "The request was cancelled."
+		}
+		w.ResponseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.ResponseWriter.WriteHeader(status)
+		_, _ = w.ResponseWriter.WriteString(`{"error":"` + message + `"}`)
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// WriteHeaderNow makes sure the status is replaced before it's sent.
+func (w *deadlineWriter) WriteHeaderNow() {
+	if !w.Written() {
+		w.WriteHeader(w.Status())
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Write drops the handler's body if the response was replaced.
+func (w *deadlineWriter) Write(data []byte) (int, error) {
+	if !w.Written() {
+		w.WriteHeader(w.Status())
+	}
+	if w.replaced {
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString drops the handler's body if the response was replaced.
+func (w *deadlineWriter) WriteString(s string) (int, error) {
+	if !w.Written() {
+		w.WriteHeader(w.Status())
+	}
+	if w.replaced {
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
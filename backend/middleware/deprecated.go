@@ -0,0 +1,70 @@
+// @cite "The Deprecation HTTP Header Field." IETF, 2023. [Online].
+// Available: https://datatracker.ietf.org/doc/draft-ietf-httpapi-deprecation-header/. [Accessed: 19- October- 2026].
+// This is a file in the package-"middleware" that contains the middleware of the original routes, which /v1 replaced.
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routeKey is where the name of a request's route is kept in its gin context
+const routeKey = "relief_exchange_backend/route"
+
+// Aliases maps each original route to the /v1 route that replaced it, e.g. "POST /donations/new" to "POST /v1/donations".
+type Aliases map[string]string
+
+// Canonical names every request's route after the /v1 route it's an alias of, so the original routes
+// share rate limits and timeouts with the /v1 ones. It has to run before Deadline and RateLimit.
+// Return values:
+//   - the middleware.
+func (a Aliases) Canonical() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if successor, ok := a[c.Request.Method+" "+c.FullPath()]; ok {
+			c.Set(routeKey, successor)
+		}
+		c.Next()
+	}
+}
+
+// Route gets the name of the route a request was made to, e.g. "POST /v1/donations".
+// Requests to an original route are named after the /v1 route that replaced it.
+func Route(c *gin.Context) string {
+	if route := c.GetString(routeKey); route != "" {
+		return route
+	}
+	return c.Request.Method + " " + c.FullPath()
+}
+
+// routeNames gets the names a request's limits and timeouts can be configured under,
+// its route followed by the original route it was made to, if it was made to one.
+func routeNames(c *gin.Context) []string {
+	route := Route(c)
+	if registered := c.Request.Method + " " + c.FullPath(); registered != route {
+		return []string{route, registered}
+	}
+	return []string{route}
+}
+
+// Deprecated marks the responses of an original route as deprecated, with a link to the /v1 route that replaced it.
+// Parameters:
+//   - successor: the /v1 route, e.g. "PATCH /v1/donations/:id". Its parameters are filled in from the request's,
+//     and the link is left out if the original route doesn't have them all, e.g. when they were in the query.
+//
+// Return values:
+//   - the middleware.
+func Deprecated(successor string) gin.HandlerFunc {
+	_, path, _ := strings.Cut(successor, " ")
+	return func(c *gin.Context) {
+		link := path
+		for _, param := range c.Params {
+			link = strings.Replace(link, ":"+param.Key, param.Value, 1)
+		}
+		c.Header("Deprecation", "true")
+		if !strings.Contains(link, ":") {
+			c.Header("Link", "<"+link+`>; rel="successor-version"`)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,198 @@
+// @cite "Retry-After." MDN Web Docs, 2023. [Online].
+// Available: https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Retry-After. [Accessed: 19- October- 2026].
+// This is a file in the package-"middleware" that contains the RateLimit middleware.
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"relief_exchange_backend/logging"
+	"strconv"
+	"strings"
+	"time"
+
+	"firebase.google.com/go/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// TokenVerifier checks the Authorization header of a request, e.g. *helpers.Service.
+type TokenVerifier interface {
+	VerifyAuthHeader(ctx context.Context, authHeader string) (*auth.Token, error)
+}
+
+// maxTokenPeekBytes is the most of a JSON body read to find the token of a request
+const maxTokenPeekBytes = 64 << 10
+
+// Limit is how many requests can be made to a route.
+// Up to Burst requests can be made at once, and after that Requests every Per.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+// rate gets the number of tokens added to a bucket per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// UnmarshalJSON reads a limit, with Per written as a duration like "1m".
+func (l *Limit) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Requests int    `json:"requests"`
+		Per      string `json:"per"`
+		Burst    int    `json:"burst"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	per, err := time.ParseDuration(raw.Per)
+	if err != nil {
+		return fmt.Errorf("invalid per %q: %w", raw.Per, err)
+	}
+	*l = Limit{Requests: raw.Requests, Per: per, Burst: raw.Burst}
+	if l.Burst == 0 {
+		l.Burst = l.Requests
+	}
+	return l.validate()
+}
+
+// validate checks that a limit lets requests through at all.
+func (l Limit) validate() error {
+	if l.Requests <= 0 || l.Per <= 0 || l.Burst <= 0 {
+		return errors.New("requests, per and burst must all be positive")
+	}
+	return nil
+}
+
+// RateLimits are the limits applied to every route.
+// Routes are written as the method and path they're registered with, e.g. "POST /v1/donations".
+// The original routes share the limits of the /v1 routes that replaced them, but can still be given their own.
+type RateLimits struct {
+	Default Limit            `json:"default"`
+	Routes  map[string]Limit `json:"routes"`
+}
+
+// DefaultRateLimits are used when there's no rate limits file.
+var DefaultRateLimits = RateLimits{
+	Default: Limit{Requests: 120, Per: time.Minute, Burst: 60},
+}
+
+// forRoute gets the limit of the first of a route's names that has one, falling back to the default one.
+func (l RateLimits) forRoute(routes ...string) Limit {
+	for _, route := range routes {
+		if limit, ok := l.Routes[route]; ok {
+			return limit
+		}
+	}
+	return l.Default
+}
+
+// LoadRateLimits reads the rate limits from a JSON file.
+// Parameters:
+//   - path: the path of the file.
+//
+// Return values:
+//   - the limits in the file.
+//   - error, if the file couldn't be read or has invalid limits.
+func LoadRateLimits(path string) (RateLimits, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return RateLimits{}, fmt.Errorf("failed reading rate limits: %w", err)
+	}
+	var limits RateLimits
+	if err = json.Unmarshal(data, &limits); err != nil {
+		return RateLimits{}, fmt.Errorf("failed parsing rate limits: %w", err)
+	}
+	if err = limits.Default.validate(); err != nil {
+		return RateLimits{}, fmt.Errorf("invalid default rate limit: %w", err)
+	}
+	return limits, nil
+}
+
+// RateLimit limits how often each client can call each route, using a token bucket per route
+// for their IP address and another for their UID when they're signed in. A request has to get
+// a token from both, so one user can't get around their limit by switching networks, and
+// a script can't by signing up new accounts.
+// Requests over the limit get a 429 with a Retry-After header.
+// Parameters:
+//   - store: where the token buckets are kept.
+//   - limits: the limit of every route.
+//   - verifier: checks the tokens of signed in users.
+//
+// Return values:
+//   - the middleware.
+func RateLimit(store RateLimitStore, limits RateLimits, verifier TokenVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := Route(c)
+		limit := limits.forRoute(routeNames(c)...)
+		if c.FullPath() == "" {
+			// Unknown routes 404 anyway, so they share a single bucket per client
+			route = c.Request.Method + " *"
+			limit = limits.forRoute(route)
+		}
+
+		keys := []string{"ip:" + c.ClientIP() + ":" + route}
+		if uid := requestUID(c, verifier); uid != "" {
+			keys = append(keys, "uid:"+uid+":"+route)
+		}
+
+		for _, key := range keys {
+			allowed, retryAfter, err := store.Take(c.Request.Context(), key, limit)
+			if err != nil {
+				// Don't take the whole site down because the store is unavailable
+				logging.FromContext(c.Request.Context()).WithError(err).Error("rate limit store failed")
+				continue
+			}
+			if !allowed {
+				seconds := int(math.Ceil(retryAfter.Seconds()))
+				if seconds < 1 {
+					seconds = 1
+				}
+				c.Header("Retry-After", strconv.Itoa(seconds))
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, please try again later."})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// requestUID gets the UID of the user who sent a request, or an empty string if it isn't signed in.
+// The token is taken from the Authorization header, or from the "token" field of a JSON body
+// for the endpoints that send it there. The body is put back so the handler can still read it.
+func requestUID(c *gin.Context, verifier TokenVerifier) string {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" && strings.HasPrefix(c.ContentType(), "application/json") && c.Request.Body != nil {
+		data, err := io.ReadAll(io.LimitReader(c.Request.Body, maxTokenPeekBytes))
+		c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), c.Request.Body))
+		if err != nil {
+			return ""
+		}
+		var body struct {
+			Token string `json:"token"`
+		}
+		if json.Unmarshal(data, &body) != nil || body.Token == "" {
+			return ""
+		}
+		authHeader = "Bearer " + body.Token
+	}
+	if authHeader == "" {
+		return ""
+	}
+
+	token, err := verifier.VerifyAuthHeader(c.Request.Context(), authHeader)
+	if err != nil {
+		// The handler rejects the request itself, so it's only limited by IP
+		return ""
+	}
+	return token.UID
+}
@@ -0,0 +1,85 @@
+// @cite "Token bucket." Wikipedia, 2023. [Online].
+// Available: https://en.wikipedia.org/wiki/Token_bucket. [Accessed: 19- October- 2026].
+// This is a file in the package-"middleware" that contains the stores used by the rate limiter.
+package middleware
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimitStore keeps the token buckets of the rate limiter.
+// The in-memory store only limits a single instance, so deployments running several
+// instances can plug in a shared store (e.g. Redis) by implementing this interface.
+type RateLimitStore interface {
+	// Take removes a token from the bucket with the given key, refilling it according to limit first.
+	// It returns whether a token was available, and if not, how long until one will be.
+	Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+}
+
+// bucket is the state of a single token bucket.
+type bucket struct {
+	tokens   float64
+	updated  time.Time
+	capacity float64 // Largest number of tokens it can hold
+	rate     float64 // Tokens added per second
+}
+
+// memorySweepInterval is how often idle buckets are removed from a MemoryStore
+const memorySweepInterval = 10 * time.Minute
+
+// MemoryStore is a RateLimitStore that keeps its buckets in memory.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, now: time.Now}
+}
+
+// Take removes a token from the bucket with the given key, see RateLimitStore.
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	rate := limit.rate()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	} else {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*rate)
+		b.updated = now
+	}
+	b.capacity, b.rate = float64(limit.Burst), rate
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+	wait := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	return false, wait, nil
+}
+
+// sweep removes buckets that have been idle long enough to be full again,
+// since they behave the same as buckets that don't exist yet.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < memorySweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*b.rate >= b.capacity {
+			delete(s.buckets, key)
+		}
+	}
+}
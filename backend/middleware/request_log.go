@@ -0,0 +1,86 @@
+// This is a file in the package-"middleware" that contains the middleware giving each request an ID and a logger.
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader is the header a request's ID is read from and sent back in
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID matches request IDs that can be taken from a proxy in front of the backend.
+// Anything else is replaced, so clients can't put arbitrary text into the logs.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestLogger gives every request an ID and a logger that tags each line with it, which
+// helpers get from the request's context. A line is logged for each request once it's handled,
+// with its route, status, latency and the UID of its user if they signed in.
+// Parameters:
+//   - logger: the logger the request's lines are written to.
+//
+// Return values:
+//   - the middleware.
+func RequestLogger(logger *log.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		c.Header(RequestIDHeader, requestID)
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		fields := log.Fields{
+			"request_id": requestID,
+			"method":     c.Request.Method,
+			"route":      route,
+		}
+		if spanContext := trace.SpanContextFromContext(c.Request.Context()); spanContext.HasTraceID() {
+			fields["trace_id"] = spanContext.TraceID().String()
+		}
+		tracing.SetAttributes(c.Request.Context(), attribute.String("relief_exchange.request_id", requestID))
+
+		ctx := logging.NewContext(c.Request.Context(), logger.WithFields(fields))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		entry := logging.FromContext(ctx).WithFields(log.Fields{
+			"status":     c.Writer.Status(),
+			"latency_ms": time.Since(started).Milliseconds(),
+			"client_ip":  c.ClientIP(),
+		})
+		switch status := c.Writer.Status(); {
+		case status >= 500:
+			entry.Error("request failed")
+		case status >= 400:
+			entry.Warn("request rejected")
+		default:
+			entry.Info("request handled")
+		}
+	}
+}
+
+// newRequestID makes a random request ID.
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		// Only happens if the system's random source is broken, and the time is still unique enough to search logs by
+		return time.Now().UTC().Format("20060102T150405.000000000")
+	}
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,136 @@
+// @cite "openapi3filter." Pkg.go.dev, 2023. [Online].
+// Available: https://pkg.go.dev/github.com/getkin/kin-openapi/openapi3filter. [Accessed: 19- October- 2026].
+// This is a file in the package-"middleware" that contains the ValidateRequest middleware.
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"relief_exchange_backend/logging"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gin-gonic/gin"
+)
+
+// openAPIPathParam matches the parameters of an OpenAPI path, e.g. "{id}"
+var openAPIPathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// GinPath converts an OpenAPI path to the path gin registers the route with, e.g. "/donations/{id}" to "/donations/:id".
+func GinPath(openAPIPath string) string {
+	return openAPIPathParam.ReplaceAllString(openAPIPath, ":$1")
+}
+
+// ValidateRequest rejects requests that don't match the OpenAPI document with a 400,
+// before they reach the handler. Only JSON bodies are checked, since uploads are checked
+// by their handler as they're read, without holding the whole body in memory first.
+// Bodies sent to operations taking JSON have to be sent as JSON, or they're rejected with a 415,
+// since the handlers decode them whatever their Content-Type and they'd skip the checks.
+// Tokens are verified by the handlers, so only their presence is described by the document.
+// Routes the document doesn't have, like uploaded files, aren't checked.
+// Parameters:
+//   - doc: the OpenAPI document.
+//
+// Return values:
+//   - the middleware.
+func ValidateRequest(doc *openapi3.T) gin.HandlerFunc {
+	// Look routes up the way gin names them, e.g. "GET /donations/:id"
+	operations := make(map[string]*routers.Route)
+	for path, pathItem := range doc.Paths {
+		for method, operation := range pathItem.Operations() {
+			operations[method+" "+GinPath(path)] = &routers.Route{
+				Spec:      doc,
+				Path:      path,
+				PathItem:  pathItem,
+				Method:    method,
+				Operation: operation,
+			}
+		}
+	}
+
+	return func(c *gin.Context) {
+		route, ok := operations[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		isJSON := c.ContentType() == gin.MIMEJSON
+		takesJSON := takesJSONBody(route.Operation)
+		if takesJSON && !isJSON && hasBody(c.Request) {
+			c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be " + gin.MIMEJSON})
+			return
+		}
+
+		pathParams := make(map[string]string, len(c.Params))
+		for _, param := range c.Params {
+			pathParams[param.Key] = param.Value
+		}
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				// Missing JSON bodies are still checked, so a required one has to be sent
+				ExcludeRequestBody:         !isJSON && !takesJSON,
+				ExcludeReadOnlyValidations: true, // Clients send donations back as they got them
+				SkipSettingDefaults:        true,
+				AuthenticationFunc:         openapi3filter.NoopAuthenticationFunc,
+			},
+		}
+		if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+			logging.FromContext(c.Request.Context()).WithField("route", route.Method+" "+route.Path).Info("invalid request: ", err)
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": validationMessage(err)})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// takesJSONBody checks whether an operation's request body is JSON.
+func takesJSONBody(operation *openapi3.Operation) bool {
+	return operation.RequestBody != nil && operation.RequestBody.Value != nil &&
+		operation.RequestBody.Value.Content.Get(gin.MIMEJSON) != nil
+}
+
+// hasBody checks whether a request was sent with a body, including one whose length isn't known.
+func hasBody(req *http.Request) bool {
+	return req.ContentLength != 0
+}
+
+// validationMessage describes why a request doesn't match the document, without the schema it was checked against.
+func validationMessage(err error) string {
+	var requestErr *openapi3filter.RequestError
+	if !errors.As(err, &requestErr) {
+		return err.Error()
+	}
+	var schemaErr *openapi3.SchemaError
+	if errors.As(requestErr.Err, &schemaErr) {
+		reason := schemaErr.Reason
+		if reason == "" && schemaErr.Origin != nil {
+			reason = schemaErr.Origin.Error() // e.g. a parameter that isn't a number
+		}
+		// Formats are checked with a regular expression that doesn't help anyone fix their request
+		reason, _, _ = strings.Cut(reason, " (regular expression")
+
+		field := strings.Join(schemaErr.JSONPointer(), ".")
+		switch {
+		case requestErr.Parameter != nil && field != "":
+			return "parameter " + requestErr.Parameter.Name + "." + field + ": " + reason
+		case requestErr.Parameter != nil:
+			return "parameter " + requestErr.Parameter.Name + ": " + reason
+		case field != "":
+			return "body." + field + ": " + reason
+		default:
+			return "body: " + reason
+		}
+	}
+	if requestErr.Parameter != nil && requestErr.Err != nil {
+		return "parameter " + requestErr.Parameter.Name + ": " + requestErr.Err.Error() // e.g. a number that couldn't be parsed
+	}
+	return requestErr.Error()
+}
@@ -0,0 +1,74 @@
+// @cite "OpenAPI Specification v3.0.3." OpenAPI Initiative, 2020. [Online].
+// Available: https://spec.openapis.org/oas/v3.0.3. [Accessed: 19- October- 2026].
+// @cite "Swagger UI Installation." SmartBear, 2023. [Online].
+// Available: https://swagger.io/docs/open-source-tools/swagger-ui/usage/installation/. [Accessed: 19- October- 2026].
+// This is a file in the package-"openapi" that contains the OpenAPI document of the backend and the page for browsing it.
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// SpecPath and DocsPath are where the document and the page for browsing it are served
+const (
+	DocsPath = "/docs"
+	SpecPath = DocsPath + "/openapi.yaml"
+)
+
+// spec is the OpenAPI document of every route, which requests are validated against
+//
+//go:embed openapi.yaml
+var spec []byte
+
+// Load parses the OpenAPI document and checks that it's valid.
+// Return values:
+//   - the document.
+//   - error, if it isn't valid.
+func Load() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing OpenAPI document: %w", err)
+	}
+	if err = doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	return doc, nil
+}
+
+// ServeSpec sends the OpenAPI document as YAML.
+func ServeSpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	_, _ = w.Write(spec)
+}
+
+// ServeDocs sends a page for browsing the OpenAPI document, using Swagger UI.
+func ServeDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = fmt.Fprintf(w, docsPage, SpecPath)
+}
+
+// docsPage loads Swagger UI from a CDN, so it doesn't have to be bundled with the backend
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<title>Relief Exchange API</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5.9.0/swagger-ui.css">
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@5.9.0/swagger-ui-bundle.js" crossorigin></script>
+	<script>
+		window.onload = () => {
+			window.ui = SwaggerUIBundle({ url: %q, dom_id: "#swagger-ui" });
+		};
+	</script>
+</body>
+</html>
+`
@@ -0,0 +1,106 @@
+// This is a file in the package-"server" that contains the connections to Firebase.
+package server
+
+import (
+	"context"
+	"fmt"
+	"relief_exchange_backend/config"
+	"relief_exchange_backend/metrics"
+	"relief_exchange_backend/storage"
+	"relief_exchange_backend/tracing"
+
+	"cloud.google.com/go/firestore"
+	firebase "firebase.google.com/go"
+	"firebase.google.com/go/auth"
+	"google.golang.org/api/option"
+)
+
+// Firebase holds the connections to the Firebase project.
+// App is the Firebase application, Firestore and Auth are clients for Firestore and authentication respectively.
+// Images is where uploaded images are kept, and Exports is where personal data exports are kept.
+type Firebase struct {
+	App       *firebase.App
+	Firestore *firestore.Client
+	Auth      *auth.Client
+	Images    storage.Store
+	Exports   storage.Store
+}
+
+// ConnectFirebase sets up all Firebase connections.
+// Requests pass their own context to each call, so ctx is only used while connecting.
+// Parameters:
+//   - ctx: the context used while connecting.
+//   - cfg: the settings the backend was started with.
+//
+// Return values:
+//   - the connections, whose Firestore client has to be closed once it's no longer used.
+//   - error, if any of them couldn't be set up.
+func ConnectFirebase(ctx context.Context, cfg config.Config) (*Firebase, error) {
+	// Import Firebase credentials
+	var options []option.ClientOption
+	if cfg.Firebase.CredentialsFile != "" {
+		options = append(options, option.WithCredentialsFile(cfg.Firebase.CredentialsFile))
+	} else if cfg.Firebase.CredentialsJSON != "" {
+		options = append(options, option.WithCredentialsJSON([]byte(cfg.Firebase.CredentialsJSON)))
+	}
+	// Otherwise Google's application default credentials are used
+
+	// Set up Firebase
+	app, err := firebase.NewApp(ctx, &firebase.Config{
+		StorageBucket: cfg.Firebase.StorageBucket,
+	}, options...)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing Firebase app: %w", err)
+	}
+	fb := &Firebase{App: app}
+
+	// Set up Firestore, measuring and tracing every call it makes
+	firestoreOptions := append([]option.ClientOption{}, options...)
+	dialOptions := append(metrics.FirestoreDialOptions("relief_exchange_backend/helpers"), tracing.FirestoreDialOptions()...)
+	for _, dialOption := range dialOptions {
+		firestoreOptions = append(firestoreOptions, option.WithGRPCDialOption(dialOption))
+	}
+	fb.Firestore, err = firestore.NewClient(ctx, firestore.DetectProjectID, firestoreOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing Firestore client: %w", err)
+	}
+
+	// Set up Firebase Auth
+	fb.Auth, err = app.Auth(ctx)
+	if err != nil {
+		fb.Firestore.Close()
+		return nil, fmt.Errorf("error initializing Firebase Auth client: %w", err)
+	}
+
+	// Set up image and export storage, using Firebase Storage if a bucket is given and the local disk otherwise.
+	// Only the bucket is shared by every instance, so exports on the local disk can only be downloaded from the
+	// instance that made them.
+	if bucketName := cfg.Firebase.StorageBucket; bucketName != "" {
+		storageClient, err := app.Storage(ctx)
+		if err != nil {
+			fb.Firestore.Close()
+			return nil, fmt.Errorf("error initializing Firebase Storage client: %w", err)
+		}
+		bucket, err := storageClient.DefaultBucket()
+		if err != nil {
+			fb.Firestore.Close()
+			return nil, fmt.Errorf("error getting Firebase Storage bucket: %w", err)
+		}
+		fb.Images = storage.NewFirebaseStore(bucket, bucketName)
+		fb.Exports = fb.Images
+	} else {
+		fb.Images, err = storage.NewLocalStore(cfg.Storage.LocalDir, "/uploads")
+		if err != nil {
+			fb.Firestore.Close()
+			return nil, fmt.Errorf("error initializing local storage: %w", err)
+		}
+		// Exports aren't served like uploads, they're only sent to their owner
+		fb.Exports, err = storage.NewLocalStore(cfg.Storage.ExportDir, "")
+		if err != nil {
+			fb.Firestore.Close()
+			return nil, fmt.Errorf("error initializing local export storage: %w", err)
+		}
+	}
+
+	return fb, nil
+}
@@ -0,0 +1,131 @@
+// @file jobs.go contains the background jobs that run alongside the web server
+package server
+
+import (
+	"context"
+	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/metrics"
+	"relief_exchange_backend/tracing"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Job is a background job. The Server runs each one on its own, and they can also be run by hand with reliefctl.
+type Job struct {
+	Name     string
+	Interval time.Duration // How often it runs, or 0 if it only runs once when the Server starts
+	Run      func(ctx context.Context, service *helpers.Service) error
+}
+
+// Jobs are the background jobs of every Server.
+// Schema migrations aren't one of them, since they change data and should only run when an operator
+// has checked them with a dry run, using reliefctl migrate.
+var Jobs = []Job{
+	{Name: "auth profile sync", Interval: 6 * time.Hour, Run: func(ctx context.Context, service *helpers.Service) error {
+		synced, err := service.SyncAuthProfiles(ctx)
+		logging.FromContext(ctx).WithField("synced", synced).Info("auth profile sync finished")
+		return err
+	}},
+	{Name: "data exports", Interval: 5 * time.Minute, Run: func(ctx context.Context, service *helpers.Service) error {
+		return service.ProcessPendingDataExports(ctx)
+	}},
+	{Name: "account deletions", Interval: time.Minute, Run: func(ctx context.Context, service *helpers.Service) error {
+		return service.ProcessPendingAccountDeletions(ctx)
+	}},
+}
+
+// BackgroundJobs are the background jobs of a Server once they've been started.
+type BackgroundJobs struct {
+	stop   context.CancelFunc // Stops new runs from starting
+	cancel context.CancelFunc // Cancels the runs in progress
+	wg     sync.WaitGroup
+}
+
+// StartBackgroundJobs starts every background job of the Server, which run until they're shut down.
+// Cancelling ctx stops them straight away, cancelling any runs in progress.
+func (s *Server) StartBackgroundJobs(ctx context.Context) *BackgroundJobs {
+	jobs := &BackgroundJobs{}
+	runCtx, cancel := context.WithCancel(ctx)
+	scheduleCtx, stop := context.WithCancel(runCtx)
+	jobs.stop, jobs.cancel = stop, cancel
+
+	for _, job := range Jobs {
+		job := job
+		jobs.wg.Add(1)
+		go func() {
+			defer jobs.wg.Done()
+			run := func(ctx context.Context) error {
+				return job.Run(ctx, s.service)
+			}
+			if job.Interval == 0 {
+				if err := s.runJob(runCtx, job.Name, run); err != nil {
+					s.deps.Logger.WithField("job", job.Name).Error(err.Error())
+				}
+				return
+			}
+			s.runPeriodically(scheduleCtx, runCtx, job.Name, job.Interval, run)
+		}()
+	}
+	return jobs
+}
+
+// Shutdown stops new runs of the jobs from starting, then waits for the runs in progress to finish.
+// If ctx is done first, the runs in progress are cancelled, and whatever they didn't get to is picked up
+// on the next start. That's safe since every job claims its work and resumes where it stopped.
+// Return values:
+//   - ctx's error if the runs in progress had to be cancelled, nil otherwise.
+func (j *BackgroundJobs) Shutdown(ctx context.Context) error {
+	j.stop()
+	done := make(chan struct{})
+	go func() {
+		j.wg.Wait()
+		close(done)
+	}()
+
+	defer j.cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runPeriodically runs a job on an interval until scheduleCtx is cancelled.
+// Each run gets a context from runCtx, so a run in progress keeps going after scheduling stops.
+// Failures are logged and the job is tried again on the next tick.
+func (s *Server) runPeriodically(scheduleCtx context.Context, runCtx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-scheduleCtx.Done():
+			return
+		case <-ticker.C:
+			// Both can be ready at once, and select picks either
+			if scheduleCtx.Err() != nil {
+				return
+			}
+			if err := s.runJob(runCtx, name, job); err != nil {
+				s.deps.Logger.WithField("job", name).Error(err.Error())
+			}
+		}
+	}
+}
+
+// runJob runs a job once, in its own trace, and records how it went.
+func (s *Server) runJob(ctx context.Context, name string, job func(ctx context.Context) error) error {
+	ctx = s.logContext(ctx, log.Fields{"job": name})
+	ctx, span := tracing.Start(ctx, "job "+name)
+	defer span.End()
+
+	started := time.Now()
+	err := job(ctx)
+	metrics.ObserveJob(name, started, err)
+	tracing.RecordError(span, err)
+	return err
+}
@@ -0,0 +1,148 @@
+// @cite [1] Stack Overflow.(2015). "Go Gin framework CORS," Stack Overflow [Online].
+// Available: https://stackoverflow.com/questions/29418478/go-gin-framework-cors.  [Accessed: 16-May-2023].
+// This is a file in the package-"server" that contains the middleware and routes of the Server.
+package server
+
+import (
+	endpointsPost "relief_exchange_backend/endpoints/post"
+	"relief_exchange_backend/metrics"
+	"relief_exchange_backend/middleware"
+	"relief_exchange_backend/openapi"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// setupRoutes creates the Server's router, with its middleware and every endpoint.
+func (s *Server) setupRoutes() error {
+	cfg := s.config
+
+	// Requests are logged by the request logger, so gin's own logger isn't used
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(otelgin.Middleware(cfg.Tracing.ServiceName))
+	r.Use(middleware.RequestLogger(s.deps.Logger))
+	r.Use(middleware.Metrics())
+
+	// Health checks are registered before the other middleware, so they're never rate limited
+	r.GET("/healthz", s.get.GetHealth)
+	r.GET("/readyz", s.get.GetReadiness)
+
+	// Metrics are served behind a token on the main listener when they don't have their own
+	if cfg.Metrics.Address == "" && cfg.Metrics.Token != "" {
+		r.GET("/metrics", middleware.RequireToken(cfg.Metrics.Token), gin.WrapH(metrics.Handler()))
+	}
+
+	// Set up CORS middleware for all requests
+	//citations.txt: [2]
+	r.Use(cors.New(cors.Config{
+		AllowOrigins:     cfg.CORS.AllowedOrigins,
+		AllowMethods:     []string{"GET", "POST", "PATCH", "DELETE"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "traceparent", "tracestate", middleware.RequestIDHeader, endpointsPost.CAPTCHAHeader},
+		ExposeHeaders:    []string{"Content-Length", "Retry-After", "Deprecation", "Link", middleware.RequestIDHeader},
+		AllowCredentials: true,
+		MaxAge:           12 * time.Hour,
+	}))
+
+	// Only trust X-Forwarded-For from known proxies, otherwise clients could pick their own IP
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return err
+	}
+
+	// Give up on requests that take too long or whose client went away, and rate limit the rest.
+	// The original routes share their limits and timeouts with the /v1 routes that replaced them.
+	s.aliases = middleware.Aliases{}
+	r.Use(s.aliases.Canonical())
+	r.Use(middleware.Deadline(cfg.Timeouts))
+	r.Use(middleware.RateLimit(s.deps.RateLimitStore, s.deps.RateLimits, s.service))
+
+	// Reject requests that don't match the OpenAPI document, which can be browsed at /docs
+	doc, err := openapi.Load()
+	if err != nil {
+		return err
+	}
+	r.Use(middleware.ValidateRequest(doc))
+	r.GET(openapi.DocsPath, gin.WrapF(openapi.ServeDocs))
+	r.GET(openapi.SpecPath, gin.WrapF(openapi.ServeSpec))
+
+	// Set up the /v1 endpoints
+	v1 := r.Group("/v1")
+	v1.GET("/donations", s.get.GetDonationsList)
+	v1.POST("/donations", s.post.AddDonationV1)
+	v1.GET("/donations/:id", s.get.GetDonationByID)
+	v1.PATCH("/donations/:id", s.post.EditDonationV1)
+	v1.DELETE("/donations/:id", s.post.DeleteDonation)
+	v1.POST("/donations/:id/reports", s.post.ReportDonationV1)
+	v1.POST("/donations/:id/images", s.post.AddDonationImage)
+	v1.PATCH("/donations/:id/images", s.post.ReorderDonationImages)
+	v1.PATCH("/donations/:id/images/:imageId", s.post.SetDonationImageAlt)
+	v1.DELETE("/donations/:id/images/:imageId", s.post.RemoveDonationImage)
+	v1.POST("/images", s.post.UploadImage)
+	v1.POST("/users", s.post.AddUserV1)
+	v1.GET("/users/:id", s.get.GetUserDataByID)
+	v1.GET("/users/:id/donations", s.get.GetUserDonations)
+	v1.GET("/users/:id/banned", s.get.GetIfBannedByID)
+	v1.GET("/users/:id/admin", s.get.GetIfAdminByID)
+	v1.POST("/users/:id/ban", s.post.BanUserV1)
+	v1.POST("/users/:id/roles", s.post.GrantRoleV1)
+	v1.DELETE("/users/:id/roles/:role", s.post.RevokeRoleV1)
+	v1.GET("/me", s.get.GetMe)
+	v1.PATCH("/me", s.post.UpdateProfileV1)
+	v1.DELETE("/me", s.post.DeleteUserV1)
+	v1.PATCH("/me/privacy", s.post.SetPublicFieldsV1)
+	v1.POST("/me/exports", s.post.RequestDataExportV1)
+	v1.GET("/me/exports/:id", s.get.GetDataExport)
+	v1.GET("/me/exports/:id/download", s.get.DownloadDataExport)
+	v1.GET("/account-deletions/:id", s.get.GetAccountDeletion)
+	v1.GET("/admin/users", s.get.GetPrivilegedUsers)
+	v1.POST("/captcha/verify", s.post.ValidateCAPTCHAToken)
+
+	// Keep the original endpoints working for clients that haven't moved to /v1 yet
+	legacy := func(method string, path string, successor string, handler gin.HandlerFunc) {
+		s.aliases[method+" "+path] = successor
+		r.Handle(method, path, middleware.Deprecated(successor), handler)
+	}
+
+	// Set up all original GET endpoints
+	legacy("GET", "/donations/list", "GET /v1/donations", s.get.GetDonationsList)
+	legacy("GET", "/donations/:id", "GET /v1/donations/:id", s.get.GetDonationByID)
+	legacy("GET", "/users/:id", "GET /v1/users/:id", s.get.GetUserDataByID)
+	legacy("GET", "/users/:id/donations", "GET /v1/users/:id/donations", s.get.GetUserDonations)
+	legacy("GET", "/users/banned", "GET /v1/users/:id/banned", s.get.GetIfBanned)
+	legacy("GET", "/users/admin", "GET /v1/users/:id/admin", s.get.GetIfAdmin)
+	legacy("GET", "/me", "GET /v1/me", s.get.GetMe)
+	legacy("GET", "/users/export/:id", "GET /v1/me/exports/:id", s.get.GetDataExport)
+	legacy("GET", "/users/export/:id/download", "GET /v1/me/exports/:id/download", s.get.DownloadDataExport)
+	legacy("GET", "/users/delete/:id", "GET /v1/account-deletions/:id", s.get.GetAccountDeletion)
+	legacy("GET", "/admin/users", "GET /v1/admin/users", s.get.GetPrivilegedUsers)
+
+	// Set up all original POST endpoints
+	legacy("POST", "/confirmCAPTCHA", "POST /v1/captcha/verify", s.post.ValidateCAPTCHAToken)
+	legacy("POST", "/donations/new", "POST /v1/donations", s.post.AddDonation)
+	legacy("POST", "/users/new", "POST /v1/users", s.post.AddUser)
+	legacy("POST", "/users/delete", "DELETE /v1/me", s.post.DeleteUser)
+	legacy("POST", "/users/ban", "POST /v1/users/:id/ban", s.post.BanUser)
+	legacy("POST", "/users/privacy", "PATCH /v1/me/privacy", s.post.SetPublicFields)
+	legacy("POST", "/users/profile", "PATCH /v1/me", s.post.UpdateProfile)
+	legacy("POST", "/users/export", "POST /v1/me/exports", s.post.RequestDataExport)
+	legacy("POST", "/images", "POST /v1/images", s.post.UploadImage)
+	legacy("POST", "/donations/report", "POST /v1/donations/:id/reports", s.post.ReportDonation)
+	legacy("POST", "/donations/edit", "PATCH /v1/donations/:id", s.post.EditDonation)
+	legacy("POST", "/donations/:id/delete", "DELETE /v1/donations/:id", s.post.DeleteDonation)
+	legacy("POST", "/donations/:id/images", "POST /v1/donations/:id/images", s.post.AddDonationImage)
+	legacy("POST", "/donations/:id/images/order", "PATCH /v1/donations/:id/images", s.post.ReorderDonationImages)
+	legacy("POST", "/donations/:id/images/:imageId/alt", "PATCH /v1/donations/:id/images/:imageId", s.post.SetDonationImageAlt)
+	legacy("POST", "/donations/:id/images/:imageId/delete", "DELETE /v1/donations/:id/images/:imageId", s.post.RemoveDonationImage)
+	legacy("POST", "/admin/roles/grant", "POST /v1/users/:id/roles", s.post.GrantRole)
+	legacy("POST", "/admin/roles/revoke", "DELETE /v1/users/:id/roles/:role", s.post.RevokeRole)
+
+	// Serve uploaded images from disk when they aren't stored in Firebase Storage
+	if cfg.Firebase.StorageBucket == "" {
+		r.Static("/uploads", cfg.Storage.LocalDir)
+	}
+
+	s.router = r
+	return nil
+}
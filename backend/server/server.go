@@ -0,0 +1,110 @@
+// This is a file in the package-"server" that contains the Server, which holds everything the backend runs with.
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"relief_exchange_backend/captcha"
+	"relief_exchange_backend/config"
+	endpointsGet "relief_exchange_backend/endpoints/get"
+	endpointsPost "relief_exchange_backend/endpoints/post"
+	"relief_exchange_backend/helpers"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/middleware"
+	"relief_exchange_backend/storage"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// Deps is everything a Server reads from, writes to or calls.
+type Deps struct {
+	Firestore      *firestore.Client
+	Auth           helpers.AuthClient // Also verifies ID tokens
+	Images         storage.Store
+	Exports        storage.Store
+	CAPTCHA        captcha.Verifier
+	RateLimitStore middleware.RateLimitStore
+	RateLimits     middleware.RateLimits
+	Clock          helpers.Clock
+	Logger         *log.Logger
+}
+
+// Server is an instance of the backend. It shares nothing with other Servers except the
+// Prometheus metrics and OpenTelemetry tracer, which are process wide, so several can run
+// in one process, e.g. one per test.
+type Server struct {
+	config  config.Config
+	service *helpers.Service
+	get     *endpointsGet.Handlers
+	post    *endpointsPost.Handlers
+	deps    Deps
+	router  *gin.Engine
+	aliases middleware.Aliases // The original routes and the /v1 routes that replaced them
+	tasks   *tasks
+}
+
+// New creates a Server and sets up its routes.
+// Parameters:
+//   - cfg: the settings the backend was started with.
+//   - deps: everything the Server uses. The clock, logger and rate limits default to the system clock,
+//     the standard logger and an in-memory store with DefaultRateLimits.
+//
+// Return values:
+//   - the Server.
+//   - error, if the config can't be used to set up the routes.
+func New(cfg config.Config, deps Deps) (*Server, error) {
+	if deps.Clock == nil {
+		deps.Clock = helpers.SystemClock{}
+	}
+	if deps.Logger == nil {
+		deps.Logger = log.StandardLogger()
+	}
+	if deps.RateLimitStore == nil {
+		deps.RateLimitStore = middleware.NewMemoryStore()
+	}
+	if deps.RateLimits.Default == (middleware.Limit{}) {
+		deps.RateLimits = middleware.DefaultRateLimits
+	}
+
+	service := helpers.NewService(deps.Firestore, deps.Auth, deps.Images, deps.Exports, cfg, deps.Clock, deps.Logger)
+	s := &Server{
+		config:  cfg,
+		service: service,
+		get:     endpointsGet.NewHandlers(service),
+		deps:    deps,
+		tasks:   newTasks(),
+	}
+	s.post = endpointsPost.NewHandlers(service, deps.CAPTCHA, s.runTask)
+	if err := s.setupRoutes(); err != nil {
+		return nil, fmt.Errorf("failed setting up routes: %w", err)
+	}
+	return s, nil
+}
+
+// Handler gets the handler serving every route of the Server.
+func (s *Server) Handler() http.Handler {
+	return s.router
+}
+
+// Routes gets every route the Server handles.
+func (s *Server) Routes() gin.RoutesInfo {
+	return s.router.Routes()
+}
+
+// Service gets the helpers the Server's endpoints call.
+func (s *Server) Service() *helpers.Service {
+	return s.service
+}
+
+// MarkShuttingDown makes the readiness endpoint fail from now on, so no new traffic is sent to the Server.
+func (s *Server) MarkShuttingDown() {
+	s.get.MarkShuttingDown()
+}
+
+// logContext gets a context carrying the Server's logger, for work that isn't part of a request.
+func (s *Server) logContext(ctx context.Context, fields log.Fields) context.Context {
+	return logging.NewContext(ctx, s.deps.Logger.WithFields(fields))
+}
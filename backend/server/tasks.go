@@ -0,0 +1,86 @@
+// @file tasks.go contains the tasks the endpoints start after responding, which the Server waits for when it shuts down
+package server
+
+import (
+	"context"
+	"relief_exchange_backend/logging"
+	"relief_exchange_backend/tracing"
+	"sync"
+)
+
+// tasks are the one-off tasks the endpoints start after responding, e.g. generating an export that was just requested.
+type tasks struct {
+	ctx     context.Context // Cancelled when the tasks in progress have to stop
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+	stopped bool // Set once the Server shuts down, so no more are started
+	wg      sync.WaitGroup
+}
+
+// newTasks creates the tasks of a Server, before any have started.
+func newTasks() *tasks {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &tasks{ctx: ctx, cancel: cancel}
+}
+
+// runTask runs a task after the request that started it has been responded to.
+// It stays in the request's trace and keeps its logger, but isn't cancelled along with it.
+// Once the Server is shutting down tasks aren't started, they're left to the background jobs,
+// which pick up whatever was queued up the next time the Server starts.
+// Parameters:
+//   - ctx: the context of the request starting the task.
+//   - name: what the task does, for the logs.
+//   - task: the task, which is logged if it fails.
+func (s *Server) runTask(ctx context.Context, name string, task func(ctx context.Context) error) {
+	logger := logging.FromContext(ctx).WithField("task", name)
+	s.tasks.mu.Lock()
+	defer s.tasks.mu.Unlock()
+	if s.tasks.stopped {
+		logger.Warn("shutting down, the task is left to the background jobs")
+		return
+	}
+
+	s.tasks.wg.Add(1)
+	ctx, cancel := context.WithCancel(logging.NewContext(tracing.Detach(ctx), logger))
+	go func() {
+		defer s.tasks.wg.Done()
+		defer cancel()
+		// Cancelled along with every other task if the Server can't wait for it to finish
+		go func() {
+			select {
+			case <-s.tasks.ctx.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		if err := task(ctx); err != nil {
+			logger.Error(err.Error())
+		}
+	}()
+}
+
+// ShutdownTasks stops tasks from starting, then waits for the ones in progress to finish.
+// If ctx is done first they're cancelled, and the background jobs finish them on the next start,
+// since the tasks claim their work and resume where they stopped.
+// It should be called once the web server has stopped taking requests, so none are started after it.
+// Return values:
+//   - ctx's error if the tasks in progress had to be cancelled, nil otherwise.
+func (s *Server) ShutdownTasks(ctx context.Context) error {
+	s.tasks.mu.Lock()
+	s.tasks.stopped = true
+	s.tasks.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.tasks.wg.Wait()
+		close(done)
+	}()
+
+	defer s.tasks.cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
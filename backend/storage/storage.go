@@ -0,0 +1,25 @@
+// Package storage provides places to store uploaded files, such as donation images.
+// Files are stored under a key (a slash-separated path), and can be read back through
+// the URL returned when they're stored.
+package storage
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned when a file doesn't exist in a store.
+var ErrNotFound = errors.New("file not found")
+
+// Store is somewhere uploaded files can be kept.
+type Store interface {
+	// Put stores a file under a key, replacing any file already there.
+	// It returns the URL that the file can be downloaded from.
+	Put(ctx context.Context, key string, data []byte, contentType string) (string, error)
+
+	// Get reads back the contents of a file.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Delete removes a file. Deleting a file that doesn't exist isn't an error.
+	Delete(ctx context.Context, key string) error
+}
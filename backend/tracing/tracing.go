@@ -0,0 +1,150 @@
+// @cite "OpenTelemetry Go: Getting Started." OpenTelemetry, 2023. [Online].
+// Available: https://opentelemetry.io/docs/instrumentation/go/getting-started/. [Accessed: 19- October- 2026].
+// @cite "Trace Context." W3C Recommendation, 2021. [Online].
+// Available: https://www.w3.org/TR/trace-context/. [Accessed: 19- October- 2026].
+// This is a file in the package-"tracing" that sets up OpenTelemetry tracing and the spans made by the backend.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"relief_exchange_backend/config"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// instrumentationName names the tracer every span of the backend is made with
+const instrumentationName = "relief_exchange_backend"
+
+// Keys of the attributes put on spans
+const (
+	UIDKey        = attribute.Key("enduser.id")
+	DonationIDKey = attribute.Key("relief_exchange.donation_id")
+)
+
+// UID gets the attribute for the UID of the user a span is for.
+func UID(uid string) attribute.KeyValue {
+	return UIDKey.String(uid)
+}
+
+// DonationID gets the attribute for the ID of the donation a span is about.
+func DonationID(id string) attribute.KeyValue {
+	return DonationIDKey.String(id)
+}
+
+// Init sets up the global tracer provider and W3C trace context propagation.
+// With the "none" exporter spans are still made, so incoming trace context is passed on, but they aren't sent anywhere.
+// Parameters:
+//   - ctx: the context used to connect to the collector.
+//   - cfg: the tracing settings.
+//
+// Return values:
+//   - a function that sends any spans not sent yet and stops the exporter, to call on shutdown.
+//   - error, if the exporter couldn't be created.
+func Init(ctx context.Context, cfg config.TracingConfig) (func(context.Context) error, error) {
+	// Accept the traceparent and tracestate headers sent by the frontend, and send them on
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "otlp":
+		var options []otlptracegrpc.Option
+		if cfg.OTLPEndpoint != "" {
+			options = append(options, otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint))
+		}
+		if cfg.Insecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, options...)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	case "none":
+	default:
+		err = fmt.Errorf("unknown exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed creating trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed creating trace resource: %w", err)
+	}
+
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		// Spans inside the backend follow their parent's decision, so traces aren't cut in half.
+		// Requests are sampled at the sample rate even if their traceparent says they're sampled,
+		// since the frontend and other clients can't be trusted to decide how much is traced.
+		sdktrace.WithSampler(sdktrace.ParentBased(
+			sdktrace.TraceIDRatioBased(cfg.SampleRate),
+			sdktrace.WithRemoteParentSampled(sdktrace.TraceIDRatioBased(cfg.SampleRate)),
+			sdktrace.WithRemoteParentNotSampled(sdktrace.TraceIDRatioBased(cfg.SampleRate)),
+		)),
+	}
+	if exporter != nil {
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+	provider := sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Start starts a span as a child of the one in ctx.
+// Parameters:
+//   - ctx: the context of the caller.
+//   - name: the name of the span, e.g. "helpers.AddDonation".
+//   - attributes: the attributes to put on the span, like the UID and donation ID it's about.
+//
+// Return values:
+//   - ctx with the new span in it, to pass to anything called during the span.
+//   - the span, which has to be ended.
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// StartClient starts a span for a call to another service, like Firebase Auth.
+func StartClient(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attributes...), trace.WithSpanKind(trace.SpanKindClient))
+}
+
+// SetAttributes adds attributes to the span in ctx, e.g. the request's span once its UID is known.
+func SetAttributes(ctx context.Context, attributes ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).SetAttributes(attributes...)
+}
+
+// RecordError marks a span as failed with err, if there is one.
+func RecordError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// FirestoreDialOptions gets the gRPC options that make a span for every Firestore call.
+func FirestoreDialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(otelgrpc.StreamClientInterceptor()),
+	}
+}
+
+// Detach gets a context in the same trace as ctx that isn't cancelled along with it,
+// for work that carries on after a request has been responded to.
+func Detach(ctx context.Context) context.Context {
+	return trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+}
@@ -0,0 +1,32 @@
+package types
+
+import (
+	"time"
+)
+
+// Steps of deleting an account, in the order they're run
+const (
+	DeletionStepDonations = "donations"
+	DeletionStepReports   = "reports"
+	DeletionStepImages    = "images"
+	DeletionStepExports   = "exports"
+	DeletionStepProfile   = "profile"
+	DeletionStepAuth      = "auth"
+)
+
+// DeletionSteps includes every step of deleting an account, in the order they're run.
+var DeletionSteps = []string{DeletionStepDonations, DeletionStepReports, DeletionStepImages, DeletionStepExports, DeletionStepProfile, DeletionStepAuth}
+
+// AccountDeletion represents the progress of deleting all traces of a user's account.
+// Each step is safe to run again, so a deletion that was interrupted picks up where it left off.
+type AccountDeletion struct {
+	ID             string         `json:"id"`
+	UID            string         `json:"uid,omitempty"`
+	Status         string         `json:"status"` // One of the JobStatus constants
+	CompletedSteps []string       `json:"completed_steps"`
+	Progress       map[string]int `json:"progress"` // Number of items handled so far in each step
+	Attempts       int64          `json:"attempts"`
+	RequestedAt    time.Time      `json:"requested_at"`           // In UTC
+	CompletedAt    *time.Time     `json:"completed_at,omitempty"` // In UTC
+	Error          string         `json:"error,omitempty"`
+}
@@ -0,0 +1,16 @@
+package types
+
+import (
+	"time"
+)
+
+// BanRecord represents the details of a ban.
+// It includes the banned user's UID, the admin who banned them, the reason,
+// when the ban was made and when it expires, if ever.
+type BanRecord struct {
+	UID       string     `json:"uid"`
+	BannedBy  string     `json:"banned_by"`
+	Reason    string     `json:"reason"`
+	BannedAt  time.Time  `json:"banned_at"`            // In UTC
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // In UTC, nil if the ban is permanent
+}
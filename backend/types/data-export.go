@@ -0,0 +1,17 @@
+package types
+
+import (
+	"time"
+)
+
+// DataExport represents a request by a user for a copy of all their data.
+// The export is generated in the background, and can be downloaded once its status is complete.
+type DataExport struct {
+	ID          string     `json:"id"`
+	UID         string     `json:"uid"`
+	Status      string     `json:"status"`                 // One of the JobStatus constants
+	RequestedAt time.Time  `json:"requested_at"`           // In UTC
+	CompletedAt *time.Time `json:"completed_at,omitempty"` // In UTC
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`   // In UTC, when the download is deleted
+	Error       string     `json:"error,omitempty"`
+}
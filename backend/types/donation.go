@@ -5,16 +5,42 @@ import (
 )
 
 // Donation represents a donation item.
-// It includes information about the item like title, description, location, image,
-// creation timestamp, owner's id, tags, and reports.
+// It includes information about the item like title, description, location, images,
+// creation timestamp, owner's id, tags, reports, and flags raised for moderators.
+// Fields are tagged with the names they're stored under in Firestore, so documents can be read with DataTo.
 type Donation struct {
-	ID                string    `json:"id"`
-	Title             string    `json:"title"`
-	Description       string    `json:"description"`
-	Location          string    `json:"location"`
-	Image             string    `json:"img"`
-	CreationTimestamp time.Time `json:"creation_timestamp"` // In UTC
-	OwnerId           string    `json:"owner_id"`
-	Tags              []string  `json:"tags"`
-	Reports           []string  `json:"reports"` // Includes the UIDs of every person who reported it
+	ID                string          `json:"id" firestore:"-"`
+	Title             string          `json:"title"`
+	Description       string          `json:"description"`
+	Location          string          `json:"location"`
+	Image             string          `json:"img" firestore:"img"`                               // URL of the first image, kept for older clients
+	Images            []DonationImage `json:"images" firestore:"-"`                              // In the order they're shown
+	CreationTimestamp time.Time       `json:"creation_timestamp" firestore:"creation_timestamp"` // In UTC
+	OwnerId           string          `json:"owner_id" firestore:"owner_id"`
+	Tags              []string        `json:"tags"`
+	Reports           []string        `json:"reports"`                                         // Includes the UIDs of every person who reported it
+	Flags             []string        `json:"flags"`                                           // Raised automatically, see the DonationFlag constants
+	DuplicateOf       string          `json:"duplicate_of,omitempty" firestore:"duplicate_of"` // ID of the donation it was flagged as a duplicate of
+}
+
+// Flags raised automatically on a donation for moderators to look at.
+const (
+	DonationFlagBannedImage      = "banned_image"      // Uses a photo close to one from a removed donation or banned user
+	DonationFlagDuplicateListing = "duplicate_listing" // Nearly the same as another donation, see DuplicateOf
+)
+
+// DonationImage represents one of the images of a donation, along with its alt text.
+type DonationImage struct {
+	ImageID      string `json:"image_id,omitempty"` // Empty for images uploaded before uploads went through the backend
+	URL          string `json:"url"`
+	MediumURL    string `json:"medium_url,omitempty"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	AltText      string `json:"alt"`
+}
+
+// DonationPage represents one page of a list of donations.
+// NextCursor is empty when there are no more pages.
+type DonationPage struct {
+	Donations  []Donation `json:"donations"`
+	NextCursor string     `json:"next_cursor"`
 }
@@ -0,0 +1,19 @@
+package types
+
+import (
+	"time"
+)
+
+// Image represents an image uploaded through the backend.
+// Every image is stored in several sizes (renditions), each with its own URL.
+// All metadata (including EXIF location data) is removed before it's stored.
+type Image struct {
+	ID           string    `json:"id"`
+	OwnerId      string    `json:"owner_id"`
+	URL          string    `json:"url"`           // Largest rendition
+	MediumURL    string    `json:"medium_url"`    // For listing pages
+	ThumbnailURL string    `json:"thumbnail_url"` // For cards and previews
+	Width        int       `json:"width"`         // Of the largest rendition
+	Height       int       `json:"height"`        // Of the largest rendition
+	CreatedAt    time.Time `json:"created_at"`    // In UTC
+}
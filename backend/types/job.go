@@ -0,0 +1,10 @@
+package types
+
+// Statuses that a background job (e.g. a data export or an account deletion) goes through
+const (
+	JobStatusPending  = "pending"
+	JobStatusRunning  = "running"
+	JobStatusComplete = "complete"
+	JobStatusFailed   = "failed"
+	JobStatusExpired  = "expired"
+)
@@ -0,0 +1,24 @@
+package types
+
+// Me represents everything the frontend needs to know about the signed-in user.
+// It includes their profile, roles, ban status, activity counts, and any actions
+// they still have to take before using the platform fully.
+type Me struct {
+	UID             string     `json:"uid"`
+	Email           string     `json:"email"`
+	EmailVerified   bool       `json:"email_verified"`
+	Profile         *UserData  `json:"profile"` // nil if they haven't been added to the database yet
+	Roles           []string   `json:"roles"`
+	Banned          bool       `json:"banned"`
+	Ban             *BanRecord `json:"ban,omitempty"`
+	DonationsMade   int64      `json:"donations_made"`
+	ActiveDonations int        `json:"active_donations"`
+	ReportsFiled    int        `json:"reports_filed"`
+	PendingActions  []string   `json:"pending_actions"`
+}
+
+// Actions that a user may still have to take, returned in Me.PendingActions.
+const (
+	PendingActionCreateProfile = "create_profile"
+	PendingActionVerifyEmail   = "verify_email"
+)
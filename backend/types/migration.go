@@ -0,0 +1,21 @@
+package types
+
+import (
+	"time"
+)
+
+// MigrationProgress represents how far a schema migration has got through its collection.
+// It's stored in the migrations collection, so a migration that stopped carries on where it left off.
+type MigrationProgress struct {
+	Version     int        `json:"version"`
+	Collection  string     `json:"collection"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"`                 // One of the JobStatus constants, pending if it hasn't started
+	Scanned     int        `json:"scanned"`                // How many documents have been looked at
+	Migrated    int        `json:"migrated"`               // How many documents were changed, or would be in a dry run
+	LastDocID   string     `json:"last_doc_id,omitempty"`  // The last document looked at, which the next run starts after
+	DryRun      bool       `json:"dry_run,omitempty"`      // Nothing was written
+	StartedAt   *time.Time `json:"started_at,omitempty"`   // In UTC
+	CompletedAt *time.Time `json:"completed_at,omitempty"` // In UTC
+	Error       string     `json:"error,omitempty"`
+}
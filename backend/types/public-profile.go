@@ -0,0 +1,69 @@
+package types
+
+import (
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// Optional fields of a user's data that they can choose to show on their public profile.
+const (
+	PublicFieldEmail  = "email"
+	PublicFieldBio    = "bio"
+	PublicFieldAvatar = "avatar"
+	PublicFieldArea   = "area"
+)
+
+// OptionalPublicFields includes every field a user can choose to make public.
+var OptionalPublicFields = []string{PublicFieldEmail, PublicFieldBio, PublicFieldAvatar, PublicFieldArea}
+
+// DefaultPublicFields are the optional fields that are public for users who haven't chosen any.
+// It's empty, so none of them are shown until a user opts in.
+var DefaultPublicFields = []string{}
+
+// PublicProfile represents the part of a user's data that anyone can see.
+// It includes display name, join date, donation counts, the IDs of their donations,
+// and any optional fields they chose to make public.
+type PublicProfile struct {
+	UID                   string             `json:"uid"`
+	DisplayName           string             `json:"display_name"`
+	RegistrationTimestamp time.Time          `json:"registered_date"` // In UTC
+	DonationsMade         int64              `json:"donations_made"`
+	ActiveDonations       int                `json:"active_donations"`
+	DonationIDs           []string           `json:"donation_ids"`
+	ContactPreferences    ContactPreferences `json:"contact_preferences"`
+	Email                 string             `json:"email,omitempty"`  // Optional
+	Bio                   string             `json:"bio,omitempty"`    // Optional
+	AvatarURL             string             `json:"avatar,omitempty"` // Optional
+	Area                  string             `json:"area,omitempty"`   // Optional
+}
+
+// ToPublicProfile projects a user's data down to what anyone is allowed to see.
+func (u UserData) ToPublicProfile() PublicProfile {
+	profile := PublicProfile{
+		UID:                   u.UID,
+		DisplayName:           u.DisplayName,
+		RegistrationTimestamp: u.RegistrationTimestamp,
+		DonationsMade:         u.DonationsMade,
+		ActiveDonations:       len(u.Posts),
+		DonationIDs:           u.DonationIDs,
+		ContactPreferences:    u.ContactPreferences,
+	}
+
+	// Only add the optional fields that the user chose to show.
+	// Their email is also hidden if they don't want to be contacted through it.
+	if slices.Contains(u.PublicFields, PublicFieldEmail) && u.ContactPreferences.Email {
+		profile.Email = u.Email
+	}
+	if slices.Contains(u.PublicFields, PublicFieldBio) {
+		profile.Bio = u.Bio
+	}
+	if slices.Contains(u.PublicFields, PublicFieldAvatar) {
+		profile.AvatarURL = u.AvatarURL
+	}
+	if slices.Contains(u.PublicFields, PublicFieldArea) {
+		profile.Area = u.Area
+	}
+
+	return profile
+}
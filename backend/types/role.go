@@ -0,0 +1,21 @@
+package types
+
+// Roles that can be granted to a user. Roles are stored in the "roles" field
+// of a user's document and mirrored into their Firebase custom claims so ID
+// tokens carry them. Moderators can take down donations and ban users, and
+// admins can do anything moderators can, along with managing roles.
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+)
+
+// ValidRoles includes every role that can be granted through the admin endpoints.
+var ValidRoles = []string{RoleAdmin, RoleModerator}
+
+// PrivilegedUser represents a user that holds at least one role.
+type PrivilegedUser struct {
+	UID         string   `json:"uid"`
+	DisplayName string   `json:"display_name"`
+	Email       string   `json:"email"`
+	Roles       []string `json:"roles"`
+}
@@ -7,14 +7,40 @@ import (
 )
 
 // UserData represents a user's data.
-// It includes display name, email, registration timestamp, admin status, user's posts,
-// UID and count of donations made.
+// It includes display name, email, registration timestamp, admin status, roles, user's posts,
+// UID, count of donations made, which optional fields are shown on their public profile,
+// and the profile details they chose themselves (bio, avatar, general area and contact preferences).
+// Posts are kept as document references internally, and only their IDs are sent to clients.
+// Fields are tagged with the names they're stored under in Firestore, so documents can be read with DataTo.
 type UserData struct {
-	DisplayName           string                   `json:"display_name"`
+	DisplayName           string                   `json:"display_name" firestore:"display_name"`
 	Email                 string                   `json:"email"`
-	RegistrationTimestamp time.Time                `json:"registered_date"` // In UTC
+	RegistrationTimestamp time.Time                `json:"registered_date" firestore:"registered_date"` // In UTC
 	Admin                 bool                     `json:"admin"`
-	Posts                 []*firestore.DocumentRef `json:"posts"`
-	UID                   string                   `json:"uid"`
-	DonationsMade         int64                    `json:"donations_made"`
+	Roles                 []string                 `json:"roles"`
+	Posts                 []*firestore.DocumentRef `json:"-"`
+	DonationIDs           []string                 `json:"donation_ids" firestore:"-"`
+	UID                   string                   `json:"uid" firestore:"-"`
+	DonationsMade         int64                    `json:"donations_made" firestore:"donations_made"`
+	PublicFields          []string                 `json:"public_fields" firestore:"public_fields"`
+	Bio                   string                   `json:"bio"`
+	AvatarURL             string                   `json:"avatar" firestore:"avatar"`
+	Area                  string                   `json:"area"` // General area, never an exact address
+	ContactPreferences    ContactPreferences       `json:"contact_preferences" firestore:"contact_preferences"`
+}
+
+// ContactPreferences represents how a user would like to be contacted about their donations.
+type ContactPreferences struct {
+	Email bool   `json:"email"` // Whether they can be contacted by email
+	Note  string `json:"note"`  // Free-form details, e.g. "weekday evenings only"
+}
+
+// ProfileUpdate represents a change to a user's profile.
+// Fields that are nil are left unchanged.
+type ProfileUpdate struct {
+	DisplayName        *string             `json:"display_name"`
+	Bio                *string             `json:"bio"`
+	AvatarURL          *string             `json:"avatar"`
+	Area               *string             `json:"area"`
+	ContactPreferences *ContactPreferences `json:"contact_preferences"`
 }
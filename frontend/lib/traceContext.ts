@@ -0,0 +1,36 @@
+/**
+ * @file Sends W3C trace context with every request to the backend, so its traces can be
+ * matched up with the page action that caused them.
+ * @cite "Trace Context," W3C, https://www.w3.org/TR/trace-context/.
+ */
+
+import axios from "axios";
+
+/**
+ * Gets a random lowercase hex string
+ * @param bytes number of random bytes
+ * @returns the bytes written as hex
+ */
+function randomHex(bytes: number) {
+    const values = new Uint8Array(bytes);
+    globalThis.crypto.getRandomValues(values);
+    return Array.from(values, value => value.toString(16).padStart(2, "0")).join("");
+}
+
+/**
+ * Makes a traceparent header starting a new trace. It's flagged as sampled, but the backend
+ * decides whether to keep it using its own sample rate.
+ * @returns the header's value
+ */
+export function newTraceparent() {
+    return `00-${randomHex(16)}-${randomHex(8)}-01`;
+}
+
+// Add a traceparent to every backend request that doesn't already have one
+axios.interceptors.request.use(config => {
+    const backendBaseURL = process.env.NEXT_PUBLIC_BACKEND_BASE_URL;
+    if (backendBaseURL && config.url?.startsWith(backendBaseURL) && !config.headers.has("traceparent")) {
+        config.headers.set("traceparent", newTraceparent());
+    }
+    return config;
+});
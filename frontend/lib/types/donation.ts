@@ -1,3 +1,5 @@
+import DonationImage from "./donationImage"
+
 /**
  * Data schema for a Donation.
  */
@@ -6,9 +8,12 @@ export default interface Donation {
     title: string,
     description: string, // markdown
     location: string,
-    img: string, // direct src to firebase image
+    img: string, // src of the first image, kept for older donations
+    images: DonationImage[], // in the order they're shown
     creation_timestamp: Date,
     tags: string[] | null,
     owner_id: string,
-    reports: string[]
+    reports: string[],
+    flags?: string[], // raised automatically for moderators
+    duplicate_of?: string // id of the donation it was flagged as a duplicate of
 }
\ No newline at end of file
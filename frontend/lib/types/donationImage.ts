@@ -0,0 +1,10 @@
+/**
+ * Data schema for one of a Donation's images.
+ */
+export default interface DonationImage {
+    image_id?: string,
+    url: string,
+    medium_url?: string,
+    thumbnail_url?: string,
+    alt: string // description for screen readers
+}
@@ -1,13 +1,13 @@
-import { DocumentReference } from "firebase/firestore"
-
 /**
  * Data schema for User Data, separate from the user data directly from our authentication server.
  */
 export default interface UserData {
     display_name: string,
-    email: string,
+    email?: string,
     registered_date: string,
     admin: string,
-    posts: DocumentReference[],
-    donations_made: Number
+    roles: string[],
+    donation_ids: string[],
+    donations_made: Number,
+    public_fields: string[]
 }
\ No newline at end of file
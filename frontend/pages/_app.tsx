@@ -15,6 +15,7 @@ import axios from "axios";
 
 import auth from "@lib/firebase/auth";
 import convertBackendRouteToURL from "@lib/convertBackendRouteToURL";
+import "@lib/traceContext";
 
 import "../styles/globals.css";
 
@@ -35,9 +36,14 @@ export default function App({ Component, pageProps }) {
             if (user && Object.keys(user).length !== 0) {
                 // Kick the user off if they're banned
                 const run = async () => {
-                    const bannedRes = await axios.get(convertBackendRouteToURL(`/users/banned?uid=${user.uid}`))
-                    if (bannedRes.data.banned) {
-                        alert("You have been banned from our platform for breaking our rules. As such, you are not allowed to sign in.")
+                    const meRes = await axios.get(convertBackendRouteToURL("/me"), {
+                        headers: {
+                            Authorization: `Bearer ${await user.getIdToken()}`
+                        }
+                    })
+                    if (meRes.data.banned) {
+                        const reason = meRes.data.ban?.reason ? ` Reason: ${meRes.data.ban.reason}` : ""
+                        alert(`You have been banned from our platform for breaking our rules. As such, you are not allowed to sign in.${reason}`)
                         await signOut(auth)
                     }
                 }
@@ -66,9 +66,13 @@ export default function EditDonation({ originalDonation }: { originalDonation: R
             // Only run if user is signed-in
             if (newUser && Object.keys(newUser).length !== 0) {
                 // Check whether user is admin to ensure they are allowed to access this page
-                axios.get(convertBackendRouteToURL(`/users/admin?uid=${newUser.uid}`)).then(res => {
+                newUser.getIdToken().then(idToken => axios.get(convertBackendRouteToURL("/me"), {
+                    headers: {
+                        Authorization: `Bearer ${idToken}`
+                    }
+                })).then(res => {
                     // Don't allow if they're not an admin or original author
-                    if (newUser.uid !== originalDonation.owner_id && !res.data["admin"]) {
+                    if (newUser.uid !== originalDonation.owner_id && !res.data.roles.includes("admin")) {
                         alert("You cannot edit this post, as you are not its author. Redirecting...")
                         router.push("/")
                     } else {
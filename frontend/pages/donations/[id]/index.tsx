@@ -15,6 +15,7 @@ import { useRouter } from "next/router";
 import axios from "axios";
 import { ParsedUrlQuery } from 'querystring'
 import ReactMarkdown from "react-markdown";
+import ReCAPTCHA from "react-google-recaptcha"
 import { User, onAuthStateChanged } from "firebase/auth";
 
 import Layout from "@components/Layout";
@@ -104,20 +105,30 @@ export default function DonationSpecificPage({ rawDonation }) {
     // State vars
     const [user, setUser] = useState<User>(null);
     const [isAdmin, setIsAdmin] = useState(false);
+    const [isModerator, setIsModerator] = useState(false);
     const [performingAction, setPerformingAction] = useState(false);
+    const [reportCAPTCHAShown, setReportCAPTCHAShown] = useState(false);
+
+    // Site key for using Google ReCAPTCHA v2
+    const RECAPTCHA_SITE_KEY = process.env.NEXT_PUBLIC_RECAPTCHA_SITE_KEY;
 
     /**
      * Sends a request to send a report regarding this post.
      */
-    const sendReport = async () => {
+    const sendReport = async (captchaToken: string | null) => {
+        // Nothing to send if the CAPTCHA expired
+        if (!captchaToken) return;
+
         // Freeze other actions while performing this
         setPerformingAction(true)
+        setReportCAPTCHAShown(false)
 
         try {
             // Try sending a request to report
             await axios.post(convertBackendRouteToURL("/donations/report"), {
                 donation_id: donation.id,
-                token: await user.getIdToken()
+                token: await user.getIdToken(),
+                captcha: captchaToken
             })
 
             alert("The post has been successfully reported. Thank you for helping us keep ReliefExchange clean.")
@@ -125,6 +136,8 @@ export default function DonationSpecificPage({ rawDonation }) {
             // Alert user of error and proceed
             if (e.response.status === 409) {
                 alert("You have already reported this post. You cannot report it again.");
+            } else if (e.response.status === 403) {
+                alert("The CAPTCHA could not be verified. Please try again.");
             } else {
                 console.error(e)
                 alert("Something went wrong while reporting this post. Please try again later.")
@@ -209,8 +222,14 @@ export default function DonationSpecificPage({ rawDonation }) {
 
                 // Check if user is admin by getting their user data
                 try {
-                    axios.get(convertBackendRouteToURL(`/users/${newUser.uid}`)).then(res => {
-                        setIsAdmin(!!res.data.admin)
+                    newUser.getIdToken().then(idToken => axios.get(convertBackendRouteToURL("/me"), {
+                        headers: {
+                            Authorization: `Bearer ${idToken}`
+                        }
+                    })).then(res => {
+                        setIsAdmin(res.data.roles.includes("admin"))
+                        // Admins can do anything moderators can
+                        setIsModerator(res.data.roles.includes("admin") || res.data.roles.includes("moderator"))
                     })
                 } catch (e) {
                     // Silently record the error
@@ -244,14 +263,14 @@ export default function DonationSpecificPage({ rawDonation }) {
                                 <button
                                     className="flex items-center text-red-500 hover:text-red-600 active:text-red-700 disabled:text-red-900 duration-150"
                                     disabled={performingAction}
-                                    onClick={() => sendReport()}
+                                    onClick={() => setReportCAPTCHAShown(true)}
                                 >
                                     Report
                                     <FiFlag className="ml-1" />
                                 </button>
                             }
 
-                            {user && (user.uid === donation.owner_id || isAdmin) &&
+                            {user && (user.uid === donation.owner_id || isModerator) &&
                                 <button
                                     className="flex items-center text-red-500 hover:text-red-600 active:text-red-700 disabled:text-red-900 duration-150"
                                     disabled={performingAction}
@@ -263,7 +282,20 @@ export default function DonationSpecificPage({ rawDonation }) {
                             }
                         </div>
 
-                        {donation.img ? <Image src={donation.img} alt="Featured image" height={500} width={500} className="rounded-md object-cover object-center" /> : <></>}
+                        {reportCAPTCHAShown && (
+                            <div className="flex flex-col items-end gap-1">
+                                <span className="text-white">Complete the CAPTCHA to send your report.</span>
+                                <ReCAPTCHA sitekey={RECAPTCHA_SITE_KEY} onChange={token => sendReport(token)} />
+                            </div>
+                        )}
+
+                        {donation.images?.length ? (
+                            <div className="flex flex-col gap-2">
+                                {donation.images.map((image, i) => (
+                                    <Image key={image.image_id || i} src={image.url} alt={image.alt} height={500} width={500} className="rounded-md object-cover object-center" />
+                                ))}
+                            </div>
+                        ) : donation.img ? <Image src={donation.img} alt="Featured image" height={500} width={500} className="rounded-md object-cover object-center" /> : <></>}
 
                         <div className="flex gap-2 justify-between mb-2 w-full">
                             {user && (user.uid === donation.owner_id || isAdmin) && (
@@ -276,7 +308,7 @@ export default function DonationSpecificPage({ rawDonation }) {
                                 </Link>
                             )}
 
-                            {user && isAdmin && (
+                            {user && isModerator && (
                                 <>
                                     <button
                                         className="flex items-center text-red-500 hover:text-red-600 active:text-red-700 disabled:text-red-900 duration-150"
@@ -312,7 +344,9 @@ export default function DonationSpecificPage({ rawDonation }) {
 
                         <br />
 
-                        <a className="py-2 px-4 bg-blue-500 font-semibold text-center text-white rounded-lg hover:bg-blue-600 duration-75" href={`mailto:${donation.owner.email}`}>Contact {donation.owner.display_name} for More Info</a>
+                        {donation.owner.email && (
+                            <a className="py-2 px-4 bg-blue-500 font-semibold text-center text-white rounded-lg hover:bg-blue-600 duration-75" href={`mailto:${donation.owner.email}`}>Contact {donation.owner.display_name} for More Info</a>
+                        )}
                     </div>
                 </div>
             </div>
@@ -6,7 +6,6 @@
  * @cite “Docs,” Docs | Next.js, https://nextjs.org/docs. 
  * @cite React, https://react.dev/. 
  * @cite D. Omotayo, “How to implement ReCAPTCHA in a React application,” LogRocket Blog, https://blog.logrocket.com/implement-recaptcha-react-application/. 
- * @cite “Upload files with cloud storage on web | cloud storage for firebase,” Google, https://firebase.google.com/docs/storage/web/upload-files. 
  */
 
 import { useState, useEffect, useRef, FormEventHandler } from "react";
@@ -15,14 +14,12 @@ import dynamic from "next/dynamic";
 
 import axios from "axios";
 import { getIdToken, onAuthStateChanged, User } from "firebase/auth";
-import { getDownloadURL, ref, uploadBytes } from "firebase/storage";
 import ReCAPTCHA from "react-google-recaptcha"
 import Multiselect from 'multiselect-react-dropdown';
 import * as commands from "@uiw/react-md-editor/lib/commands";
 
 import Layout from "@components/Layout";
 import auth from "@lib/firebase/auth";
-import storage from "@lib/firebase/storage";
 import allTags from "@lib/tag-types";
 import convertBackendRouteToURL from "@lib/convertBackendRouteToURL";
 
@@ -99,38 +96,30 @@ export default function CreateDonation() {
         // Freeze submit button
         setSubmitting(true);
 
-        // Confirm the CAPTCHA with the server
-        const token = captchaRef.current.getValue();
+        // Get the CAPTCHA token, which the server checks along with the donation
+        const captchaToken = captchaRef.current.getValue();
         captchaRef.current.reset();
-        if (!token) {
+        if (!captchaToken) {
             alert("Please complete the CAPTCHA and try again.");
             setSubmitting(false);
             return;
         }
-        try {
-            // Send request to server to get result of CAPTCHA
-            const res = await axios.post(convertBackendRouteToURL(`/confirmCAPTCHA?token=${token}`))
-            if (!res.data.human) throw "User was detected to be a bot by ReCAPTCHA."
-        } catch (e) {
-            // Handle error, let user know of problem
-            alert("Something went wrong. Please try again.");
-            console.error(e);
-            setSubmitting(false);
-
-            // Don't proceed with rest of donation adding process
-            return
-        }
 
-        // CAPTCHA confirmed, now upload the image to Firebase Storage
-        let imgLink = ""
+        // CAPTCHA completed, now upload the image through the backend, which checks it before it can be posted
+        const idToken = await getIdToken(user, true);
+        const images = [];
         if (featuredImage.length !== 0) {
-            // Create a reference to an image with a random name
-            const imgRef = ref(storage, `donations/${crypto.randomUUID()}.jpg`);
+            const imageForm = new FormData();
+            imageForm.append("image", featuredImage[0]);
 
             try {
-                // Try uploading the image and getting its public URL
-                const imgSnapshot = await uploadBytes(imgRef, featuredImage[0]);
-                imgLink = await getDownloadURL(imgSnapshot.ref);
+                // Try uploading the image and getting its ID
+                const uploadRes = await axios.post(convertBackendRouteToURL("/images"), imageForm, {
+                    headers: {
+                        Authorization: `Bearer ${idToken}`
+                    }
+                });
+                images.push({ "image_id": uploadRes.data.id, "alt": formData["product-name"] });
             } catch (e) {
                 // Let user know of specific issue
                 alert("Something went wrong while uploading your image. Please try again, and make sure that your image is <=10MB.");
@@ -149,12 +138,11 @@ export default function CreateDonation() {
             date.getUTCMinutes(), date.getUTCSeconds()));
 
         // Image uploaded, now prepare the data to send to endpoint
-        const idToken = await getIdToken(user, true);
         const donationData = {
             "title": formData["product-name"],
             "description": descriptionMD,
             "location": formData["product-location"],
-            "img": imgLink,
+            "images": images,
             "tags": tagsSelected.map(obj => obj.name),
             "creation_timestamp": nowUTC.toISOString(),
             "ownerID": user.uid
@@ -164,13 +152,24 @@ export default function CreateDonation() {
         try {
             const apiRes = await axios.post(convertBackendRouteToURL("/donations/new"), {
                 data: donationData,
-                token: idToken
+                token: idToken,
+                captcha: captchaToken
             });
-            alert("Your donation was successfully submitted! Redirecting you to its page...");
+            if (apiRes.status === 200) {
+                alert("This looks the same as a donation you already posted, so it was added to that one instead. Redirecting you to its page...");
+            } else {
+                alert("Your donation was successfully submitted! Redirecting you to its page...");
+            }
             router.push(`/donations/${apiRes.data}`);
         } catch (e) {
             // Let user know of issue
-            alert("Something went wrong while submitting your donation. Please try again.");
+            if (axios.isAxiosError(e) && e.response?.status === 409) {
+                alert("This looks the same as a donation that's already posted. Please edit that donation instead.");
+            } else if (axios.isAxiosError(e) && e.response?.status === 403) {
+                alert("The CAPTCHA could not be verified. Please try again.");
+            } else {
+                alert("Something went wrong while submitting your donation. Please try again.");
+            }
             console.error(e);
         }
 
@@ -135,9 +135,13 @@ export default function DonationsIndex({ rawDonations }: { rawDonations: RawDona
             // Only run if user is signed in
             if (user && Object.keys(user).length !== 0) {
                 // Attempt to get the user's data from the backend
-                axios.get(convertBackendRouteToURL(`/users/${user.uid}`)).then(async res => {
-                    // Get admin attribute from data
-                    setIsAdmin(res.data && !!res.data.admin);
+                user.getIdToken().then(idToken => axios.get(convertBackendRouteToURL("/me"), {
+                    headers: {
+                        Authorization: `Bearer ${idToken}`
+                    }
+                })).then(async res => {
+                    // Get admin role from data
+                    setIsAdmin(res.data && res.data.roles.includes("admin"));
                 }).catch(err => {
                     // Silently log error
                     console.error(err);
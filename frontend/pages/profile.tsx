@@ -65,7 +65,7 @@ export default function UserProfile() {
 
                 signOut(auth)
 
-                alert("Your account has been deleted. Redirecting you to the home page...")
+                alert("Your account is being deleted, which may take a few minutes. Redirecting you to the home page...")
                 router.push("/")
             } catch (e) {
                 console.error(e)
@@ -89,37 +89,28 @@ export default function UserProfile() {
                 setUser(newUser);
 
                 // Attempt to get the user's data from the backend
-                axios.get(convertBackendRouteToURL(`/users/${newUser.uid}`)).then(async res => {
-                    let data = res.data;
-                    // Empty arrays evaluate to null from Firebase, when
-                    // they really should just be empty arrays.
-                    if (data.posts === null) {
-                        data.posts = []
+                newUser.getIdToken().then(idToken => axios.get(convertBackendRouteToURL(`/users/${newUser.uid}`), {
+                    headers: {
+                        Authorization: `Bearer ${idToken}`
                     }
+                })).then(async res => {
+                    let data = res.data;
 
-                    // Get data of each donation and replace the post's key with it
-                    data.posts = await Promise.all(data.posts.map(async (post: { ID: string }) => {
-                        // Get each donation
-                        try {
-                            // Get raw donation
-                            const res = await axios.get(convertBackendRouteToURL(`/donations/${post.ID}`));
-
-                            // Convert the ISO string date to an actual date object 
-                            const donation: Donation = {
-                                ...res.data,
-                                creation_timestamp: new Date(res.data.creation_timestamp)
-                            }
-
-                            return donation;
-                        } catch (e) {
-                            // Store a null object in the array, which we remove later
-                            console.warn("Couldn't get a donation from database: ", e);
-                            return null;
-                        }
-                    }));
-
-                    // Filter out all the donations we couldn't fetch
-                    data.posts = data.posts.filter((obj: any) => obj !== null)
+                    // Get the data of all their donations, one page at a time
+                    data.posts = []
+                    let cursor = ""
+                    do {
+                        const donationsRes = await axios.get(convertBackendRouteToURL(`/users/${newUser.uid}/donations`), {
+                            params: { cursor }
+                        })
+
+                        // Convert the ISO string date to an actual date object 
+                        data.posts.push(...donationsRes.data.donations.map((rawDonation: any) => ({
+                            ...rawDonation,
+                            creation_timestamp: new Date(rawDonation.creation_timestamp)
+                        } as Donation)))
+                        cursor = donationsRes.data.next_cursor
+                    } while (cursor)
 
                     // Sort by date descending
                     data.posts.sort((a: Donation, b: Donation) => -(a.creation_timestamp.getTime() - b.creation_timestamp.getTime()))
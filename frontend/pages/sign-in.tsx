@@ -7,7 +7,8 @@
  */
 
 import Image from "next/image";
-import { useEffect, useState } from "react"
+import { useEffect, useRef, useState } from "react"
+import ReCAPTCHA from "react-google-recaptcha"
 import { useRouter } from "next/router";
 
 import Layout from "@components/Layout";
@@ -20,19 +21,28 @@ import {
     browserLocalPersistence, 
     onAuthStateChanged, 
     getRedirectResult, 
-    signInWithRedirect 
+    signInWithRedirect,
+    User
 } from "firebase/auth";
 import axios from "axios";
 
 import GoogleLogo from "@media/social-media-logos/google.png";
 
 /**
- * The sign-in page. Handles the sign-in flow. Only accessible if the user isn't signed in.
+ * The sign-in page. Handles the sign-in flow. Signed in users are sent on to the home page,
+ * unless they still have to complete the CAPTCHA to finish creating their account.
  */
 export default function SignIn() {
     // State and hooks necessary for sign-in.
     const router = useRouter();
     const [signingIn, setSigningIn] = useState(false)
+    // Set once a signed in user turns out not to have a profile yet, so they're asked for the CAPTCHA
+    const [newUser, setNewUser] = useState<User>(null);
+    const [error, setError] = useState<string>(null);
+    const captchaRef = useRef(null);
+
+    // Site key for using Google ReCAPTCHA v2
+    const RECAPTCHA_SITE_KEY = process.env.NEXT_PUBLIC_RECAPTCHA_SITE_KEY;
 
     /**
      * Prompt the user to sign in using their Google account.
@@ -55,6 +65,57 @@ export default function SignIn() {
             // Log the error and let the user know
             console.error(e);
             alert("Something went wrong. Please try again.");
+            setSigningIn(false);
+        }
+    }
+
+    /**
+     * Send a signed in user to the home page, unless they still have to create their profile.
+     * Only new users are asked for a CAPTCHA, as are users whose profile couldn't be created last time.
+     */
+    const finishSignIn = async (user: User) => {
+        setError(null);
+        try {
+            const meRes = await axios.get(convertBackendRouteToURL("/me"), {
+                headers: {
+                    Authorization: `Bearer ${await user.getIdToken()}`
+                }
+            })
+            if (meRes.data.pending_actions?.includes("create_profile")) {
+                setNewUser(user);
+                return;
+            }
+            router.push("/");
+        } catch (e) {
+            console.error(e);
+            setError("We couldn't finish signing you in.");
+        }
+    }
+
+    /**
+     * Create the profile of a new user once they've completed the CAPTCHA, then send them to the home page.
+     */
+    const createProfile = async (captchaToken: string | null) => {
+        // Nothing to send if the CAPTCHA expired
+        if (!captchaToken) return;
+
+        setError(null);
+        try {
+            // Create a document for their user data in our Firestore DB
+            await axios.post(convertBackendRouteToURL("/users/new"), {
+                token: await newUser.getIdToken(),
+                captcha: captchaToken
+            })
+            router.push("/");
+        } catch (e) {
+            console.error(e);
+            if (e.response?.status === 403) {
+                setError("The CAPTCHA could not be verified.");
+            } else {
+                setError("Something went wrong while creating your account.");
+            }
+            // The CAPTCHA can only be used once, so they have to complete it again to retry
+            captchaRef.current?.reset();
         }
     }
 
@@ -64,30 +125,12 @@ export default function SignIn() {
     useEffect(() => {
         // Make sure to run this when the user first signs in
         (async () => {
-            // Create the provider class just like it was created when starting the log in flow
-            const provider = new GoogleAuthProvider();
-            provider.addScope("email");
-
             // Manage the results if there was a redirect
             const res = await getRedirectResult(auth);
             if (res !== null) {
                 // Disable sign-in button to prevent them starting another login flow
                 setSigningIn(true);
-
-                // Actually a redirect, handle sign-in
-                if (res.user.metadata.creationTime === res.user.metadata.lastSignInTime) {
-                    try {
-                        // Create a document for their user data in our Firestore DB if user is new
-                        await axios.post(convertBackendRouteToURL("/users/new"), {
-                            token: await res.user.getIdToken()
-                        })
-                    } catch (e) {
-                        console.error(e)
-                    }
-                }
-
-                // Redirect them to the home page
-                router.push("/");
+                await finishSignIn(res.user);
             } else {
                 // No previous log-in flow, let them use the page as normal (if they aren't logged in)
 
@@ -98,9 +141,9 @@ export default function SignIn() {
                 // since this will only run once anyways. I think...
                 onAuthStateChanged(auth, user => {
                     if (user) {
-                        // Redirect to home page if they're already signed in
-                        alert("You are already signed in! Redirecting...");
-                        router.push("/");
+                        // Send them on if they're already signed in, or let them finish creating their profile
+                        setSigningIn(true);
+                        finishSignIn(user);
                     }
                 });
             }
@@ -112,12 +155,29 @@ export default function SignIn() {
             <div className="flex flex-col flex-grow items-center justify-center">
                 <div className="flex flex-col items-center justify-center gap-2">
                     <h1 className="text-3xl font-semibold text-white mb-4">Log In / Sign Up</h1>
-                    <button className="flex flex-row gap-3 justify-center items-end bg-slate-700 hover:bg-slate-600 active:bg-slate-800 duration-150 px-5 py-3 rounded-lg w-full disabled:text-gray-200 disabled:bg-gray-800" onClick={() => { continueWithGoogle() }} disabled={signingIn}>
-                        <Image src={GoogleLogo} width={25} height={25} alt="" />
-                        <span className="text-xl text-white">
-                            Continue with Google
-                        </span>
-                    </button>
+                    {newUser ? (
+                        <>
+                            <span className="text-white">Complete the CAPTCHA to finish creating your account.</span>
+                            <ReCAPTCHA sitekey={RECAPTCHA_SITE_KEY} ref={captchaRef} onChange={token => createProfile(token)} />
+                        </>
+                    ) : (
+                        <button className="flex flex-row gap-3 justify-center items-end bg-slate-700 hover:bg-slate-600 active:bg-slate-800 duration-150 px-5 py-3 rounded-lg w-full disabled:text-gray-200 disabled:bg-gray-800" onClick={() => { continueWithGoogle() }} disabled={signingIn}>
+                            <Image src={GoogleLogo} width={25} height={25} alt="" />
+                            <span className="text-xl text-white">
+                                Continue with Google
+                            </span>
+                        </button>
+                    )}
+                    {error && (
+                        <div className="flex flex-col items-center gap-1">
+                            <span className="text-red-400">{error} {newUser ? "Please complete the CAPTCHA again." : ""}</span>
+                            {!newUser && signingIn && (
+                                <button className="text-white underline" onClick={() => { finishSignIn(auth.currentUser) }}>
+                                    Try again
+                                </button>
+                            )}
+                        </div>
+                    )}
                 </div>
             </div>
         </Layout>